@@ -0,0 +1,161 @@
+package flowmode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/service"
+	"github.com/grafana/agent/service/cluster"
+	httpservice "github.com/grafana/agent/service/http"
+	"github.com/grafana/agent/service/labelstore"
+	otel_service "github.com/grafana/agent/service/otel"
+	uiservice "github.com/grafana/agent/service/ui"
+	"github.com/grafana/river/diag"
+	"github.com/spf13/cobra"
+)
+
+func explainCommand() *cobra.Command {
+	e := &flowExplain{
+		minStability: featuregate.StabilityExperimental,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "explain [flags] path [path...]",
+		Short: "Explain the evaluation order of a configuration",
+		Long: `The explain subcommand loads a configuration without running it and prints
+the order in which the Flow controller evaluates its components and
+configuration blocks.
+
+Nodes are grouped into steps. Every node is evaluated after all nodes of the
+previous steps. Nodes within the same step don't reference each other, so
+their relative order isn't defined and they may be evaluated concurrently.
+
+The --changed flag prints which nodes are evaluated again when the given node
+changes its exports. The flag may be provided multiple times.
+
+Paths are combined in the same way as by the run subcommand. Modules are not
+loaded, so the components of modules aren't included.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := e.Run(cmd.OutOrStdout(), args...)
+
+			var diags diag.Diagnostics
+			if errors.As(err, &diags) {
+				p := diag.NewPrinter(diag.PrinterConfig{
+					Color:              !color.NoColor,
+					ContextLinesBefore: 1,
+					ContextLinesAfter:  1,
+				})
+				_ = p.Fprint(os.Stderr, e.rawConfigs, diags)
+				return fmt.Errorf("could not load the configuration")
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&e.changed, "changed", e.changed, "ID of a node whose dependants should be printed")
+	cmd.Flags().Var(&e.minStability, "stability.level", fmt.Sprintf("Minimum stability level of components which may be used. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	return cmd
+}
+
+type flowExplain struct {
+	changed      []string
+	minStability featuregate.Stability
+
+	rawConfigs map[string][]byte // Loaded configuration files, used for printing diagnostics.
+}
+
+func (fe *flowExplain) Run(w io.Writer, configPaths ...string) error {
+	source, err := loadFlowSources(configPaths, "flow", false, "")
+	if err != nil {
+		return err
+	}
+	fe.rawConfigs = source.RawConfigs()
+
+	plan, diags := flow.PlanEvaluation(source, flow.PlanOptions{
+		Services:     explainServices(),
+		MinStability: fe.minStability,
+	})
+	if diags.HasErrors() {
+		return diags
+	}
+
+	fmt.Fprintln(w, "Evaluation order:")
+	for i, step := range plan.Steps() {
+		if len(step) > 1 {
+			fmt.Fprintf(w, "  Step %d (%d nodes, in any order):\n", i+1, len(step))
+		} else {
+			fmt.Fprintf(w, "  Step %d:\n", i+1)
+		}
+		for _, id := range step {
+			fmt.Fprintf(w, "    %s\n", id)
+		}
+	}
+
+	for _, id := range fe.changed {
+		direct, indirect, err := plan.Dependants(id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "\nWhen %s changes its exports:\n", id)
+		if len(direct) == 0 {
+			fmt.Fprintln(w, "  No nodes are evaluated.")
+			continue
+		}
+		fmt.Fprintln(w, "  Evaluated immediately:")
+		for _, dep := range direct {
+			fmt.Fprintf(w, "    %s\n", dep)
+		}
+		if len(indirect) > 0 {
+			fmt.Fprintln(w, "  Evaluated if the nodes they reference change their exports:")
+			for _, dep := range indirect {
+				fmt.Fprintf(w, "    %s\n", dep)
+			}
+		}
+	}
+
+	return nil
+}
+
+// explainServices returns placeholders for the services used by the run
+// subcommand, so that blocks configuring them are recognized.
+func explainServices() []service.Service {
+	names := []string{
+		httpservice.ServiceName,
+		uiservice.ServiceName,
+		cluster.ServiceName,
+		otel_service.ServiceName,
+		labelstore.ServiceName,
+	}
+
+	services := make([]service.Service, 0, len(names))
+	for _, name := range names {
+		services = append(services, explainService{name: name})
+	}
+	return services
+}
+
+// explainService is a placeholder for a service which is never run.
+type explainService struct {
+	name string
+}
+
+var _ service.Service = explainService{}
+
+func (s explainService) Definition() service.Definition {
+	return service.Definition{Name: s.name}
+}
+
+func (explainService) Run(context.Context, service.Host) error { return nil }
+func (explainService) Update(any) error                        { return nil }
+func (explainService) Data() any                               { return nil }
@@ -0,0 +1,82 @@
+package flowmode
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.river")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+		local.file "a" {
+			filename = "/tmp/a"
+		}
+
+		local.file "b" {
+			filename = local.file.a.content
+		}
+
+		local.file "c" {
+			filename = local.file.b.content
+		}
+
+		http {}
+	`), 0644))
+
+	t.Run("Evaluation order", func(t *testing.T) {
+		var out bytes.Buffer
+		e := &flowExplain{
+			changed:      []string{"local.file.a", "local.file.c"},
+			minStability: featuregate.StabilityExperimental,
+		}
+		require.NoError(t, e.Run(&out, configFile))
+
+		require.Equal(t, `Evaluation order:
+  Step 1 (8 nodes, in any order):
+    cluster
+    http
+    labelstore
+    local.file.a
+    logging
+    otel
+    tracing
+    ui
+  Step 2:
+    local.file.b
+  Step 3:
+    local.file.c
+
+When local.file.a changes its exports:
+  Evaluated immediately:
+    local.file.b
+  Evaluated if the nodes they reference change their exports:
+    local.file.c
+
+When local.file.c changes its exports:
+  No nodes are evaluated.
+`, out.String())
+	})
+
+	t.Run("Unknown node", func(t *testing.T) {
+		e := &flowExplain{changed: []string{"local.file.missing"}}
+		require.EqualError(t, e.Run(&bytes.Buffer{}, configFile), `node "local.file.missing" does not exist`)
+	})
+
+	t.Run("Stability level", func(t *testing.T) {
+		experimentalFile := filepath.Join(t.TempDir(), "experimental.river")
+		require.NoError(t, os.WriteFile(experimentalFile, []byte(`otelcol.processor.transform "default" {}`), 0644))
+
+		e := &flowExplain{minStability: featuregate.StabilityStable}
+		err := e.Run(&bytes.Buffer{}, experimentalFile)
+
+		var diags diag.Diagnostics
+		require.ErrorAs(t, err, &diags)
+		require.ErrorContains(t, err, "below the minimum allowed stability level")
+	})
+}
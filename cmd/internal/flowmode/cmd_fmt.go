@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
+	"github.com/grafana/river/ast"
 	"github.com/grafana/river/diag"
 	"github.com/grafana/river/parser"
 	"github.com/grafana/river/printer"
+	"github.com/grafana/river/token"
 )
 
 func fmtCommand() *cobra.Command {
@@ -27,7 +31,12 @@ River configuration file.
 
 If the file argument is not supplied or if the file argument is "-", then fmt will read from stdin.
 
-The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.`,
+The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.
+
+The -r flag can be used to also format every module file referenced by a
+module.file component, following references in module files recursively. Only
+module.file components whose filename is a string literal are followed. -r can
+only be used together with -w.`,
 		Args:         cobra.RangeArgs(0, 1),
 		SilenceUsage: true,
 		Aliases:      []string{"format"},
@@ -55,11 +64,13 @@ The -w flag can be used to write the formatted file back to disk. -w can not be
 	}
 
 	cmd.Flags().BoolVarP(&f.write, "write", "w", f.write, "write result to (source) file instead of stdout")
+	cmd.Flags().BoolVarP(&f.recursive, "recursive", "r", f.recursive, "also format module files referenced by module.file components")
 	return cmd
 }
 
 type flowFmt struct {
-	write bool
+	write     bool
+	recursive bool
 }
 
 func (ff *flowFmt) Run(configFile string) error {
@@ -68,24 +79,104 @@ func (ff *flowFmt) Run(configFile string) error {
 		if ff.write {
 			return fmt.Errorf("cannot use -w with standard input")
 		}
+		if ff.recursive {
+			return fmt.Errorf("cannot use -r with standard input")
+		}
 		return format("<stdin>", nil, os.Stdin, false)
 
 	default:
-		fi, err := os.Stat(configFile)
-		if err != nil {
-			return err
+		if ff.recursive {
+			if !ff.write {
+				return fmt.Errorf("-r can only be used with -w")
+			}
+			return formatRecursive(configFile, make(map[string]struct{}))
 		}
-		if fi.IsDir() {
-			return fmt.Errorf("cannot format a directory")
+		return formatFile(configFile, ff.write)
+	}
+}
+
+// formatFile formats the file at configFile.
+func formatFile(configFile string, write bool) error {
+	fi, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("cannot format a directory")
+	}
+
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return format(configFile, fi, f, write)
+}
+
+// formatRecursive formats and writes the file at configFile and every file
+// referenced by a module.file component within it. visited holds the set of
+// files which have already been formatted.
+func formatRecursive(configFile string, visited map[string]struct{}) error {
+	absPath, err := filepath.Abs(configFile)
+	if err != nil {
+		return err
+	}
+	if _, ok := visited[absPath]; ok {
+		return nil
+	}
+	visited[absPath] = struct{}{}
+
+	if err := formatFile(configFile, true); err != nil {
+		return err
+	}
+
+	bb, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	f, err := parser.ParseFile(configFile, bb)
+	if err != nil {
+		return err
+	}
+
+	for _, moduleFile := range moduleFileReferences(f) {
+		if err := formatRecursive(moduleFile, visited); err != nil {
+			return fmt.Errorf("formatting module %s referenced by %s: %w", moduleFile, configFile, err)
 		}
+	}
+	return nil
+}
 
-		f, err := os.Open(configFile)
-		if err != nil {
-			return err
+// moduleFileReferences returns the filenames of all module.file components in
+// f whose filename attribute is a string literal.
+func moduleFileReferences(f *ast.File) []string {
+	var filenames []string
+
+	for _, stmt := range f.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || block.GetBlockName() != "module.file" {
+			continue
+		}
+
+		for _, inner := range block.Body {
+			attr, ok := inner.(*ast.AttributeStmt)
+			if !ok || attr.Name.Name != "filename" {
+				continue
+			}
+
+			lit, ok := attr.Value.(*ast.LiteralExpr)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			filename, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				continue
+			}
+			filenames = append(filenames, filename)
 		}
-		defer f.Close()
-		return format(configFile, fi, f, ff.write)
 	}
+
+	return filenames
 }
 
 func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
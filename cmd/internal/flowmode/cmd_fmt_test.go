@@ -0,0 +1,48 @@
+package flowmode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatRecursive(t *testing.T) {
+	dir := t.TempDir()
+
+	var (
+		rootFile   = filepath.Join(dir, "root.river")
+		moduleFile = filepath.Join(dir, "module.river")
+		nestedFile = filepath.Join(dir, "nested.river")
+	)
+
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+	}
+
+	writeFile(rootFile, fmt.Sprintf("module.file \"a\" {\nfilename = %q\n}\n", moduleFile))
+	// The module references itself and a nested module to ensure cycles are
+	// handled.
+	writeFile(moduleFile, fmt.Sprintf("module.file \"self\" {\nfilename = %q\n}\nmodule.file \"nested\" {\nfilename = %q\n}\n", moduleFile, nestedFile))
+	writeFile(nestedFile, "argument \"x\" {\noptional = true\n}\n")
+
+	ff := &flowFmt{write: true, recursive: true}
+	require.NoError(t, ff.Run(rootFile))
+
+	readFile := func(name string) string {
+		bb, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(bb)
+	}
+
+	require.Equal(t, fmt.Sprintf("module.file \"a\" {\n\tfilename = %q\n}\n", moduleFile), readFile(rootFile))
+	require.Equal(t, fmt.Sprintf("module.file \"self\" {\n\tfilename = %q\n}\n\nmodule.file \"nested\" {\n\tfilename = %q\n}\n", moduleFile, nestedFile), readFile(moduleFile))
+	require.Equal(t, "argument \"x\" {\n\toptional = true\n}\n", readFile(nestedFile))
+}
+
+func TestFormatRecursiveRequiresWrite(t *testing.T) {
+	ff := &flowFmt{recursive: true}
+	require.EqualError(t, ff.Run(filepath.Join(t.TempDir(), "config.river")), "-r can only be used with -w")
+}
@@ -0,0 +1,199 @@
+package flowmode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/spf13/cobra"
+)
+
+func healthcheckCommand() *cobra.Command {
+	h := &flowHealthcheck{
+		addr:     "http://127.0.0.1:12345",
+		uiPrefix: "/",
+		severity: component.HealthTypeUnhealthy.String(),
+		timeout:  5 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "healthcheck [flags]",
+		Short: "Check the health of the components of a running agent",
+		Long: `The healthcheck subcommand queries the HTTP API of a running Grafana Agent
+Flow process and exits with a non-zero status if any of its components are
+unhealthy. Components which are not healthy are printed as a table.
+
+Components in modules are checked along with the components which created
+them. The --module flag limits the check to a single module and the modules
+created by its components.
+
+The --severity flag sets the least healthy state which is tolerated. By
+default, components which are unhealthy or have exited fail the check, while
+components whose health is unknown do not.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return h.Run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&h.addr, "server.http.addr", h.addr, "Address of the HTTP server of the agent to check")
+	cmd.Flags().StringVar(&h.uiPrefix, "server.http.ui-path-prefix", h.uiPrefix, "Prefix the HTTP UI of the agent is served at")
+	cmd.Flags().StringVar(&h.moduleID, "module", h.moduleID, "ID of the module to check. Defaults to the root module")
+	cmd.Flags().StringVar(&h.severity, "severity", h.severity, "Least healthy component state which fails the check (unknown, unhealthy, exited)")
+	cmd.Flags().DurationVar(&h.timeout, "timeout", h.timeout, "Timeout for querying the agent")
+	return cmd
+}
+
+type flowHealthcheck struct {
+	addr     string
+	uiPrefix string
+	moduleID string
+	severity string
+	timeout  time.Duration
+}
+
+// healthcheckComponent is the subset of the component details returned by the
+// Flow API which is used by the healthcheck command.
+type healthcheckComponent struct {
+	LocalID  string `json:"localID"`
+	ModuleID string `json:"moduleID"`
+	Health   struct {
+		State   string `json:"state"`
+		Message string `json:"message"`
+	} `json:"health"`
+	CreatedModuleIDs []string `json:"createdModuleIDs"`
+}
+
+// healthSeverity orders health states from most to least healthy.
+var healthSeverity = map[component.HealthType]int{
+	component.HealthTypeHealthy:   0,
+	component.HealthTypeUnknown:   1,
+	component.HealthTypeUnhealthy: 2,
+	component.HealthTypeExited:    3,
+}
+
+func (fh *flowHealthcheck) Run(ctx context.Context, w io.Writer) error {
+	var severity component.HealthType
+	if err := severity.UnmarshalText([]byte(fh.severity)); err != nil || severity == component.HealthTypeHealthy {
+		return fmt.Errorf("invalid --severity %q: must be one of unknown, unhealthy, exited", fh.severity)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fh.timeout)
+	defer cancel()
+
+	components, err := fh.listComponents(ctx)
+	if err != nil {
+		return err
+	}
+
+	var failed []healthcheckComponent
+	for _, c := range components {
+		var health component.HealthType
+		if err := health.UnmarshalText([]byte(c.Health.State)); err != nil {
+			return fmt.Errorf("component %s: %w", componentName(c), err)
+		}
+		if healthSeverity[health] >= healthSeverity[severity] {
+			failed = append(failed, c)
+		}
+	}
+
+	if len(failed) == 0 {
+		fmt.Fprintf(w, "%d components checked, all healthy\n", len(components))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tHEALTH\tMESSAGE")
+	for _, c := range failed {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", componentName(c), c.Health.State, c.Health.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d of %d components failed the health check", len(failed), len(components))
+}
+
+// listComponents returns the components of the module being checked and of
+// all modules created by them, recursively.
+func (fh *flowHealthcheck) listComponents(ctx context.Context) ([]healthcheckComponent, error) {
+	var (
+		res     []healthcheckComponent
+		queue   = []string{fh.moduleID}
+		visited = make(map[string]struct{})
+	)
+
+	for len(queue) > 0 {
+		moduleID := queue[0]
+		queue = queue[1:]
+		if _, ok := visited[moduleID]; ok {
+			continue
+		}
+		visited[moduleID] = struct{}{}
+
+		components, err := fh.getModuleComponents(ctx, moduleID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range components {
+			res = append(res, c)
+			queue = append(queue, c.CreatedModuleIDs...)
+		}
+	}
+
+	return res, nil
+}
+
+func (fh *flowHealthcheck) getModuleComponents(ctx context.Context, moduleID string) ([]healthcheckComponent, error) {
+	u, err := url.Parse(fh.addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --server.http.addr: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid --server.http.addr %q: expected a URL such as http://127.0.0.1:12345", fh.addr)
+	}
+
+	endpoint := "/components"
+	if moduleID != "" {
+		endpoint = path.Join("/modules", moduleID, "components")
+	}
+	u.Path = path.Join(u.Path, fh.uiPrefix, "/api/v0/web", endpoint)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("querying agent: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var components []healthcheckComponent
+	if err := json.NewDecoder(resp.Body).Decode(&components); err != nil {
+		return nil, fmt.Errorf("decoding response from agent: %w", err)
+	}
+	return components, nil
+}
+
+// componentName returns the globally unique ID of c.
+func componentName(c healthcheckComponent) string {
+	if c.ModuleID == "" {
+		return c.LocalID
+	}
+	return c.ModuleID + "/" + c.LocalID
+}
@@ -0,0 +1,77 @@
+package flowmode
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthcheck(t *testing.T) {
+	responses := map[string]string{
+		"/api/v0/web/components": `[
+			{"localID": "local.file.a", "moduleID": "", "health": {"state": "healthy"}},
+			{"localID": "module.file.b", "moduleID": "", "health": {"state": "healthy"}, "createdModuleIDs": ["module.file.b"]}
+		]`,
+		"/api/v0/web/modules/module.file.b/components": `[
+			{"localID": "remote.http.c", "moduleID": "module.file.b", "health": {"state": "unhealthy", "message": "connection refused"}},
+			{"localID": "local.file.d", "moduleID": "module.file.b", "health": {"state": "unknown"}}
+		]`,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	defer srv.Close()
+
+	newHealthcheck := func(moduleID, severity string) *flowHealthcheck {
+		return &flowHealthcheck{
+			addr:     srv.URL,
+			uiPrefix: "/",
+			moduleID: moduleID,
+			severity: severity,
+			timeout:  time.Second,
+		}
+	}
+
+	t.Run("Unhealthy components in modules fail the check", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newHealthcheck("", "unhealthy").Run(context.Background(), &out)
+		require.EqualError(t, err, "1 of 4 components failed the health check")
+		require.Contains(t, out.String(), "module.file.b/remote.http.c  unhealthy  connection refused")
+		require.NotContains(t, out.String(), "local.file.d")
+	})
+
+	t.Run("Severity includes components with unknown health", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newHealthcheck("", "unknown").Run(context.Background(), &out)
+		require.EqualError(t, err, "2 of 4 components failed the health check")
+		require.Contains(t, out.String(), "module.file.b/local.file.d")
+	})
+
+	t.Run("Exited severity ignores unhealthy components", func(t *testing.T) {
+		var out bytes.Buffer
+		err := newHealthcheck("module.file.b", "exited").Run(context.Background(), &out)
+		require.NoError(t, err)
+		require.Equal(t, "2 components checked, all healthy\n", out.String())
+	})
+
+	t.Run("Invalid severity", func(t *testing.T) {
+		err := newHealthcheck("", "healthy").Run(context.Background(), &bytes.Buffer{})
+		require.ErrorContains(t, err, "invalid --severity")
+	})
+
+	t.Run("Missing module", func(t *testing.T) {
+		err := newHealthcheck("module.file.missing", "unhealthy").Run(context.Background(), &bytes.Buffer{})
+		require.ErrorContains(t, err, "404 Not Found")
+	})
+}
@@ -0,0 +1,310 @@
+package flowmode
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/service"
+	"github.com/grafana/agent/service/cluster"
+	httpservice "github.com/grafana/agent/service/http"
+	"github.com/grafana/agent/service/labelstore"
+	otel_service "github.com/grafana/agent/service/otel"
+	"github.com/grafana/river"
+	"github.com/grafana/river/token/builder"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/cobra"
+)
+
+func moduleTestCommand() *cobra.Command {
+	mt := &flowModuleTest{
+		timeout:      10 * time.Second,
+		minStability: featuregate.StabilityExperimental,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "test [flags] file [file...]",
+		Short: "Test modules against expected exports",
+		Long: `The test subcommand runs the test cases of one or more test files.
+
+A test file names the module under test with the module attribute, relative
+to the directory of the test file, and contains one test block per test case.
+Each test block passes the attributes of its arguments block to the module
+and waits for the module to export the attributes of its expect block.
+
+A test case fails if the module can't be loaded, or if its exports don't
+match the expected values before the timeout elapses. The timeout can be set
+for all test cases of a file with its timeout attribute.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return mt.Run(cmd.Context(), cmd.OutOrStdout(), args...)
+		},
+	}
+
+	cmd.Flags().DurationVar(&mt.timeout, "timeout", mt.timeout, "Maximum time to wait for the exports of a test case to match")
+	cmd.Flags().BoolVar(&mt.verbose, "verbose", mt.verbose, "Write the logs of the modules under test to stderr")
+	cmd.Flags().Var(&mt.minStability, "stability.level", fmt.Sprintf("Minimum stability level of components which may be used. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	return cmd
+}
+
+type flowModuleTest struct {
+	timeout      time.Duration
+	verbose      bool
+	minStability featuregate.Stability
+}
+
+// moduleTestFile is the contents of a test file.
+type moduleTestFile struct {
+	Module  string           `river:"module,attr"`
+	Timeout time.Duration    `river:"timeout,attr,optional"`
+	Tests   []moduleTestCase `river:"test,block"`
+}
+
+// moduleTestCase is a single test block of a test file.
+type moduleTestCase struct {
+	Name      string         `river:",label"`
+	Arguments map[string]any `river:"arguments,block,optional"`
+	Expect    map[string]any `river:"expect,block"`
+}
+
+// moduleTestComponent is the label of the module.string component which runs
+// the module under test.
+const moduleTestComponent = "under_test"
+
+func (mt *flowModuleTest) Run(ctx context.Context, w io.Writer, testPaths ...string) error {
+	var total, failed int
+	for _, testPath := range testPaths {
+		file, content, err := readModuleTestFile(testPath)
+		if err != nil {
+			return err
+		}
+
+		timeout := mt.timeout
+		if file.Timeout > 0 {
+			timeout = file.Timeout
+		}
+
+		for _, tc := range file.Tests {
+			total++
+			start := time.Now()
+			err := mt.runTestCase(ctx, content, tc, timeout)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				failed++
+				fmt.Fprintf(w, "FAIL %s: %s (%s)\n", testPath, tc.Name, elapsed)
+				fmt.Fprintf(w, "    %s\n", strings.ReplaceAll(err.Error(), "\n", "\n    "))
+				continue
+			}
+			fmt.Fprintf(w, "PASS %s: %s (%s)\n", testPath, tc.Name, elapsed)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, total)
+	}
+	fmt.Fprintf(w, "%d tests passed\n", total)
+	return nil
+}
+
+// readModuleTestFile reads the test file at testPath and the contents of the
+// module it tests.
+func readModuleTestFile(testPath string) (*moduleTestFile, []byte, error) {
+	bb, err := os.ReadFile(testPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file moduleTestFile
+	if err := river.Unmarshal(bb, &file); err != nil {
+		return nil, nil, fmt.Errorf("reading test file %s: %w", testPath, err)
+	}
+	if len(file.Tests) == 0 {
+		return nil, nil, fmt.Errorf("test file %s doesn't contain any test blocks", testPath)
+	}
+
+	modulePath := file.Module
+	if !filepath.IsAbs(modulePath) {
+		modulePath = filepath.Join(filepath.Dir(testPath), modulePath)
+	}
+	content, err := os.ReadFile(modulePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading module of test file %s: %w", testPath, err)
+	}
+	return &file, content, nil
+}
+
+// runTestCase runs the module with the arguments of tc and waits until its
+// exports match the expected values.
+func (mt *flowModuleTest) runTestCase(ctx context.Context, content []byte, tc moduleTestCase, timeout time.Duration) error {
+	logOutput := io.Discard
+	if mt.verbose {
+		logOutput = os.Stderr
+	}
+	l, err := logging.New(logOutput, logging.DefaultOptions)
+	if err != nil {
+		return err
+	}
+
+	dataPath, err := os.MkdirTemp("", "agent-module-test-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataPath)
+
+	services, err := moduleTestServices(l)
+	if err != nil {
+		return err
+	}
+
+	f := flow.New(flow.Options{
+		Logger:       l,
+		DataPath:     dataPath,
+		Reg:          prometheus.NewRegistry(),
+		MinStability: mt.minStability,
+		Services:     services,
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	source, err := flow.ParseSource("test", moduleTestSource(content, tc.Arguments))
+	if err != nil {
+		return err
+	}
+	if err := f.LoadSource(source, nil); err != nil {
+		return fmt.Errorf("loading module: %w", err)
+	}
+
+	id := component.ID{LocalID: "module.string." + moduleTestComponent}
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		info, err := f.GetComponent(id, component.InfoOptions{GetHealth: true, GetExports: true})
+		if err != nil {
+			return err
+		}
+		mismatches := compareModuleExports(info.Exports, tc.Expect)
+		if len(mismatches) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if info.Health.Health == component.HealthTypeUnhealthy {
+				mismatches = append(mismatches, fmt.Sprintf("module is unhealthy: %s", info.Health.Message))
+			}
+			return fmt.Errorf("exports didn't match after %s:\n%s", timeout, strings.Join(mismatches, "\n"))
+		case <-ticker.C:
+		}
+	}
+}
+
+// moduleTestServices returns the services which the modules under test may
+// depend on.
+func moduleTestServices(l *logging.Logger) ([]service.Service, error) {
+	clusterService, err := cluster.New(cluster.Options{
+		Log:              l,
+		EnableClustering: false,
+		NodeName:         "module-test",
+		AdvertiseAddress: "127.0.0.1:80",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	otelService := otel_service.New(l)
+	if otelService == nil {
+		return nil, fmt.Errorf("failed to create otel service")
+	}
+
+	return []service.Service{
+		httpservice.New(httpservice.Options{
+			Logger:         l,
+			HTTPListenAddr: "127.0.0.1:0",
+		}),
+		clusterService,
+		otelService,
+		labelstore.New(l, prometheus.NewRegistry()),
+	}, nil
+}
+
+// moduleTestSource returns the configuration which runs the module with the
+// given arguments.
+func moduleTestSource(content []byte, args map[string]any) []byte {
+	block := builder.NewBlock([]string{"module", "string"}, moduleTestComponent)
+	block.Body().SetAttributeValue("content", string(content))
+
+	argsBlock := builder.NewBlock([]string{"arguments"}, "")
+	for _, name := range sortedKeys(args) {
+		argsBlock.Body().SetAttributeValue(name, args[name])
+	}
+	block.Body().AppendBlock(argsBlock)
+
+	file := builder.NewFile()
+	file.Body().AppendBlock(block)
+	return file.Bytes()
+}
+
+// compareModuleExports returns a description of every expected export which
+// doesn't match the exports of the module.
+func compareModuleExports(exports component.Exports, expect map[string]any) []string {
+	var actual map[string]any
+	if e, ok := exports.(module.Exports); ok {
+		actual = e.Exports
+	}
+
+	var mismatches []string
+	for _, name := range sortedKeys(expect) {
+		want := riverValueString(expect[name])
+
+		value, ok := actual[name]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("export %q: expected %s, but it isn't exported", name, want))
+			continue
+		}
+		if got := riverValueString(value); got != want {
+			mismatches = append(mismatches, fmt.Sprintf("export %q: expected %s, got %s", name, want, got))
+		}
+	}
+	return mismatches
+}
+
+// riverValueString returns the River representation of v.
+func riverValueString(v any) string {
+	expr := builder.NewExpr()
+	expr.SetValue(v)
+	return string(expr.Bytes())
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package flowmode
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleTest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "add.river"), []byte(`
+		argument "a" { }
+
+		argument "b" {
+			optional = true
+			default  = 1
+		}
+
+		export "sum" {
+			value = argument.a.value + argument.b.value
+		}
+
+		export "labels" {
+			value = {"a" = argument.a.value}
+		}
+	`), 0644))
+
+	mt := &flowModuleTest{
+		timeout:      time.Second,
+		minStability: featuregate.StabilityExperimental,
+	}
+
+	t.Run("Passing tests", func(t *testing.T) {
+		testFile := filepath.Join(dir, "pass.river")
+		require.NoError(t, os.WriteFile(testFile, []byte(`
+			module = "add.river"
+
+			test "both_arguments" {
+				arguments {
+					a = 1
+					b = 2
+				}
+				expect {
+					sum    = 3
+					labels = {"a" = 1}
+				}
+			}
+
+			test "default_argument" {
+				arguments {
+					a = 5
+				}
+				expect {
+					sum = 6
+				}
+			}
+		`), 0644))
+
+		var out bytes.Buffer
+		require.NoError(t, mt.Run(context.Background(), &out, testFile))
+		require.Contains(t, out.String(), "PASS "+testFile+": both_arguments")
+		require.Contains(t, out.String(), "PASS "+testFile+": default_argument")
+		require.Contains(t, out.String(), "2 tests passed")
+	})
+
+	t.Run("Failing tests", func(t *testing.T) {
+		testFile := filepath.Join(dir, "fail.river")
+		require.NoError(t, os.WriteFile(testFile, []byte(`
+			module  = "add.river"
+			timeout = "200ms"
+
+			test "wrong_sum" {
+				arguments {
+					a = 1
+				}
+				expect {
+					sum     = 3
+					missing = true
+				}
+			}
+
+			test "unknown_argument" {
+				arguments {
+					a = 1
+					c = 2
+				}
+				expect {
+					sum = 2
+				}
+			}
+		`), 0644))
+
+		var out bytes.Buffer
+		require.EqualError(t, mt.Run(context.Background(), &out, testFile), "2 of 2 tests failed")
+		require.Contains(t, out.String(), "FAIL "+testFile+": wrong_sum")
+		require.Contains(t, out.String(), `export "missing": expected true, but it isn't exported`)
+		require.Contains(t, out.String(), `export "sum": expected 3, got 2`)
+		require.Contains(t, out.String(), "FAIL "+testFile+": unknown_argument")
+		require.Contains(t, out.String(), "loading module:")
+	})
+}
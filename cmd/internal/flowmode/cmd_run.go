@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -19,6 +23,8 @@ import (
 	"github.com/grafana/agent/converter"
 	convert_diag "github.com/grafana/agent/converter/diag"
 	"github.com/grafana/agent/internal/agentseed"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/internal/selfmonitor"
 	"github.com/grafana/agent/pkg/boringcrypto"
 	"github.com/grafana/agent/pkg/config/instrumentation"
 	"github.com/grafana/agent/pkg/flow"
@@ -46,20 +52,22 @@ import (
 
 func runCommand() *cobra.Command {
 	r := &flowRun{
-		inMemoryAddr:          "agent.internal:12345",
-		httpListenAddr:        "127.0.0.1:12345",
-		storagePath:           "data-agent/",
-		uiPrefix:              "/",
-		disableReporting:      false,
-		enablePprof:           true,
-		configFormat:          "flow",
-		clusterAdvInterfaces:  advertise.DefaultInterfaces,
-		ClusterMaxJoinPeers:   5,
-		clusterRejoinInterval: 60 * time.Second,
+		inMemoryAddr:           "agent.internal:12345",
+		httpListenAddr:         "127.0.0.1:12345",
+		storagePath:            "data-agent/",
+		uiPrefix:               "/",
+		disableReporting:       false,
+		enablePprof:            true,
+		configFormat:           "flow",
+		clusterAdvInterfaces:   advertise.DefaultInterfaces,
+		ClusterMaxJoinPeers:    5,
+		clusterRejoinInterval:  60 * time.Second,
+		minStability:           featuregate.StabilityExperimental,
+		selfMonitoringInterval: time.Minute,
 	}
 
 	cmd := &cobra.Command{
-		Use:   "run [flags] path",
+		Use:   "run [flags] path [path...]",
 		Short: "Run Grafana Agent Flow",
 		Long: `The run subcommand runs Grafana Agent Flow in the foreground until an interrupt
 is received.
@@ -71,6 +79,12 @@ immediately.
 If path is a directory, all *.river files in that directory will be combined
 into a single unit. Subdirectories are not recursively searched for further merging.
 
+Multiple paths may be provided, in which case all of the files and *.river
+files in directories are combined into a single unit. Components declared
+more than once across the combined files are reported as errors at the
+location of each repeated declaration, which include the location of the
+first declaration.
+
 run starts an HTTP server which can be used to debug Grafana Agent Flow or
 force it to reload (by sending a GET or POST request to /-/reload). The listen
 address can be changed through the --server.http.listen-addr flag.
@@ -79,6 +93,11 @@ By default, the HTTP server exposes a debugging UI at /. The path of the
 debugging UI can be changed by providing a different value to
 --server.http.ui-path-prefix.
 
+When --shutdown.stop-scraping-timeout is set, receiving an interrupt or a POST
+request to /-/drain stops prometheus.scrape components from scraping before
+shutting down, waiting up to the timeout for scrapes in progress. Other
+components, including those which send data, aren't flushed.
+
 Additionally, the HTTP server exposes the following debug endpoints:
 
   /debug/pprof   Go performance profiling tools
@@ -87,11 +106,11 @@ If reloading the config dir/file-path fails, Grafana Agent Flow will continue ru
 its last valid state. Components which failed may be be listed as unhealthy,
 depending on the nature of the reload error.
 `,
-		Args:         cobra.ExactArgs(1),
+		Args:         cobra.MinimumNArgs(1),
 		SilenceUsage: true,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return r.Run(args[0])
+			return r.Run(args...)
 		},
 	}
 
@@ -125,6 +144,18 @@ depending on the nature of the reload error.
 	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
 	cmd.Flags().BoolVar(&r.configBypassConversionErrors, "config.bypass-conversion-errors", r.configBypassConversionErrors, "Enable bypassing errors when converting")
 	cmd.Flags().StringVar(&r.configExtraArgs, "config.extra-args", r.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	cmd.Flags().BoolVar(&r.configRollbackOnFailure, "config.rollback-on-failure", r.configRollbackOnFailure, "Validate configurations, including the modules they load, before applying them, and keep the previous configuration running if validation fails")
+	cmd.Flags().Var(&r.minStability, "stability.level", fmt.Sprintf("Minimum stability level of components which may be used. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().StringVar(&r.auditLogPath, "audit-log.path", r.auditLogPath, "File to append a structured audit log of configuration loads to. Disabled if empty")
+	cmd.Flags().DurationVar(&r.evaluationTimeout, "controller.evaluation-timeout", r.evaluationTimeout, "Maximum time to wait for a single component evaluation before marking the component unhealthy. 0 disables the timeout")
+	cmd.Flags().IntVar(&r.maxConcurrentEvaluations, "controller.max-concurrent-evaluations", r.maxConcurrentEvaluations, "Maximum number of components evaluated at the same time. Components which don't depend on each other are evaluated concurrently when the configuration is loaded. 0 evaluates them one at a time during loads")
+	cmd.Flags().DurationVar(&r.minUpdateInterval, "controller.min-update-interval", r.minUpdateInterval, "Minimum time between evaluations of the dependants of a component whose exports change. More frequent updates are coalesced. 0 disables the limit")
+	cmd.Flags().IntVar(&r.maxModuleDepth, "controller.max-module-depth", r.maxModuleDepth, "Maximum number of modules which may be nested in each other. 0 disables the limit")
+	cmd.Flags().IntVar(&r.maxModules, "controller.max-modules", r.maxModules, "Maximum number of modules, including nested modules, which may run at the same time. 0 disables the limit")
+	cmd.Flags().IntVar(&r.maxComponentSeries, "metrics.max-component-series", r.maxComponentSeries, "Maximum number of series each component may expose on the /metrics endpoint. 0 means no limit.")
+	cmd.Flags().StringVar(&r.selfMonitoringURL, "self-monitoring.remote-write-url", r.selfMonitoringURL, "Prometheus remote write endpoint to push the agent's own metrics to. Disabled if empty")
+	cmd.Flags().DurationVar(&r.stopScrapingTimeout, "shutdown.stop-scraping-timeout", r.stopScrapingTimeout, "Maximum time to wait for prometheus.scrape components to stop scraping before shutting down. 0 shuts down without stopping scrapes first")
+	cmd.Flags().DurationVar(&r.selfMonitoringInterval, "self-monitoring.interval", r.selfMonitoringInterval, "How often to push the agent's own metrics to the self-monitoring remote write endpoint")
 	return cmd
 }
 
@@ -147,16 +178,33 @@ type flowRun struct {
 	configFormat                 string
 	configBypassConversionErrors bool
 	configExtraArgs              string
+	configRollbackOnFailure      bool
+	maxComponentSeries           int
+	minStability                 featuregate.Stability
+	evaluationTimeout            time.Duration
+	maxConcurrentEvaluations     int
+	minUpdateInterval            time.Duration
+	maxModuleDepth               int
+	maxModules                   int
+	auditLogPath                 string
+	selfMonitoringURL            string
+	selfMonitoringInterval       time.Duration
+	stopScrapingTimeout          time.Duration
 }
 
-func (fr *flowRun) Run(configPath string) error {
+func (fr *flowRun) Run(configPaths ...string) error {
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
-	ctx, cancel := interruptContext()
+	interruptCtx, interruptCancel := interruptContext()
+	defer interruptCancel()
+
+	// Components must keep running while they're drained after an interrupt,
+	// so everything else is stopped through a separate context.
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if configPath == "" {
+	if len(configPaths) == 0 || slices.Contains(configPaths, "") {
 		return fmt.Errorf("path argument not provided")
 	}
 
@@ -201,8 +249,13 @@ func (fr *flowRun) Run(configPath string) error {
 	// To work around this, we lazily create variables for the functions the HTTP
 	// service needs and set them after the Flow controller exists.
 	var (
-		reload func() (*flow.Source, error)
-		ready  func() bool
+		reload   func() (*flow.Source, error)
+		validate func() error
+		ready    func() bool
+		refresh  func(id string) (int, error)
+
+		draining       atomic.Bool
+		drainRequested = make(chan struct{}, 1)
 	)
 
 	clusterService, err := buildClusterService(clusterOptions{
@@ -230,8 +283,16 @@ func (fr *flowRun) Run(configPath string) error {
 		Tracer:   t,
 		Gatherer: prometheus.DefaultGatherer,
 
-		ReadyFunc:  func() bool { return ready() },
-		ReloadFunc: func() (*flow.Source, error) { return reload() },
+		ReadyFunc:    func() bool { return ready() },
+		ReloadFunc:   func() (*flow.Source, error) { return reload() },
+		ValidateFunc: func() error { return validate() },
+		DrainFunc: func() {
+			select {
+			case drainRequested <- struct{}{}:
+			default:
+			}
+		},
+		RefreshFunc: func(id string) (int, error) { return refresh(id) },
 
 		HTTPListenAddr:   fr.httpListenAddr,
 		MemoryListenAddr: fr.inMemoryAddr,
@@ -251,11 +312,37 @@ func (fr *flowRun) Run(configPath string) error {
 	labelService := labelstore.New(l, reg)
 	agentseed.Init(fr.storagePath, l)
 
+	var auditLogger log.Logger
+	if fr.auditLogPath != "" {
+		auditFile, err := os.OpenFile(fr.auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+		if err != nil {
+			return fmt.Errorf("opening audit log: %w", err)
+		}
+		defer auditFile.Close()
+
+		auditLogger = log.NewJSONLogger(log.NewSyncWriter(auditFile))
+		auditLogger = log.With(auditLogger, "ts", log.DefaultTimestampUTC)
+	}
+
 	f := flow.New(flow.Options{
 		Logger:   l,
 		Tracer:   t,
 		DataPath: fr.storagePath,
 		Reg:      reg,
+
+		MaxComponentSeries: fr.maxComponentSeries,
+		MinStability:       fr.minStability,
+		EvaluationTimeout:  fr.evaluationTimeout,
+		MaxModuleDepth:     fr.maxModuleDepth,
+		MaxModules:         fr.maxModules,
+		AuditLogger:        auditLogger,
+		AgentID:            agentseed.Get().UID,
+		ClusterName:        fr.clusterName,
+
+		MaxConcurrentEvaluations: fr.maxConcurrentEvaluations,
+		MinUpdateInterval:        fr.minUpdateInterval,
+		RollbackOnFailure:        fr.configRollbackOnFailure,
+
 		Services: []service.Service{
 			httpService,
 			uiService,
@@ -265,14 +352,23 @@ func (fr *flowRun) Run(configPath string) error {
 		},
 	})
 
-	ready = f.Ready
+	ready = func() bool { return !draining.Load() && f.Ready() }
+	refresh = func(id string) (int, error) {
+		if id == "" {
+			return f.RefreshComponents(), nil
+		}
+		if err := f.RefreshComponent(component.ParseID(id)); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
 	reload = func() (*flow.Source, error) {
-		flowSource, err := loadFlowSource(configPath, fr.configFormat, fr.configBypassConversionErrors, fr.configExtraArgs)
+		flowSource, err := loadFlowSources(configPaths, fr.configFormat, fr.configBypassConversionErrors, fr.configExtraArgs)
 		defer instrumentation.InstrumentSHA256(flowSource.SHA256())
 		defer instrumentation.InstrumentLoad(err == nil)
 
 		if err != nil {
-			return nil, fmt.Errorf("reading config path %q: %w", configPath, err)
+			return nil, fmt.Errorf("reading config path %s: %w", strings.Join(quoteAll(configPaths), ", "), err)
 		}
 		if err := f.LoadSource(flowSource, nil); err != nil {
 			return flowSource, fmt.Errorf("error during the initial grafana/agent load: %w", err)
@@ -280,6 +376,13 @@ func (fr *flowRun) Run(configPath string) error {
 
 		return flowSource, nil
 	}
+	validate = func() error {
+		flowSource, err := loadFlowSources(configPaths, fr.configFormat, fr.configBypassConversionErrors, fr.configExtraArgs)
+		if err != nil {
+			return fmt.Errorf("reading config path %s: %w", strings.Join(quoteAll(configPaths), ", "), err)
+		}
+		return f.ValidateSource(flowSource, nil)
+	}
 
 	// Flow controller
 	{
@@ -304,6 +407,19 @@ func (fr *flowRun) Run(configPath string) error {
 		}()
 	}
 
+	// Push the agent's own metrics, independently of the loaded configuration.
+	if fr.selfMonitoringURL != "" {
+		pusher, err := newSelfMonitoringPusher(l, fr.selfMonitoringURL, fr.selfMonitoringInterval)
+		if err != nil {
+			return fmt.Errorf("failed to start self-monitoring: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pusher.Run(ctx)
+		}()
+	}
+
 	// Perform the initial reload. This is done after starting the HTTP server so
 	// that /metric and pprof endpoints are available while the Flow controller
 	// is loading.
@@ -342,7 +458,13 @@ func (fr *flowRun) Run(configPath string) error {
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-interruptCtx.Done():
+			draining.Store(true)
+			fr.drain(l, f)
+			return nil
+		case <-drainRequested:
+			draining.Store(true)
+			fr.drain(l, f)
 			return nil
 		case <-reloadSignal:
 			if _, err := reload(); err != nil {
@@ -350,10 +472,35 @@ func (fr *flowRun) Run(configPath string) error {
 			} else {
 				level.Info(l).Log("msg", "config reloaded")
 			}
+
+			// Also refresh modules and other remote content so that changes
+			// are picked up without waiting for the next poll.
+			refreshed := f.RefreshComponents()
+			level.Info(l).Log("msg", "requested refresh of components with external sources", "count", refreshed)
 		}
 	}
 }
 
+// drain drains the components of f before the process shuts down, which
+// stops prometheus.scrape components from scraping, waiting at most for the
+// configured timeout. Draining is skipped if the timeout is 0.
+func (fr *flowRun) drain(l log.Logger, f *flow.Flow) {
+	if fr.stopScrapingTimeout <= 0 {
+		return
+	}
+
+	level.Info(l).Log("msg", "stopping scrapes before shutting down", "timeout", fr.stopScrapingTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fr.stopScrapingTimeout)
+	defer cancel()
+
+	if err := f.Drain(ctx); err != nil {
+		level.Warn(l).Log("msg", "not all components were drained, shutting down anyway", "err", err)
+		return
+	}
+	level.Info(l).Log("msg", "all components drained")
+}
+
 // getEnabledComponentsFunc returns a function that gets the current enabled components
 func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
 	return func() map[string]interface{} {
@@ -366,6 +513,74 @@ func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
 	}
 }
 
+// loadFlowSources loads and combines the sources at the given paths. Sources
+// in other formats than flow can only be converted when a single file is
+// given.
+func loadFlowSources(paths []string, converterSourceFormat string, converterBypassErrors bool, configExtraArgs string) (*flow.Source, error) {
+	if len(paths) == 1 {
+		return loadFlowSource(paths[0], converterSourceFormat, converterBypassErrors, configExtraArgs)
+	}
+	if converterSourceFormat != "flow" {
+		return nil, fmt.Errorf("only a single path may be provided when the config format is %q", converterSourceFormat)
+	}
+
+	sources := map[string][]byte{}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if fi.IsDir() {
+			if err := readRiverDir(path, sources); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sources[path] = bb
+	}
+
+	return flow.ParseSources(sources)
+}
+
+// readRiverDir reads all *.river files at the top level of the directory at
+// path into sources, keyed by their path.
+func readRiverDir(path string, sources map[string][]byte) error {
+	return filepath.WalkDir(path, func(curPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip all directories and don't recurse into child dirs that aren't at top-level
+		if d.IsDir() {
+			if curPath != path {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Ignore files not ending in .river extension
+		if !strings.HasSuffix(curPath, ".river") {
+			return nil
+		}
+
+		bb, err := os.ReadFile(curPath)
+		sources[curPath] = bb
+		return err
+	})
+}
+
+func quoteAll(ss []string) []string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = strconv.Quote(s)
+	}
+	return quoted
+}
+
 func loadFlowSource(path string, converterSourceFormat string, converterBypassErrors bool, configExtraArgs string) (*flow.Source, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
@@ -374,30 +589,9 @@ func loadFlowSource(path string, converterSourceFormat string, converterBypassEr
 
 	if fi.IsDir() {
 		sources := map[string][]byte{}
-		err := filepath.WalkDir(path, func(curPath string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			// Skip all directories and don't recurse into child dirs that aren't at top-level
-			if d.IsDir() {
-				if curPath != path {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-			// Ignore files not ending in .river extension
-			if !strings.HasSuffix(curPath, ".river") {
-				return nil
-			}
-
-			bb, err := os.ReadFile(curPath)
-			sources[curPath] = bb
-			return err
-		})
-		if err != nil {
+		if err := readRiverDir(path, sources); err != nil {
 			return nil, err
 		}
-
 		return flow.ParseSources(sources)
 	}
 
@@ -450,3 +644,32 @@ func splitPeers(s, sep string) []string {
 	}
 	return strings.Split(s, sep)
 }
+
+// newSelfMonitoringPusher creates a pusher which sends the metrics of the
+// default registry to the remote write endpoint at rawURL. Every series is
+// labeled with the hostname of the agent.
+func newSelfMonitoringPusher(l log.Logger, rawURL string, interval time.Duration) (*selfmonitor.Pusher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote write URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote write URL %q: scheme must be http or https", rawURL)
+	}
+
+	instance, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("getting hostname for self-monitoring: %w", err)
+	}
+
+	return selfmonitor.New(selfmonitor.Options{
+		Logger:   log.With(l, "subsystem", "self-monitoring"),
+		Gatherer: prometheus.DefaultGatherer,
+		URL:      u,
+		Interval: interval,
+		Labels: map[string]string{
+			"job":      "grafana-agent",
+			"instance": instance,
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package flowmode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
+)
+
+func TestLoadFlowSources(t *testing.T) {
+	var (
+		dir       = t.TempDir()
+		configDir = filepath.Join(dir, "conf.d")
+		mainFile  = filepath.Join(dir, "main.river")
+		dirFile   = filepath.Join(configDir, "extra.river")
+		otherFile = filepath.Join(configDir, "README.md")
+	)
+	require.NoError(t, os.Mkdir(configDir, 0755))
+	require.NoError(t, os.WriteFile(mainFile, []byte(`logging { level = "debug" }`), 0644))
+	require.NoError(t, os.WriteFile(dirFile, []byte(`local.file "example" { filename = "/tmp/example" }`), 0644))
+	require.NoError(t, os.WriteFile(otherFile, []byte(`not a river file`), 0644))
+
+	t.Run("Files and directories are combined", func(t *testing.T) {
+		source, err := loadFlowSources([]string{mainFile, configDir}, "flow", false, "")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{mainFile, dirFile}, maps.Keys(source.RawConfigs()))
+	})
+
+	t.Run("Converting multiple paths is not supported", func(t *testing.T) {
+		_, err := loadFlowSources([]string{mainFile, configDir}, "prometheus", false, "")
+		require.ErrorContains(t, err, `only a single path may be provided when the config format is "prometheus"`)
+	})
+
+	t.Run("Missing paths are reported", func(t *testing.T) {
+		_, err := loadFlowSources([]string{mainFile, filepath.Join(dir, "missing.river")}, "flow", false, "")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
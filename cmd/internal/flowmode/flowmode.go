@@ -25,8 +25,11 @@ func Run() {
 
 	cmd.AddCommand(
 		convertCommand(),
+		explainCommand(),
 		fmtCommand(),
+		healthcheckCommand(),
 		runCommand(),
+		moduleTestCommand(),
 		toolsCommand(),
 	)
 
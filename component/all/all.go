@@ -56,9 +56,13 @@ import (
 	_ "github.com/grafana/agent/component/loki/source/windowsevent"                 // Import loki.source.windowsevent
 	_ "github.com/grafana/agent/component/loki/write"                               // Import loki.write
 	_ "github.com/grafana/agent/component/mimir/rules/kubernetes"                   // Import mimir.rules.kubernetes
+	_ "github.com/grafana/agent/component/module/directory"                         // Import module.directory
 	_ "github.com/grafana/agent/component/module/file"                              // Import module.file
+	_ "github.com/grafana/agent/component/module/foreach"                           // Import module.foreach
 	_ "github.com/grafana/agent/component/module/git"                               // Import module.git
 	_ "github.com/grafana/agent/component/module/http"                              // Import module.http
+	_ "github.com/grafana/agent/component/module/oci"                               // Import module.oci
+	_ "github.com/grafana/agent/component/module/s3"                                // Import module.s3
 	_ "github.com/grafana/agent/component/module/string"                            // Import module.string
 	_ "github.com/grafana/agent/component/otelcol/auth/basic"                       // Import otelcol.auth.basic
 	_ "github.com/grafana/agent/component/otelcol/auth/bearer"                      // Import otelcol.auth.bearer
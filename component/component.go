@@ -113,3 +113,30 @@ type DebugComponent interface {
 	// DebugInfo must be safe for calling concurrently.
 	DebugInfo() interface{}
 }
+
+// RefreshableComponent is an extension interface for components which
+// periodically load content from an external source, such as a file or a
+// remote endpoint.
+type RefreshableComponent interface {
+	Component
+
+	// Refresh requests the component to reload its content from its source as
+	// soon as possible, instead of waiting for the next scheduled reload.
+	// Refresh must not block.
+	Refresh()
+}
+
+// DrainableComponent is an extension interface for components which receive
+// or buffer data and can stop accepting new data ahead of a planned shutdown.
+type DrainableComponent interface {
+	Component
+
+	// Drain requests the component to stop accepting new data. Components
+	// which buffer data may also flush it; Drain then blocks until the
+	// buffered data has been flushed or ctx is canceled. The component keeps
+	// running after Drain returns, until the context passed to Run is
+	// canceled.
+	//
+	// Drain must be safe for calling concurrently with Update.
+	Drain(ctx context.Context) error
+}
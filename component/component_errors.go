@@ -0,0 +1,65 @@
+package component
+
+import (
+	"errors"
+
+	"github.com/grafana/river/diag"
+)
+
+// ErrorDetail is a structured representation of an error reported while
+// loading or evaluating a configuration. Errors which originate from River
+// diagnostics retain the position in the source they refer to; for
+// components running inside of modules, File is the name of the module
+// source.
+type ErrorDetail struct {
+	NodeID   string `json:"nodeID,omitempty"` // Global ID of the component which reported the error, if known.
+	Severity string `json:"severity"`         // Either "error" or "warning".
+	Message  string `json:"message"`
+
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+
+	// Value is an optional value related to the error, such as the name of
+	// an unknown attribute.
+	Value string `json:"value,omitempty"`
+}
+
+// ErrorDetails converts err into a list of ErrorDetail. Each River
+// diagnostic in err is returned as its own ErrorDetail, with its position and
+// the message of the outer errors wrapping it stripped. Any other error is
+// returned as a single ErrorDetail without a position. ErrorDetails returns
+// nil if err is nil.
+func ErrorDetails(err error) []ErrorDetail {
+	if err == nil {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) || len(diags) == 0 {
+		return []ErrorDetail{{Severity: "error", Message: err.Error()}}
+	}
+
+	res := make([]ErrorDetail, 0, len(diags))
+	for _, d := range diags {
+		detail := ErrorDetail{
+			Severity: "error",
+			Message:  d.Message,
+			File:     d.StartPos.Filename,
+			Line:     d.StartPos.Line,
+			Column:   d.StartPos.Column,
+			Value:    d.Value,
+		}
+		if d.Severity == diag.SeverityLevelWarn {
+			detail.Severity = "warning"
+		}
+		if d.EndPos.Line > 0 {
+			detail.EndLine = d.EndPos.Line
+			detail.EndColumn = d.EndPos.Column
+		}
+		res = append(res, detail)
+	}
+	return res
+}
@@ -0,0 +1,54 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/river/diag"
+	"github.com/grafana/river/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorDetails(t *testing.T) {
+	require.Nil(t, ErrorDetails(nil))
+
+	require.Equal(t, []ErrorDetail{{
+		Severity: "error",
+		Message:  "building component: connection refused",
+	}}, ErrorDetails(fmt.Errorf("building component: %w", errors.New("connection refused"))))
+
+	diags := diag.Diagnostics{
+		{
+			Severity: diag.SeverityLevelError,
+			StartPos: token.Position{Filename: "module.file.a", Line: 3, Column: 5},
+			EndPos:   token.Position{Filename: "module.file.a", Line: 3, Column: 12},
+			Message:  "unrecognized attribute name \"filname\"",
+			Value:    "filname",
+		},
+		{
+			Severity: diag.SeverityLevelWarn,
+			StartPos: token.Position{Filename: "config.river", Line: 10, Column: 1},
+			Message:  "deprecated block",
+		},
+	}
+	require.Equal(t, []ErrorDetail{
+		{
+			Severity:  "error",
+			Message:   "unrecognized attribute name \"filname\"",
+			File:      "module.file.a",
+			Line:      3,
+			Column:    5,
+			EndLine:   3,
+			EndColumn: 12,
+			Value:     "filname",
+		},
+		{
+			Severity: "warning",
+			Message:  "deprecated block",
+			File:     "config.river",
+			Line:     10,
+			Column:   1,
+		},
+	}, ErrorDetails(fmt.Errorf("decoding River: %w", diags)))
+}
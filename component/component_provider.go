@@ -63,6 +63,8 @@ type InfoOptions struct {
 	GetArguments bool // When true, sets the Arguments field of returned components.
 	GetExports   bool // When true, sets the Exports field of returned components.
 	GetDebugInfo bool // When true, sets the DebugInfo field of returned components.
+
+	GetHealthHistory bool // When true, sets the HealthHistory field of returned components.
 }
 
 // String returns the "<ModuleID>/<LocalID>" string representation of the id.
@@ -93,8 +95,13 @@ type Info struct {
 	// this component depends on, or is depended on by, respectively.
 	References, ReferencedBy []string
 
-	ComponentName string // Name of the component.
-	Health        Health // Current component health.
+	ComponentName string   // Name of the component.
+	Health        Health   // Current component health.
+	HealthHistory []Health // Recent health transitions, ordered from oldest to newest.
+
+	// EvaluationErrors holds the errors of the last evaluation of the
+	// component, if it failed. Set along with Health.
+	EvaluationErrors []ErrorDetail
 
 	Arguments Arguments   // Current arguments value of the component.
 	Exports   Exports     // Current exports value of the component.
@@ -112,19 +119,21 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		}
 
 		componentDetailJSON struct {
-			Name             string               `json:"name"`
-			Type             string               `json:"type,omitempty"`
-			LocalID          string               `json:"localID"`
-			ModuleID         string               `json:"moduleID"`
-			Label            string               `json:"label,omitempty"`
-			References       []string             `json:"referencesTo"`
-			ReferencedBy     []string             `json:"referencedBy"`
-			Health           *componentHealthJSON `json:"health"`
-			Original         string               `json:"original"`
-			Arguments        json.RawMessage      `json:"arguments,omitempty"`
-			Exports          json.RawMessage      `json:"exports,omitempty"`
-			DebugInfo        json.RawMessage      `json:"debugInfo,omitempty"`
-			CreatedModuleIDs []string             `json:"createdModuleIDs,omitempty"`
+			Name             string                `json:"name"`
+			Type             string                `json:"type,omitempty"`
+			LocalID          string                `json:"localID"`
+			ModuleID         string                `json:"moduleID"`
+			Label            string                `json:"label,omitempty"`
+			References       []string              `json:"referencesTo"`
+			ReferencedBy     []string              `json:"referencedBy"`
+			Health           *componentHealthJSON  `json:"health"`
+			HealthHistory    []componentHealthJSON `json:"healthHistory,omitempty"`
+			EvaluationErrors []ErrorDetail         `json:"evaluationErrors,omitempty"`
+			Original         string                `json:"original"`
+			Arguments        json.RawMessage       `json:"arguments,omitempty"`
+			Exports          json.RawMessage       `json:"exports,omitempty"`
+			DebugInfo        json.RawMessage       `json:"debugInfo,omitempty"`
+			CreatedModuleIDs []string              `json:"createdModuleIDs,omitempty"`
 		}
 	)
 
@@ -156,6 +165,15 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 
+	var healthHistory []componentHealthJSON
+	for _, h := range info.HealthHistory {
+		healthHistory = append(healthHistory, componentHealthJSON{
+			State:       h.Health.String(),
+			Message:     h.Message,
+			UpdatedTime: h.UpdateTime,
+		})
+	}
+
 	return json.Marshal(&componentDetailJSON{
 		Name:         info.ComponentName,
 		Type:         "block",
@@ -169,6 +187,8 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			Message:     info.Health.Message,
 			UpdatedTime: info.Health.UpdateTime,
 		},
+		HealthHistory:    healthHistory,
+		EvaluationErrors: info.EvaluationErrors,
 		Arguments:        arguments,
 		Exports:          exports,
 		DebugInfo:        debugInfo,
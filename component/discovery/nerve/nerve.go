@@ -6,15 +6,17 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/prometheus/common/model"
 	prom_discovery "github.com/prometheus/prometheus/discovery/zookeeper"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "discovery.nerve",
-		Args:    Arguments{},
-		Exports: discovery.Exports{},
+		Name:      "discovery.nerve",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
+		Exports:   discovery.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
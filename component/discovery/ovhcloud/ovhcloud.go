@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/river/rivertypes"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
@@ -14,9 +15,10 @@ import (
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "discovery.ovhcloud",
-		Args:    Arguments{},
-		Exports: discovery.Exports{},
+		Name:      "discovery.ovhcloud",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
+		Exports:   discovery.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
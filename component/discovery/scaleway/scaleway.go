@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/river/rivertypes"
 	prom_config "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
@@ -19,9 +20,10 @@ import (
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "discovery.scaleway",
-		Args:    Arguments{},
-		Exports: discovery.Exports{},
+		Name:      "discovery.scaleway",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
+		Exports:   discovery.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -11,6 +11,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/logging/level"
 	"github.com/grafana/river/rivertypes"
 )
@@ -24,9 +25,10 @@ const waitReadPeriod time.Duration = 30 * time.Millisecond
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "local.file",
-		Args:    Arguments{},
-		Exports: Exports{},
+		Name:      "local.file",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
+		Exports:   Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -85,8 +87,9 @@ type Component struct {
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
 )
 
 // New creates a new local.file component.
@@ -154,6 +157,15 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// Refresh implements component.RefreshableComponent and rereads the file.
+func (c *Component) Refresh() {
+	select {
+	case c.reloadCh <- struct{}{}:
+	default:
+		// A reload is already pending.
+	}
+}
+
 func (c *Component) readFile() error {
 	// Force a re-load of the file outside of the update detection mechanism.
 	bb, err := os.ReadFile(c.args.Filename)
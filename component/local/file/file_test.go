@@ -8,9 +8,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/local/file"
 	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/river/rivertypes"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -115,6 +118,41 @@ func TestFile_ImmediateExports(t *testing.T) {
 	}, tc.Exports())
 }
 
+// TestFile_Refresh validates that refreshing a local.file component rereads
+// the file without waiting for the next poll.
+func TestFile_Refresh(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "testfile")
+	require.NoError(t, os.WriteFile(testFile, []byte("Hello, world!"), 0664))
+
+	exports := make(chan component.Exports, 10)
+	c, err := file.New(component.Options{
+		ID:            "local.file.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) { exports <- e },
+	}, file.Arguments{
+		Filename:      testFile,
+		Type:          file.DetectorPoll,
+		PollFrequency: 1 * time.Hour,
+	})
+	require.NoError(t, err)
+	<-exports
+
+	ctx, cancel := context.WithCancel(componenttest.TestContext(t))
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	require.NoError(t, os.WriteFile(testFile, []byte("New content!"), 0664))
+	c.Refresh()
+
+	select {
+	case e := <-exports:
+		require.Equal(t, "New content!", e.(file.Exports).Content.Value)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "component was not refreshed")
+	}
+}
+
 // TestFile_ExistOnLoad ensures that the configured file must exist on the
 // first load of local.file.
 func TestFile_ExistOnLoad(t *testing.T) {
@@ -17,12 +17,14 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name: "loki.source.journal",
-		Args: Arguments{},
+		Name:      "loki.source.journal",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -170,7 +170,9 @@ func hasFieldOfType(obj interface{}, fieldType reflect.Type) bool {
 			return true
 		}
 
-		if fv.Kind() == reflect.Interface && fieldType.AssignableTo(ft) {
+		// Fields of an empty interface type, such as any, accept every value
+		// and aren't consumers of a specific type.
+		if fv.Kind() == reflect.Interface && ft.NumMethod() > 0 && fieldType.AssignableTo(ft) {
 			return true
 		}
 
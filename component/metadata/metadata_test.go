@@ -74,6 +74,12 @@ func Test_inferMetadata(t *testing.T) {
 				exports: []Type{},
 			},
 		},
+		{
+			// Arguments of type any don't make module.foreach a consumer of
+			// every type.
+			name:     "module.foreach",
+			expected: Metadata{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
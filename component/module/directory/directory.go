@@ -0,0 +1,247 @@
+// Package directory implements the module.directory component.
+package directory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "module.directory",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the module.directory component.
+type Arguments struct {
+	Path          string        `river:"path,attr"`
+	Glob          string        `river:"glob,attr,optional"`
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
+	Retry     module.RetryArguments `river:"retry,block,optional"`
+	Arguments map[string]any        `river:"arguments,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Glob:          module.DefaultDirectoryGlob,
+	PollFrequency: time.Minute,
+	Retry:         module.DefaultRetryArguments,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if _, err := filepath.Match(args.Glob, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", args.Glob, err)
+	}
+	if args.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	return module.ValidateVersionConstraint(args.Version)
+}
+
+// Component implements the module.directory component.
+type Component struct {
+	opts component.Options
+	log  log.Logger
+	mod  *module.ModuleComponent
+
+	mut  sync.RWMutex
+	args Arguments
+
+	argsChanged chan struct{}
+	refresh     chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.DebugComponent       = (*Component)(nil)
+	_ module.InfoComponent           = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
+)
+
+// New creates a new module.directory component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := module.NewModuleComponent(o)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{
+		opts: o,
+		log:  o.Logger,
+
+		mod: m,
+
+		argsChanged: make(chan struct{}, 1),
+		refresh:     make(chan struct{}, 1),
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go c.mod.RunFlowController(ctx)
+
+	c.mut.RLock()
+	timer := time.NewTimer(c.args.PollFrequency)
+	backoff := module.NewBackoff(c.args.Retry)
+	c.mut.RUnlock()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-c.argsChanged:
+			c.mut.RLock()
+			backoff.SetArguments(c.args.Retry)
+			module.ResetTimer(timer, c.args.PollFrequency)
+			c.mut.RUnlock()
+
+		case <-timer.C:
+			err := c.tickPollDirectory()
+			c.mut.RLock()
+			timer.Reset(backoff.Next(err, c.args.PollFrequency))
+			c.mut.RUnlock()
+
+		case <-c.refresh:
+			level.Info(c.log).Log("msg", "reading module directory on request")
+			err := c.tickPollDirectory()
+			c.mut.RLock()
+			module.ResetTimer(timer, backoff.Next(err, c.args.PollFrequency))
+			c.mut.RUnlock()
+		}
+	}
+}
+
+// Refresh implements component.RefreshableComponent and rereads the module
+// directory as soon as possible.
+func (c *Component) Refresh() {
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Component) tickPollDirectory() error {
+	c.mut.RLock()
+	err := c.pollDirectory(c.args)
+	c.mut.RUnlock()
+
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to load module directory", "err", err)
+	}
+	c.updateHealth(err)
+	return err
+}
+
+func (c *Component) updateHealth(err error) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	if err != nil {
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		c.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) (err error) {
+	defer func() {
+		c.updateHealth(err)
+	}()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+	if err := c.pollDirectory(newArgs); err != nil {
+		return err
+	}
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case c.argsChanged <- struct{}{}:
+	default:
+	}
+
+	c.args = newArgs
+	return nil
+}
+
+// pollDirectory reads the matching files in the directory and updates the
+// controller. The module is only reloaded when the combined content of the
+// files changed, including when files were added or removed.
+func (c *Component) pollDirectory(args Arguments) error {
+	content, err := module.ReadDirectory(args.Path, args.Glob)
+	if err != nil {
+		c.mod.RecordFetchFailure()
+		return err
+	}
+	return c.mod.LoadVerifiedFlowSource(args.Arguments, content, module.Requirements{
+		Version: args.Version,
+	})
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+
+	return component.LeastHealthy(c.health, c.mod.CurrentHealth())
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return c.mod.DebugInfo()
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
+}
@@ -0,0 +1,68 @@
+package directory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/component/module/moduletest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"a.river": []byte("// a"),
+		"b.river": []byte("// b"),
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0644))
+	}
+	// Files which don't match the glob and subdirectories are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.river"), 0755))
+
+	ctrl := &moduletest.FakeModuleController{}
+	opts := component.Options{
+		ID:               "module.directory.test",
+		Logger:           log.NewNopLogger(),
+		DataPath:         t.TempDir(),
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: ctrl,
+		OnStateChange:    func(component.Exports) {},
+	}
+
+	var args Arguments
+	args.SetToDefault()
+	args.Path = dir
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, []string{module.CombineFiles(files)}, ctrl.Loaded())
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+
+	// Updating without changes to the files doesn't reload the module.
+	require.NoError(t, c.Update(args))
+	require.Len(t, ctrl.Loaded(), 1)
+
+	// Added files are merged into the module.
+	files["c.river"] = []byte("// c")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.river"), files["c.river"], 0644))
+	require.NoError(t, c.Update(args))
+	require.Equal(t, module.CombineFiles(files), ctrl.Loaded()[1])
+
+	// Changing the glob changes the files which are merged.
+	args.Glob = "a.*"
+	require.NoError(t, c.Update(args))
+	require.Equal(t, module.CombineFiles(map[string][]byte{"a.river": files["a.river"]}), ctrl.Loaded()[2])
+
+	// A glob without matches fails the update.
+	args.Glob = "*.yaml"
+	require.ErrorContains(t, c.Update(args), `no files in `+dir+` match "*.yaml"`)
+	require.Len(t, ctrl.Loaded(), 3)
+	require.Equal(t, component.HealthTypeUnhealthy, c.CurrentHealth().Health)
+}
@@ -2,21 +2,25 @@ package file
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/local/file"
 	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/river/rivertypes"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "module.file",
-		Args:    Arguments{},
-		Exports: module.Exports{},
+		Name:      "module.file",
+		Stability: featuregate.StabilityBeta,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -28,6 +32,13 @@ func init() {
 type Arguments struct {
 	LocalFileArguments file.Arguments `river:",squash"`
 
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
+	// Debounce is how long to wait after the file changes before reloading
+	// the module. Changes made within the window are loaded together.
+	Debounce time.Duration `river:"debounce,attr,optional"`
+
 	// Arguments to pass into the module.
 	Arguments map[string]any `river:"arguments,block,optional"`
 }
@@ -37,6 +48,19 @@ func (a *Arguments) SetToDefault() {
 	a.LocalFileArguments = file.DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.Debounce < 0 {
+		return fmt.Errorf("debounce must not be negative")
+	}
+	return module.ValidateVersionConstraint(a.Version)
+}
+
+// requirements returns the requirements which the module content must meet.
+func (a *Arguments) requirements() module.Requirements {
+	return module.Requirements{Version: a.Version}
+}
+
 // Component implements the module.file component.
 type Component struct {
 	opts component.Options
@@ -49,11 +73,17 @@ type Component struct {
 	managedLocalFile *file.Component
 	inUpdate         atomic.Bool
 	isCreated        atomic.Bool
+
+	reloadMut   sync.Mutex
+	reloadTimer *time.Timer // Pending reload while changes are debounced.
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.DebugComponent       = (*Component)(nil)
+	_ module.InfoComponent           = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
 )
 
 // New creates a new module.file component.
@@ -84,11 +114,12 @@ func New(o component.Options, args Arguments) (*Component, error) {
 func (c *Component) newManagedLocalComponent(o component.Options) (*file.Component, error) {
 	localFileOpts := o
 	localFileOpts.OnStateChange = func(e component.Exports) {
-		c.setContent(e.(file.Exports).Content)
+		content := e.(file.Exports).Content
+		changed := content != c.getContent()
+		c.setContent(content)
 
 		if !c.inUpdate.Load() && c.isCreated.Load() {
-			// Any errors found here are reported via component health
-			_ = c.mod.LoadFlowSource(c.getArgs().Arguments, c.getContent().Value)
+			c.scheduleReload(changed)
 		}
 	}
 
@@ -110,6 +141,8 @@ func (c *Component) Run(ctx context.Context) error {
 
 	go c.mod.RunFlowController(ctx)
 
+	defer c.cancelReload()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -135,7 +168,53 @@ func (c *Component) Update(args component.Arguments) error {
 
 	// Force a content load here and bubble up any error. This will catch problems
 	// on initial load.
-	return c.mod.LoadFlowSource(newArgs.Arguments, c.getContent().Value)
+	return c.mod.LoadVerifiedFlowSource(newArgs.Arguments, c.getContent().Value, newArgs.requirements())
+}
+
+// scheduleReload reloads the module with the current content of the file. If
+// debounce is set, the reload is delayed until the file hasn't changed for
+// the duration of debounce.
+func (c *Component) scheduleReload(changed bool) {
+	debounce := c.getArgs().Debounce
+	if debounce <= 0 {
+		c.reload()
+		return
+	}
+
+	// local.file may export the same content again, which mustn't delay a
+	// pending reload.
+	if !changed {
+		return
+	}
+
+	c.reloadMut.Lock()
+	defer c.reloadMut.Unlock()
+	if c.reloadTimer != nil {
+		c.reloadTimer.Stop()
+	}
+	c.reloadTimer = time.AfterFunc(debounce, c.reload)
+}
+
+// cancelReload stops a pending reload, if any.
+func (c *Component) cancelReload() {
+	c.reloadMut.Lock()
+	defer c.reloadMut.Unlock()
+	if c.reloadTimer != nil {
+		c.reloadTimer.Stop()
+		c.reloadTimer = nil
+	}
+}
+
+func (c *Component) reload() {
+	// Any errors found here are reported via component health
+	args := c.getArgs()
+	_ = c.mod.LoadVerifiedFlowSource(args.Arguments, c.getContent().Value, args.requirements())
+}
+
+// Refresh implements component.RefreshableComponent and rereads the module
+// file.
+func (c *Component) Refresh() {
+	c.managedLocalFile.Refresh()
 }
 
 // CurrentHealth implements component.HealthComponent.
@@ -152,6 +231,16 @@ func (c *Component) CurrentHealth() component.Health {
 	return leastHealthy
 }
 
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return c.mod.DebugInfo()
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
+}
+
 // getArgs is a goroutine safe way to get args
 func (c *Component) getArgs() Arguments {
 	c.mut.RLock()
@@ -0,0 +1,65 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/local/file"
+	"github.com/grafana/agent/component/module/moduletest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebounce(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "module.river")
+	require.NoError(t, os.WriteFile(filename, []byte("// version 0"), 0644))
+
+	ctrl := &moduletest.FakeModuleController{}
+	opts := component.Options{
+		ID:               "module.file.test",
+		Logger:           log.NewNopLogger(),
+		DataPath:         t.TempDir(),
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: ctrl,
+		OnStateChange:    func(component.Exports) {},
+	}
+
+	var args Arguments
+	args.SetToDefault()
+	args.LocalFileArguments.Filename = filename
+	args.LocalFileArguments.Type = file.DetectorPoll
+	args.LocalFileArguments.PollFrequency = 10 * time.Millisecond
+	args.Debounce = 500 * time.Millisecond
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, []string{"// version 0"}, ctrl.Loaded())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = c.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Changes made within the debounce window are loaded together.
+	for _, content := range []string{"// version 1", "// version 2", "// version 3"} {
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.Equal(t, []string{"// version 0"}, ctrl.Loaded())
+
+	require.Eventually(t, func() bool {
+		return len(ctrl.Loaded()) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"// version 0", "// version 3"}, ctrl.Loaded())
+}
@@ -0,0 +1,330 @@
+// Package foreach implements the module.foreach component.
+package foreach
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+	"github.com/grafana/river/rivertypes"
+	"github.com/grafana/river/scanner"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "module.foreach",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// eachArgument is the name of the module argument which receives the element
+// of the collection.
+const eachArgument = "each"
+
+// Arguments holds values which are used to configure the module.foreach
+// component.
+type Arguments struct {
+	// Collection to run a module for each element of. Must be a list or an
+	// object.
+	Collection any `river:"collection,attr"`
+
+	// Content to load for each module.
+	Content rivertypes.OptionalSecret `river:"content,attr"`
+
+	// Arguments to pass into every module, in addition to the element.
+	Arguments map[string]any `river:"arguments,block,optional"`
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if _, ok := args.Arguments[eachArgument]; ok {
+		return fmt.Errorf("the %q argument is set to the element of the collection and can't be set in the arguments block", eachArgument)
+	}
+	_, err := args.elements()
+	return err
+}
+
+// elements returns the elements of the collection by key. Elements of a list
+// are keyed by their index.
+func (args *Arguments) elements() (map[string]any, error) {
+	elements := make(map[string]any)
+
+	switch collection := args.Collection.(type) {
+	case []any:
+		for i, elem := range collection {
+			elements[strconv.Itoa(i)] = elem
+		}
+	case map[string]any:
+		for key, elem := range collection {
+			if !scanner.IsValidIdentifier(moduleID(key)) {
+				return nil, fmt.Errorf("collection key %q may only contain letters, digits and underscores", key)
+			}
+			elements[key] = elem
+		}
+	default:
+		return nil, fmt.Errorf("collection must be a list or an object, got %T", args.Collection)
+	}
+	return elements, nil
+}
+
+// moduleID returns the ID of the module which runs for the element with the
+// given key.
+func moduleID(key string) string {
+	return "each_" + key
+}
+
+// Exports holds values which are exported from the run modules.
+type Exports struct {
+	// Exports of every module, by the key of its element.
+	Exports map[string]any `river:"exports,attr"`
+}
+
+// Component implements the module.foreach component.
+type Component struct {
+	opts component.Options
+	log  log.Logger
+
+	mut       sync.Mutex
+	ctx       context.Context // Context of Run; nil if the component isn't running.
+	instances map[string]*instance
+
+	exportsMut     sync.Mutex
+	exports        map[string]any
+	holdExports    bool // Set while Update reloads the modules.
+	pendingExports bool // Set if exports changed while they were held.
+}
+
+// instance is the module which runs for a single element of the collection.
+type instance struct {
+	mod     component.Module
+	content string
+	args    map[string]any
+
+	cancel context.CancelFunc // Set while the module is running.
+	done   chan struct{}
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new module.foreach component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts: o,
+		log:  o.Logger,
+
+		instances: make(map[string]*instance),
+		exports:   make(map[string]any),
+	}
+	o.OnStateChange(Exports{Exports: map[string]any{}})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	c.mut.Lock()
+	c.ctx = ctx
+	for key, inst := range c.instances {
+		c.startInstance(key, inst)
+	}
+	c.mut.Unlock()
+
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for _, inst := range c.instances {
+		inst.stop()
+	}
+	c.ctx = nil
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	elements, err := newArgs.elements()
+	if err != nil {
+		return err
+	}
+
+	// Dependants see the exports of all modules after they've been reloaded,
+	// rather than a mix of old and new exports while the modules reload one
+	// by one.
+	c.holdExportUpdates()
+	defer c.releaseExportUpdates()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Stop the modules of elements which were removed from the collection.
+	for key, inst := range c.instances {
+		if _, ok := elements[key]; ok {
+			continue
+		}
+		inst.stop()
+		delete(c.instances, key)
+		c.removeExports(key)
+	}
+
+	keys := make([]string, 0, len(elements))
+	for key := range elements {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		moduleArgs := make(map[string]any, len(newArgs.Arguments)+1)
+		for name, value := range newArgs.Arguments {
+			moduleArgs[name] = value
+		}
+		moduleArgs[eachArgument] = elements[key]
+
+		inst, ok := c.instances[key]
+		if !ok {
+			key := key
+			mod, err := c.opts.ModuleController.NewModule(moduleID(key), func(exports map[string]any) {
+				c.setExports(key, exports)
+			})
+			if err != nil {
+				return fmt.Errorf("creating module for element %q: %w", key, err)
+			}
+			inst = &instance{mod: mod}
+			c.instances[key] = inst
+		}
+
+		// Modules whose element didn't change aren't reloaded.
+		if inst.content == newArgs.Content.Value && reflect.DeepEqual(inst.args, moduleArgs) {
+			continue
+		}
+		if err := inst.mod.LoadConfig([]byte(newArgs.Content.Value), moduleArgs); err != nil {
+			return fmt.Errorf("loading module for element %q: %w", key, err)
+		}
+		inst.content = newArgs.Content.Value
+		inst.args = moduleArgs
+
+		if c.ctx != nil && inst.cancel == nil {
+			c.startInstance(key, inst)
+		}
+	}
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent. It reports the least
+// healthy of the components running within the modules.
+func (c *Component) CurrentHealth() component.Health {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	health := component.Health{Health: component.HealthTypeHealthy}
+	for key, inst := range c.instances {
+		hm, ok := inst.mod.(component.HealthModule)
+		if !ok {
+			continue
+		}
+		h := hm.CurrentHealth()
+		if h.Health == component.HealthTypeHealthy {
+			continue
+		}
+		h.Message = fmt.Sprintf("module for element %q: %s", key, h.Message)
+		health = component.LeastHealthy(health, h)
+	}
+	return health
+}
+
+// startInstance runs the module of inst until Run exits or the element is
+// removed. startInstance must only be called with c.mut held.
+func (c *Component) startInstance(key string, inst *instance) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	inst.cancel = cancel
+	inst.done = make(chan struct{})
+
+	go func() {
+		defer close(inst.done)
+		if err := inst.mod.Run(ctx); err != nil {
+			level.Error(c.log).Log("msg", "failed to run module", "key", key, "err", err)
+		}
+	}()
+}
+
+// stop stops the module of inst if it's running and waits for it to exit.
+func (inst *instance) stop() {
+	if inst.cancel == nil {
+		return
+	}
+	inst.cancel()
+	<-inst.done
+	inst.cancel = nil
+}
+
+func (c *Component) setExports(key string, exports map[string]any) {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+
+	c.exports[key] = exports
+	c.publishExports()
+}
+
+func (c *Component) removeExports(key string) {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+
+	delete(c.exports, key)
+	c.publishExports()
+}
+
+// holdExportUpdates defers publishing changes to the exports until
+// releaseExportUpdates is called.
+func (c *Component) holdExportUpdates() {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+
+	c.holdExports = true
+}
+
+// releaseExportUpdates publishes the changes to the exports made since
+// holdExportUpdates was called, if any.
+func (c *Component) releaseExportUpdates() {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+
+	c.holdExports = false
+	if c.pendingExports {
+		c.pendingExports = false
+		c.publishExports()
+	}
+}
+
+// publishExports must only be called with c.exportsMut held.
+func (c *Component) publishExports() {
+	if c.holdExports {
+		c.pendingExports = true
+		return
+	}
+
+	exports := make(map[string]any, len(c.exports))
+	for key, value := range c.exports {
+		exports[key] = value
+	}
+	c.opts.OnStateChange(Exports{Exports: exports})
+}
@@ -0,0 +1,70 @@
+package foreach
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate_PublishesExportsOnce(t *testing.T) {
+	var published []string
+	opts := component.Options{
+		ID:               "module.foreach.test",
+		Logger:           log.NewNopLogger(),
+		ModuleController: fakeModuleController{},
+		OnStateChange: func(e component.Exports) {
+			published = append(published, fmt.Sprint(e.(Exports).Exports))
+		},
+	}
+
+	args := Arguments{
+		Collection: []any{1, 2},
+		Content:    rivertypes.OptionalSecret{Value: "first"},
+	}
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"map[]",
+		"map[0:map[content:first each:1] 1:map[content:first each:2]]",
+	}, published)
+
+	// Reloading every module publishes the new exports of all modules at once.
+	published = nil
+	args.Content = rivertypes.OptionalSecret{Value: "second"}
+	require.NoError(t, c.Update(args))
+	require.Equal(t, []string{
+		"map[0:map[content:second each:1] 1:map[content:second each:2]]",
+	}, published)
+
+	// Unchanged modules don't publish their exports again.
+	published = nil
+	require.NoError(t, c.Update(args))
+	require.Empty(t, published)
+}
+
+// fakeModuleController creates modules which export their content and the
+// value of the each argument as soon as they're loaded.
+type fakeModuleController struct{}
+
+func (fakeModuleController) NewModule(_ string, export component.ExportFunc) (component.Module, error) {
+	return fakeModule{export: export}, nil
+}
+
+type fakeModule struct {
+	export component.ExportFunc
+}
+
+func (m fakeModule) LoadConfig(config []byte, args map[string]any) error {
+	m.export(map[string]any{"content": string(config), eachArgument: args[eachArgument]})
+	return nil
+}
+
+func (fakeModule) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
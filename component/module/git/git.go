@@ -3,7 +3,12 @@ package git
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"sync"
@@ -12,15 +17,19 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/vcs"
 	"github.com/grafana/agent/pkg/flow/logging/level"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "module.git",
-		Args:    Arguments{},
-		Exports: module.Exports{},
+		Name:      "module.git",
+		Stability: featuregate.StabilityBeta,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -35,14 +44,26 @@ type Arguments struct {
 	Path          string        `river:"path,attr"`
 	PullFrequency time.Duration `river:"pull_frequency,attr,optional"`
 
-	Arguments     map[string]any    `river:"arguments,block,optional"`
-	GitAuthConfig vcs.GitAuthConfig `river:",squash"`
+	// SparseCheckout limits the directories of the repository which are
+	// read.
+	SparseCheckout []string `river:"sparse_checkout,attr,optional"`
+
+	// SHA256 is the expected checksum of the module content.
+	SHA256 string `river:"sha256,attr,optional"`
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
+	Retry              module.RetryArguments  `river:"retry,block,optional"`
+	Arguments          map[string]any         `river:"arguments,block,optional"`
+	GitAuthConfig      vcs.GitAuthConfig      `river:",squash"`
+	GitTransportConfig vcs.GitTransportConfig `river:",squash"`
 }
 
 // DefaultArguments holds default settings for Arguments.
 var DefaultArguments = Arguments{
 	Revision:      "HEAD",
 	PullFrequency: time.Minute,
+	Retry:         module.DefaultRetryArguments,
 }
 
 // SetToDefault implements river.Defaulter.
@@ -50,6 +71,27 @@ func (args *Arguments) SetToDefault() {
 	*args = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if vcs.IsTagPattern(args.Revision) {
+		if _, err := path.Match(args.Revision, ""); err != nil {
+			return fmt.Errorf("invalid revision pattern %q: %w", args.Revision, err)
+		}
+	}
+	if err := args.GitTransportConfig.Validate(); err != nil {
+		return err
+	}
+	if err := module.ValidateChecksum(args.SHA256); err != nil {
+		return err
+	}
+	return module.ValidateVersionConstraint(args.Version)
+}
+
+// requirements returns the requirements which the module content must meet.
+func (args *Arguments) requirements() module.Requirements {
+	return module.Requirements{SHA256: args.SHA256, Version: args.Version}
+}
+
 // Component implements the module.git component.
 type Component struct {
 	opts component.Options
@@ -61,15 +103,21 @@ type Component struct {
 	repoOpts vcs.GitRepoOptions
 	args     Arguments
 
-	argsChanged chan struct{}
+	argsChanged   chan struct{}
+	refresh       chan struct{}
+	cachedContent string // Module content last written to the cache.
+	cachedKey     string // Cache key of cachedContent.
 
 	healthMut sync.RWMutex
 	health    component.Health
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.DebugComponent       = (*Component)(nil)
+	_ module.InfoComponent           = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
 )
 
 // New creates a new module.git component.
@@ -85,13 +133,14 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		mod: m,
 
 		argsChanged: make(chan struct{}, 1),
+		refresh:     make(chan struct{}, 1),
 	}
 
 	// Only acknowledge the error from Update if it's not a
-	// vcs.UpdateFailedError; vcs.UpdateFailedError means that the Git repo
-	// exists but we were just unable to update it.
+	// vcs.UpdateFailedError or a cachedModuleError; both mean that the module
+	// is running, but we were unable to update it.
 	if err := c.Update(args); err != nil {
-		if errors.As(err, &vcs.UpdateFailedError{}) {
+		if errors.As(err, &vcs.UpdateFailedError{}) || errors.As(err, &cachedModuleError{}) {
 			level.Error(c.log).Log("msg", "failed to update repository", "err", err)
 		} else {
 			return nil, err
@@ -107,10 +156,27 @@ func (c *Component) Run(ctx context.Context) error {
 
 	go c.mod.RunFlowController(ctx)
 
-	var (
-		ticker  *time.Ticker
-		tickerC <-chan time.Time
-	)
+	c.mut.RLock()
+	backoff := module.NewBackoff(c.args.Retry)
+	c.mut.RUnlock()
+
+	// timer is only running while the repository is polled, which is when
+	// pull_frequency is set or after a failed pull.
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	// schedule resets the timer after a pull. c.mut must be held.
+	schedule := func(err error) {
+		next := backoff.Next(err, c.args.PullFrequency)
+		if next > 0 {
+			module.ResetTimer(timer, next)
+		} else {
+			timer.Stop()
+		}
+	}
 
 	for {
 		select {
@@ -122,36 +188,52 @@ func (c *Component) Run(ctx context.Context) error {
 			{
 				level.Info(c.log).Log("msg", "updating repository pull frequency", "new_frequency", c.args.PullFrequency)
 
-				if c.args.PullFrequency > 0 {
-					if ticker == nil {
-						ticker = time.NewTicker(c.args.PullFrequency)
-						tickerC = ticker.C
-					} else {
-						ticker.Reset(c.args.PullFrequency)
-					}
+				backoff.SetArguments(c.args.Retry)
+				if c.repo == nil {
+					// The cached module is running; retry downloading the
+					// repository with backoff.
+					schedule(errRepoNotDownloaded)
 				} else {
-					if ticker != nil {
-						ticker.Stop()
-					}
-					ticker = nil
-					tickerC = nil
+					schedule(nil)
 				}
 			}
 			c.mut.Unlock()
 
-		case <-tickerC:
+		case <-timer.C:
 			level.Info(c.log).Log("msg", "updating repository", "new_frequency", c.args.PullFrequency)
-			c.tickPollFile(ctx)
+			err := c.tickPollFile(ctx)
+
+			c.mut.Lock()
+			schedule(err)
+			c.mut.Unlock()
+
+		case <-c.refresh:
+			level.Info(c.log).Log("msg", "updating repository on request")
+			err := c.tickPollFile(ctx)
+
+			c.mut.Lock()
+			schedule(err)
+			c.mut.Unlock()
 		}
 	}
 }
 
-func (c *Component) tickPollFile(ctx context.Context) {
+// Refresh implements component.RefreshableComponent and pulls the repository
+// as soon as possible.
+func (c *Component) Refresh() {
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Component) tickPollFile(ctx context.Context) error {
 	c.mut.Lock()
 	err := c.pollFile(ctx, c.args)
 	c.mut.Unlock()
 
 	c.updateHealth(err)
+	return err
 }
 
 func (c *Component) updateHealth(err error) {
@@ -184,31 +266,43 @@ func (c *Component) Update(args component.Arguments) (err error) {
 
 	newArgs := args.(Arguments)
 
-	// TODO(rfratto): store in a repo-specific directory so changing repositories
-	// doesn't risk break the module loader if there's a SHA collision between
-	// the two different repositories.
-	repoPath := filepath.Join(c.opts.DataPath, "repo")
-
 	repoOpts := vcs.GitRepoOptions{
-		Repository: newArgs.Repository,
-		Revision:   newArgs.Revision,
-		Auth:       newArgs.GitAuthConfig,
+		Repository:                newArgs.Repository,
+		Revision:                  newArgs.Revision,
+		Auth:                      newArgs.GitAuthConfig,
+		Transport:                 newArgs.GitTransportConfig,
+		SparseCheckoutDirectories: newArgs.SparseCheckout,
 	}
 
 	// Create or update the repo field.
 	// Failure to update repository makes the module loader temporarily use cached contents on disk
 	if c.repo == nil || !reflect.DeepEqual(repoOpts, c.repoOpts) {
-		r, err := vcs.NewGitRepo(context.Background(), repoPath, repoOpts)
+		r, err := vcs.NewGitRepo(context.Background(), c.repoPath(repoOpts), repoOpts)
 		if err != nil {
+			c.mod.RecordFetchFailure()
 			if errors.As(err, &vcs.UpdateFailedError{}) {
 				level.Error(c.log).Log("msg", "failed to update repository", "err", err)
 				c.updateHealth(err)
+			} else if errors.As(err, &vcs.DownloadFailedError{}) && c.loadCachedModule(newArgs) == nil {
+				// The module keeps running with the cached content until the
+				// repository can be downloaded on the next poll.
+				level.Warn(c.log).Log("msg", "failed to download repository, running the cached module", "err", err)
+
+				c.repo = nil
+				c.repoOpts = repoOpts
+				c.args = newArgs
+				select {
+				case c.argsChanged <- struct{}{}:
+				default:
+				}
+				return cachedModuleError{Inner: err}
 			} else {
 				return err
 			}
 		}
 		c.repo = r
 		c.repoOpts = repoOpts
+		c.removeStaleRepos()
 	}
 
 	if err := c.pollFile(context.Background(), newArgs); err != nil {
@@ -227,19 +321,189 @@ func (c *Component) Update(args component.Arguments) (err error) {
 
 // pollFile fetches the latest content from the repository and updates the
 // controller. pollFile must only be called with c.mut held.
-func (c *Component) pollFile(ctx context.Context, args Arguments) error {
+func (c *Component) pollFile(ctx context.Context, args Arguments) (err error) {
+	ctx, span := c.mod.StartFetch(ctx)
+	span.SetAttributes(
+		attribute.String("repository", args.Repository),
+		attribute.String("revision", args.Revision),
+		attribute.String("path", args.Path),
+	)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// Download the repository if it couldn't be downloaded before.
+	if c.repo == nil {
+		r, err := vcs.NewGitRepo(ctx, c.repoPath(c.repoOpts), c.repoOpts)
+		if err != nil && !errors.As(err, &vcs.UpdateFailedError{}) {
+			c.mod.RecordFetchFailure()
+			return cachedModuleError{Inner: err}
+		}
+		c.repo = r
+		c.removeStaleRepos()
+	}
+
 	// Make sure our repo is up-to-date.
 	if err := c.repo.Update(ctx); err != nil {
+		c.mod.RecordFetchFailure()
 		return err
 	}
 
 	// Finally, configure our controller.
-	bb, err := c.repo.ReadFile(args.Path)
+	content, err := c.readModule(args.Path)
 	if err != nil {
+		c.mod.RecordFetchFailure()
+		return err
+	}
+
+	if err := c.mod.LoadVerifiedFlowSourceContext(ctx, args.Arguments, content, args.requirements()); err != nil {
 		return err
 	}
+	c.writeCachedModule(args, content)
+	return nil
+}
+
+// reposPath returns the directory which holds the clones of every repository
+// the component has used.
+func (c *Component) reposPath() string {
+	return filepath.Join(c.opts.DataPath, "repos")
+}
 
-	return c.mod.LoadFlowSource(args.Arguments, string(bb))
+// repoPath returns the directory to clone the repository described by opts
+// into. Each repository gets its own directory, so that switching
+// repositories never reuses a clone of a different repository. Revisions of
+// the same repository share the clone, and are checked out in place.
+func (c *Component) repoPath(opts vcs.GitRepoOptions) string {
+	hash := sha256.Sum256([]byte(opts.Repository))
+	return filepath.Join(c.reposPath(), hex.EncodeToString(hash[:8]))
+}
+
+// removeStaleRepos removes clones of repositories other than the current one,
+// along with the clone used by older versions of the component. Failures are
+// only logged, since stale clones don't affect the running module.
+// removeStaleRepos must only be called with c.mut held.
+func (c *Component) removeStaleRepos() {
+	current := filepath.Base(c.repoPath(c.repoOpts))
+
+	stale := []string{filepath.Join(c.opts.DataPath, "repo")}
+	entries, err := os.ReadDir(c.reposPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		level.Warn(c.log).Log("msg", "failed to list repository clones", "err", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != current {
+			stale = append(stale, filepath.Join(c.reposPath(), entry.Name()))
+		}
+	}
+
+	for _, dir := range stale {
+		if err := os.RemoveAll(dir); err != nil {
+			level.Warn(c.log).Log("msg", "failed to remove stale repository clone", "path", dir, "err", err)
+		}
+	}
+}
+
+// cacheDir returns the directory which holds the cached module content.
+func (c *Component) cacheDir() string {
+	return filepath.Join(c.opts.DataPath, "cache")
+}
+
+// cacheKey identifies the module content read from the repository, revision
+// and path in args, so that cached content is never loaded for a different
+// module.
+func cacheKey(args Arguments) string {
+	hash := sha256.Sum256([]byte(args.Repository + "\x00" + args.Revision + "\x00" + args.Path))
+	return hex.EncodeToString(hash[:8])
+}
+
+// cachePath returns the path of the last module content which was loaded
+// successfully for args.
+func (c *Component) cachePath(args Arguments) string {
+	return filepath.Join(c.cacheDir(), cacheKey(args)+".river")
+}
+
+// writeCachedModule persists content so that the module can be loaded after a
+// restart, even if the repository can't be downloaded. Content cached for
+// other arguments is removed. Failures are only logged, since the module
+// itself was loaded successfully.
+func (c *Component) writeCachedModule(args Arguments, content string) {
+	key := cacheKey(args)
+	if content == c.cachedContent && key == c.cachedKey {
+		return
+	}
+
+	if err := os.MkdirAll(c.cacheDir(), 0750); err != nil {
+		level.Warn(c.log).Log("msg", "failed to cache module content", "err", err)
+		return
+	}
+	if err := os.WriteFile(c.cachePath(args), []byte(content), 0640); err != nil {
+		level.Warn(c.log).Log("msg", "failed to cache module content", "err", err)
+		return
+	}
+	c.cachedContent, c.cachedKey = content, key
+
+	// Older versions of the component cached the content without a key.
+	stale := []string{filepath.Join(c.opts.DataPath, "module.river")}
+	entries, _ := os.ReadDir(c.cacheDir())
+	for _, entry := range entries {
+		if entry.Name() != key+".river" {
+			stale = append(stale, filepath.Join(c.cacheDir(), entry.Name()))
+		}
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			level.Warn(c.log).Log("msg", "failed to remove stale cached module content", "path", path, "err", err)
+		}
+	}
+}
+
+// loadCachedModule loads the module content which was cached by a previous
+// run of the component with the same repository, revision and path as args.
+func (c *Component) loadCachedModule(args Arguments) error {
+	bb, err := os.ReadFile(c.cachePath(args))
+	if err != nil {
+		return err
+	}
+	if err := c.mod.LoadVerifiedFlowSource(args.Arguments, string(bb), args.requirements()); err != nil {
+		return err
+	}
+	c.cachedContent, c.cachedKey = string(bb), cacheKey(args)
+	return nil
+}
+
+// cachedModuleError is returned when the repository can't be downloaded and
+// the module runs with the content cached by a previous run.
+type cachedModuleError struct {
+	Inner error
+}
+
+func (err cachedModuleError) Error() string {
+	return fmt.Sprintf("running the cached module: %s", err.Inner)
+}
+
+func (err cachedModuleError) Unwrap() error { return err.Inner }
+
+var errRepoNotDownloaded = errors.New("repository not downloaded")
+
+// readModule reads the module at path. If path is a directory, all module
+// files at its top level are combined into a single module.
+func (c *Component) readModule(path string) (string, error) {
+	if !c.repo.IsDir(path) {
+		bb, err := c.repo.ReadFile(path)
+		return string(bb), err
+	}
+
+	files, err := c.repo.ReadDir(path, module.DefaultDirectoryGlob)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files in %s match %q", path, module.DefaultDirectoryGlob)
+	}
+	return module.CombineFiles(files), nil
 }
 
 // CurrentHealth implements component.HealthComponent.
@@ -253,17 +517,30 @@ func (c *Component) CurrentHealth() component.Health {
 // DebugInfo implements component.DebugComponent.
 func (c *Component) DebugInfo() interface{} {
 	type DebugInfo struct {
-		SHA       string `river:"sha,attr"`
-		RepoError string `river:"repo_error,attr,optional"`
+		SHA       string           `river:"sha,attr"`
+		RepoError string           `river:"repo_error,attr,optional"`
+		Module    module.DebugInfo `river:",squash"`
 	}
 
 	c.mut.RLock()
 	defer c.mut.RUnlock()
 
+	info := DebugInfo{Module: c.mod.DebugInfo()}
+	if c.repo == nil {
+		info.RepoError = "repository not downloaded, running the cached module"
+		return info
+	}
+
 	rev, err := c.repo.CurrentRevision()
 	if err != nil {
-		return DebugInfo{RepoError: err.Error()}
+		info.RepoError = err.Error()
 	} else {
-		return DebugInfo{SHA: rev}
+		info.SHA = rev
 	}
+	return info
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
 }
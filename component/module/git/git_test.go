@@ -0,0 +1,207 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module/moduletest"
+	"github.com/grafana/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "valid",
+			config: `
+				repository = "https://github.com/grafana/agent-modules.git"
+				path       = "module.river"
+				proxy_url  = "http://proxy.example.com:3128"
+			`,
+		},
+		{
+			name: "invalid proxy_url",
+			config: `
+				repository = "https://github.com/grafana/agent-modules.git"
+				path       = "module.river"
+				proxy_url  = "not a url"
+			`,
+			expectError: `invalid proxy_url "not a url": expected SCHEME://HOST[:PORT]`,
+		},
+		{
+			name: "invalid revision pattern",
+			config: `
+				repository = "https://github.com/grafana/agent-modules.git"
+				revision   = "v1.[*"
+				path       = "module.river"
+			`,
+			expectError: `invalid revision pattern "v1.[*": syntax error in pattern`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			if tc.expectError == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectError)
+			}
+		})
+	}
+}
+
+func TestOfflineStart(t *testing.T) {
+	dataPath := t.TempDir()
+	repoDir := initRepository(t, "// version 1")
+
+	var args Arguments
+	args.SetToDefault()
+	args.Repository = repoDir
+	args.Path = "module.river"
+
+	ctrl := &moduletest.FakeModuleController{}
+	_, err := New(testOptions(dataPath, ctrl), args)
+	require.NoError(t, err)
+	require.Equal(t, []string{"// version 1"}, ctrl.Loaded())
+
+	// Neither the repository nor its clone are available after a restart, so
+	// the cached module content is loaded.
+	require.NoError(t, os.RemoveAll(repoDir))
+	require.NoError(t, os.RemoveAll(filepath.Join(dataPath, "repos")))
+
+	ctrl = &moduletest.FakeModuleController{}
+	c, err := New(testOptions(dataPath, ctrl), args)
+	require.NoError(t, err)
+	require.Equal(t, []string{"// version 1"}, ctrl.Loaded())
+	require.Equal(t, component.HealthTypeUnhealthy, c.CurrentHealth().Health)
+}
+
+func TestUpdate_Unreachable(t *testing.T) {
+	dataPath := t.TempDir()
+	repoDir := initRepository(t, "// version 1")
+
+	var args Arguments
+	args.SetToDefault()
+	args.Repository = repoDir
+	args.Path = "module.river"
+
+	ctrl := &moduletest.FakeModuleController{}
+	c, err := New(testOptions(dataPath, ctrl), args)
+	require.NoError(t, err)
+
+	// The content cached for the previous repository and path must not be
+	// loaded for other arguments.
+	tt := []struct {
+		name   string
+		modify func(args *Arguments)
+	}{
+		{"repository", func(args *Arguments) { args.Repository = filepath.Join(t.TempDir(), "missing") }},
+		{"path", func(args *Arguments) { args.Path = "other.river" }},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			newArgs := args
+			tc.modify(&newArgs)
+
+			require.NoError(t, os.RemoveAll(repoDir))
+			require.NoError(t, os.RemoveAll(filepath.Join(dataPath, "repos")))
+
+			require.Error(t, c.Update(newArgs))
+			require.Equal(t, []string{"// version 1"}, ctrl.Loaded())
+
+			_, err := New(testOptions(dataPath, &moduletest.FakeModuleController{}), newArgs)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestUpdate_Revision(t *testing.T) {
+	dataPath := t.TempDir()
+	repoDir := initRepository(t, "// version 1")
+	first, err := git.PlainOpen(repoDir)
+	require.NoError(t, err)
+	head, err := first.Head()
+	require.NoError(t, err)
+	commitModule(t, repoDir, "// version 2")
+
+	var args Arguments
+	args.SetToDefault()
+	args.Repository = repoDir
+	args.Revision = head.Hash().String()
+	args.Path = "module.river"
+
+	ctrl := &moduletest.FakeModuleController{}
+	c, err := New(testOptions(dataPath, ctrl), args)
+	require.NoError(t, err)
+	require.Equal(t, []string{"// version 1"}, ctrl.Loaded())
+
+	clones, err := os.ReadDir(filepath.Join(dataPath, "repos"))
+	require.NoError(t, err)
+	require.Len(t, clones, 1)
+	marker := filepath.Join(dataPath, "repos", clones[0].Name(), ".git", "marker")
+	require.NoError(t, os.WriteFile(marker, nil, 0644))
+
+	// Changing the revision checks it out in the existing clone.
+	args.Revision = "HEAD"
+	require.NoError(t, c.Update(args))
+	require.Equal(t, []string{"// version 1", "// version 2"}, ctrl.Loaded())
+	require.FileExists(t, marker)
+}
+
+func testOptions(dataPath string, ctrl component.ModuleController) component.Options {
+	return component.Options{
+		ID:               "module.git.test",
+		Logger:           log.NewNopLogger(),
+		DataPath:         dataPath,
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: ctrl,
+		OnStateChange:    func(component.Exports) {},
+	}
+}
+
+// initRepository creates a repository with a single commit containing
+// module.river.
+func initRepository(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	cfg := config.NewConfig()
+	cfg.User.Name = "Go test"
+	cfg.User.Email = "go-test@example.com"
+	require.NoError(t, repo.SetConfig(cfg))
+
+	commitModule(t, dir, content)
+	return dir
+}
+
+// commitModule commits module.river with the given content to the repository
+// in dir and returns the hash of the commit.
+func commitModule(t *testing.T, dir string, content string) plumbing.Hash {
+	t.Helper()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "module.river"), []byte(content), 0644))
+	_, err = worktree.Add("module.river")
+	require.NoError(t, err)
+	hash, err := worktree.Commit("update module", &git.CommitOptions{})
+	require.NoError(t, err)
+	return hash
+}
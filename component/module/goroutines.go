@@ -0,0 +1,105 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// goroutineProfileTTL is how long a goroutine profile is reused for. Taking a
+// profile briefly stops the world, and every module loader reads it when
+// metrics are collected.
+const goroutineProfileTTL = time.Second
+
+// componentLabelRegex matches the component_id profiler label, which the Flow
+// controller sets on the goroutines of every component. Goroutines started
+// by a component inherit its labels.
+var componentLabelRegex = regexp.MustCompile(`"component_id":"([^"]*)"`)
+
+// goroutines counts the goroutines of components by their ID.
+var goroutines goroutineCounter
+
+type goroutineCounter struct {
+	mut     sync.Mutex
+	takenAt time.Time
+	counts  map[string]int // Goroutines by component ID.
+}
+
+// countPrefix returns the number of goroutines of components whose ID starts
+// with prefix.
+func (gc *goroutineCounter) countPrefix(prefix string) int {
+	gc.mut.Lock()
+	defer gc.mut.Unlock()
+
+	if gc.counts == nil || time.Since(gc.takenAt) > goroutineProfileTTL {
+		gc.counts = countComponentGoroutines()
+		gc.takenAt = time.Now()
+	}
+
+	var total int
+	for id, count := range gc.counts {
+		if strings.HasPrefix(id, prefix) {
+			total += count
+		}
+	}
+	return total
+}
+
+// countComponentGoroutines takes a goroutine profile and returns the number
+// of goroutines by component ID.
+func countComponentGoroutines() map[string]int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	// Every stack in the profile starts with a line with the number of
+	// goroutines with that stack, such as "5 @ 0x43a8b6 0x40b4f3", followed by
+	// a line with the labels of the goroutines, if any.
+	counts := make(map[string]int)
+	var stackCount int
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if n, _, ok := strings.Cut(line, " @ "); ok {
+			stackCount, _ = strconv.Atoi(n)
+			continue
+		}
+		if !strings.HasPrefix(line, "# labels: ") {
+			continue
+		}
+		if m := componentLabelRegex.FindStringSubmatch(line); m != nil {
+			counts[m[1]] += stackCount
+		}
+	}
+	return counts
+}
+
+// dumpComponentGoroutines returns the stacks of the goroutines of the
+// component with the given ID and of the components in its module, in the
+// format of a goroutine profile with debug=1.
+func dumpComponentGoroutines(id string) string {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return ""
+	}
+
+	// Stacks in the profile are separated by empty lines.
+	var stacks []string
+	for _, stack := range strings.Split(buf.String(), "\n\n") {
+		m := componentLabelRegex.FindStringSubmatch(stack)
+		if m == nil {
+			continue
+		}
+		if m[1] == id || strings.HasPrefix(m[1], id+"/") {
+			stacks = append(stacks, strings.TrimSpace(stack))
+		}
+	}
+	return strings.Join(stacks, "\n\n")
+}
@@ -9,14 +9,16 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/module"
 	remote_http "github.com/grafana/agent/component/remote/http"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/river/rivertypes"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "module.http",
-		Args:    Arguments{},
-		Exports: module.Exports{},
+		Name:      "module.http",
+		Stability: featuregate.StabilityBeta,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -28,6 +30,11 @@ func init() {
 type Arguments struct {
 	RemoteHTTPArguments remote_http.Arguments `river:",squash"`
 
+	// SHA256 is the expected checksum of the module content.
+	SHA256 string `river:"sha256,attr,optional"`
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
 	Arguments map[string]any `river:"arguments,block,optional"`
 }
 
@@ -36,6 +43,19 @@ func (args *Arguments) SetToDefault() {
 	args.RemoteHTTPArguments.SetToDefault()
 }
 
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if err := module.ValidateChecksum(args.SHA256); err != nil {
+		return err
+	}
+	return module.ValidateVersionConstraint(args.Version)
+}
+
+// requirements returns the requirements which the module content must meet.
+func (args *Arguments) requirements() module.Requirements {
+	return module.Requirements{SHA256: args.SHA256, Version: args.Version}
+}
+
 // Component implements the module.http component.
 type Component struct {
 	opts component.Options
@@ -51,8 +71,11 @@ type Component struct {
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.DebugComponent       = (*Component)(nil)
+	_ module.InfoComponent           = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
 )
 
 // New creates a new module.http component.
@@ -87,7 +110,8 @@ func (c *Component) newManagedLocalComponent(o component.Options) (*remote_http.
 
 		if !c.inUpdate.Load() && c.isCreated.Load() {
 			// Any errors found here are reported via component health
-			_ = c.mod.LoadFlowSource(c.getArgs().Arguments, c.getContent().Value)
+			args := c.getArgs()
+			_ = c.mod.LoadVerifiedFlowSource(args.Arguments, c.getContent().Value, args.requirements())
 		}
 	}
 
@@ -134,7 +158,13 @@ func (c *Component) Update(args component.Arguments) error {
 
 	// Force a content load here and bubble up any error. This will catch problems
 	// on initial load.
-	return c.mod.LoadFlowSource(newArgs.Arguments, c.getContent().Value)
+	return c.mod.LoadVerifiedFlowSource(newArgs.Arguments, c.getContent().Value, newArgs.requirements())
+}
+
+// Refresh implements component.RefreshableComponent and polls the module
+// endpoint as soon as possible.
+func (c *Component) Refresh() {
+	c.managedRemoteHTTP.Refresh()
 }
 
 // CurrentHealth implements component.HealthComponent.
@@ -150,6 +180,16 @@ func (c *Component) CurrentHealth() component.Health {
 	return leastHealthy
 }
 
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return c.mod.DebugInfo()
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
+}
+
 // getArgs is a goroutine safe way to get args
 func (c *Component) getArgs() Arguments {
 	c.mut.RLock()
@@ -0,0 +1,156 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/parser"
+	"github.com/grafana/river/vm"
+)
+
+// Names of the blocks which describe a module and the arguments it accepts.
+const (
+	metaBlockName     = "module_meta"
+	argumentBlockName = "argument"
+)
+
+// Meta describes a module, as declared by the module_meta block inside of
+// the module.
+type Meta struct {
+	Name            string `river:"name,attr,optional"`
+	Version         string `river:"version,attr,optional"`
+	MinAgentVersion string `river:"min_agent_version,attr,optional"`
+	Description     string `river:"description,attr,optional"`
+
+	// Arguments describes the argument blocks declared in the module, in the
+	// order they're declared.
+	Arguments []ArgumentMeta
+}
+
+// ArgumentMeta describes an argument accepted by a module, as declared by an
+// argument block inside of the module.
+type ArgumentMeta struct {
+	Name     string `json:"name"`
+	Comment  string `json:"comment,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Optional bool   `json:"optional"`
+}
+
+// ParseMeta returns the module_meta block and the argument blocks declared
+// in content. A zero Meta is returned if content can't be parsed; errors in
+// the rest of the content are reported when the module is loaded.
+func ParseMeta(content string) (Meta, error) {
+	var (
+		meta      Meta
+		arguments []ArgumentMeta
+		foundMeta bool
+	)
+
+	file, err := parser.ParseFile("", []byte(content))
+	if err != nil {
+		return meta, nil
+	}
+	for _, stmt := range file.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+		switch block.GetBlockName() {
+		case metaBlockName:
+			if foundMeta {
+				continue
+			}
+			foundMeta = true
+			if err := vm.New(block.Body).Evaluate(nil, &meta); err != nil {
+				return meta, fmt.Errorf("decoding %s block: %w", metaBlockName, err)
+			}
+		case argumentBlockName:
+			arguments = append(arguments, parseArgumentMeta(block))
+		}
+	}
+	meta.Arguments = arguments
+	return meta, nil
+}
+
+// parseArgumentMeta describes the argument declared by block. Only
+// attributes which can be evaluated without a scope are used; an argument
+// whose default refers to other values is still reported as optional.
+func parseArgumentMeta(block *ast.BlockStmt) ArgumentMeta {
+	arg := ArgumentMeta{Name: block.Label}
+	for _, stmt := range block.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok {
+			continue
+		}
+		switch attr.Name.Name {
+		case "comment":
+			_ = vm.New(attr.Value).Evaluate(nil, &arg.Comment)
+		case "type":
+			_ = vm.New(attr.Value).Evaluate(nil, &arg.Type)
+		case "optional":
+			var optional bool
+			_ = vm.New(attr.Value).Evaluate(nil, &optional)
+			arg.Optional = arg.Optional || optional
+		case "default":
+			arg.Optional = true
+		}
+	}
+	return arg
+}
+
+// CheckVersion returns an error if the module described by meta doesn't
+// satisfy the version constraint, or if it requires a newer version of the
+// agent than the one running. An empty constraint is always satisfied.
+func (meta Meta) CheckVersion(constraint string) error {
+	if constraint != "" {
+		c, err := semver.NewConstraint(constraint)
+		if err != nil {
+			return fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+		}
+		if meta.Version == "" {
+			return fmt.Errorf("module %s doesn't declare a version, but version %q is required", meta.displayName(), constraint)
+		}
+		v, err := semver.NewVersion(meta.Version)
+		if err != nil {
+			return fmt.Errorf("module %s declares an invalid version %q: %w", meta.displayName(), meta.Version, err)
+		}
+		if !c.Check(v) {
+			return fmt.Errorf("module %s version %s doesn't satisfy the version constraint %q", meta.displayName(), meta.Version, constraint)
+		}
+	}
+
+	if meta.MinAgentVersion != "" {
+		minVersion, err := semver.NewVersion(meta.MinAgentVersion)
+		if err != nil {
+			return fmt.Errorf("module %s declares an invalid min_agent_version %q: %w", meta.displayName(), meta.MinAgentVersion, err)
+		}
+
+		// Development builds don't have a version to compare against.
+		agentVersion, err := semver.NewVersion(build.Version)
+		if err == nil && agentVersion.LessThan(minVersion) {
+			return fmt.Errorf("module %s requires Grafana Agent %s or later, but %s is running", meta.displayName(), meta.MinAgentVersion, build.Version)
+		}
+	}
+	return nil
+}
+
+func (meta Meta) displayName() string {
+	if meta.Name == "" {
+		return "(unnamed)"
+	}
+	return fmt.Sprintf("%q", meta.Name)
+}
+
+// ValidateVersionConstraint returns an error if constraint isn't empty or a
+// valid version constraint, such as ">= 1.2, < 2".
+func ValidateVersionConstraint(constraint string) error {
+	if constraint == "" {
+		return nil
+	}
+	if _, err := semver.NewConstraint(constraint); err != nil {
+		return fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package module
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons used for the reason label of agent_module_load_failures_total.
+const (
+	failureReasonFetch    = "fetch"
+	failureReasonChecksum = "checksum"
+	failureReasonVersion  = "version"
+	failureReasonLoad     = "load"
+)
+
+// metrics holds the metrics which are shared by all module loaders.
+type metrics struct {
+	loadAttempts   prometheus.Counter
+	loadFailures   *prometheus.CounterVec
+	contentBytes   prometheus.Gauge
+	contentChanges prometheus.Counter
+	lastSuccess    prometheus.Gauge
+	goroutines     prometheus.GaugeFunc
+	stuckLoads     prometheus.Counter
+}
+
+// newMetrics creates the metrics of the module loader with the given
+// component ID.
+func newMetrics(reg prometheus.Registerer, id string) (*metrics, error) {
+	m := &metrics{
+		loadAttempts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_module_load_attempts_total",
+			Help: "Total number of attempts to fetch and load the module.",
+		}),
+		loadFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_module_load_failures_total",
+			Help: "Total number of failed attempts to fetch and load the module, by reason.",
+		}, []string{"reason"}),
+		contentBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_module_content_bytes",
+			Help: "Size in bytes of the most recently loaded module content.",
+		}),
+		contentChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_module_content_changes_total",
+			Help: "Total number of times the loaded module content changed.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_module_last_success_timestamp_seconds",
+			Help: "Timestamp of the last successful attempt to fetch and load the module.",
+		}),
+		goroutines: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "agent_module_goroutines",
+			Help: "Number of goroutines run by the components of the module, including nested modules.",
+		}, func() float64 {
+			// The IDs of components in the module are prefixed with the ID of
+			// the module loader.
+			return float64(goroutines.countPrefix(id + "/"))
+		}),
+		stuckLoads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_module_stuck_loads_total",
+			Help: "Total number of loads of module content which didn't return within the timeout.",
+		}),
+	}
+
+	// Initialize the failure reasons so they're reported before the first
+	// failure.
+	for _, reason := range []string{failureReasonFetch, failureReasonChecksum, failureReasonVersion, failureReasonLoad} {
+		m.loadFailures.WithLabelValues(reason)
+	}
+
+	for _, c := range []prometheus.Collector{
+		m.loadAttempts,
+		m.loadFailures,
+		m.contentBytes,
+		m.contentChanges,
+		m.lastSuccess,
+		m.goroutines,
+		m.stuckLoads,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *metrics) observeFailure(reason string) {
+	m.loadAttempts.Inc()
+	m.loadFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *metrics) observeSuccess(content string, changed bool) {
+	m.loadAttempts.Inc()
+	m.contentBytes.Set(float64(len(content)))
+	if changed {
+		m.contentChanges.Inc()
+	}
+	m.lastSuccess.SetToCurrentTime()
+}
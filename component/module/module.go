@@ -2,24 +2,47 @@ package module
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/logging/level"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // ModuleComponent holds the common properties for module components.
 type ModuleComponent struct {
-	opts component.Options
-	mod  component.Module
+	opts    component.Options
+	mod     component.Module
+	metrics *metrics
+	tracer  trace.Tracer
 
-	mut           sync.RWMutex
-	health        component.Health
-	latestContent string
-	latestArgs    map[string]any
+	mut        sync.RWMutex
+	health     component.Health
+	latestHash [sha256.Size]byte // Hash of the last loaded content.
+	latestReq  Requirements
+	latestArgs map[string]any
+	latestMeta Meta
+	loaded     bool // Whether any content was loaded yet.
+
+	// Loads which haven't returned yet, checked by watchLoads.
+	stuckLoadTimeout time.Duration
+	loadsInFlight    int
+	loadStarted      time.Time // When the oldest load in flight started.
+	loadStuck        bool      // Whether the load in flight exceeded stuckLoadTimeout.
+
+	exportNames []string
 }
 
 // Exports holds values which are exported from the run module.
@@ -30,11 +53,23 @@ type Exports struct {
 
 // NewModuleComponent initializes a new ModuleComponent.
 func NewModuleComponent(o component.Options) (*ModuleComponent, error) {
+	m, err := newMetrics(o.Registerer, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	tracerProvider := o.Tracer
+	if tracerProvider == nil {
+		tracerProvider = noop.NewTracerProvider()
+	}
 	c := &ModuleComponent{
-		opts: o,
+		opts:    o,
+		metrics: m,
+		tracer:  tracerProvider.Tracer(""),
+
+		stuckLoadTimeout: stuckLoadTimeout,
 	}
-	var err error
 	c.mod, err = o.ModuleController.NewModule("", func(exports map[string]any) {
+		c.setExportNames(exports)
 		c.opts.OnStateChange(Exports{Exports: exports})
 	})
 	return c, err
@@ -44,12 +79,63 @@ func NewModuleComponent(o component.Options) (*ModuleComponent, error) {
 // It will set the component health in addition to return the error so that the consumer can rely on either or both.
 // If the content is the same as the last time it was successfully loaded, it will not be reloaded.
 func (c *ModuleComponent) LoadFlowSource(args map[string]any, contentValue string) error {
-	if reflect.DeepEqual(args, c.getLatestArgs()) && contentValue == c.getLatestContent() {
+	return c.LoadVerifiedFlowSource(args, contentValue, Requirements{})
+}
+
+// Requirements are checked against module content before it's loaded. The
+// zero value only enforces the min_agent_version of the module.
+type Requirements struct {
+	// SHA256 is the expected hex-encoded SHA-256 checksum of the content.
+	SHA256 string
+
+	// Version is a constraint which the version declared in the module_meta
+	// block of the content must satisfy.
+	Version string
+}
+
+// LoadVerifiedFlowSource is like LoadFlowSource, but only loads contentValue
+// if it meets req. The module keeps running its previous content if it
+// doesn't.
+//
+// If contentValue, args and req are the same as the last time the module was
+// successfully loaded, the content isn't parsed or verified again.
+func (c *ModuleComponent) LoadVerifiedFlowSource(args map[string]any, contentValue string, req Requirements) error {
+	return c.LoadVerifiedFlowSourceContext(context.Background(), args, contentValue, req)
+}
+
+// LoadVerifiedFlowSourceContext is like LoadVerifiedFlowSource, but traces
+// loading the content as a child of the span in ctx, such as the span
+// returned by StartFetch.
+func (c *ModuleComponent) LoadVerifiedFlowSourceContext(ctx context.Context, args map[string]any, contentValue string, req Requirements) (err error) {
+	hash := sha256.Sum256([]byte(contentValue))
+	if c.isUpToDate(hash, args, req) {
+		c.metrics.observeSuccess(contentValue, false)
 		return nil
 	}
 
-	err := c.mod.LoadConfig([]byte(contentValue), args)
+	c.startLoad()
+	defer c.finishLoad()
+
+	ctx, span := c.tracer.Start(ctx, "LoadModule", trace.WithSpanKind(trace.SpanKindInternal))
+	span.SetAttributes(
+		attribute.String("module_id", c.opts.ID),
+		attribute.String("content_sha256", hex.EncodeToString(hash[:])),
+	)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	meta, err := c.verify(ctx, contentValue, req)
+	if err != nil {
+		return err
+	}
+
+	err = c.loadConfig(ctx, []byte(contentValue), args)
 	if err != nil {
+		c.metrics.observeFailure(failureReasonLoad)
 		c.setHealth(component.Health{
 			Health:     component.HealthTypeUnhealthy,
 			Message:    fmt.Sprintf("failed to load module content: %s", err),
@@ -59,8 +145,8 @@ func (c *ModuleComponent) LoadFlowSource(args map[string]any, contentValue strin
 		return err
 	}
 
-	c.setLatestArgs(args)
-	c.setLatestContent(contentValue)
+	c.metrics.observeSuccess(contentValue, c.contentChanged(hash))
+	c.setLatest(hash, args, req, meta)
 	c.setHealth(component.Health{
 		Health:     component.HealthTypeHealthy,
 		Message:    "module content loaded",
@@ -70,19 +156,125 @@ func (c *ModuleComponent) LoadFlowSource(args map[string]any, contentValue strin
 	return nil
 }
 
+// verify checks that contentValue meets req and returns the module_meta of
+// contentValue.
+func (c *ModuleComponent) verify(ctx context.Context, contentValue string, req Requirements) (Meta, error) {
+	_, span := c.tracer.Start(ctx, "VerifyModule", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	if err := VerifyChecksum(contentValue, req.SHA256); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		c.metrics.observeFailure(failureReasonChecksum)
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to verify module content: %s", err),
+			UpdateTime: time.Now(),
+		})
+		return Meta{}, err
+	}
+
+	meta, err := ParseMeta(contentValue)
+	if err == nil {
+		err = meta.CheckVersion(req.Version)
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		c.metrics.observeFailure(failureReasonVersion)
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to verify module version: %s", err),
+			UpdateTime: time.Now(),
+		})
+		return Meta{}, err
+	}
+	return meta, nil
+}
+
+// loadConfig loads config into the module, passing ctx along if the module
+// supports it.
+func (c *ModuleComponent) loadConfig(ctx context.Context, config []byte, args map[string]any) error {
+	if mod, ok := c.mod.(component.ContextModule); ok {
+		return mod.LoadConfigContext(ctx, config, args)
+	}
+	return c.mod.LoadConfig(config, args)
+}
+
+// StartFetch starts a span for fetching the content of the module. Pass the
+// returned context to LoadVerifiedFlowSourceContext to trace loading the
+// fetched content as part of the fetch.
+func (c *ModuleComponent) StartFetch(ctx context.Context) (context.Context, trace.Span) {
+	ctx, span := c.tracer.Start(ctx, "FetchModule", trace.WithSpanKind(trace.SpanKindInternal))
+	span.SetAttributes(attribute.String("module_id", c.opts.ID))
+	return ctx, span
+}
+
+// VerifyChecksum returns an error if the SHA-256 checksum of content doesn't
+// match the hex-encoded checksum. An empty checksum always matches.
+func VerifyChecksum(content string, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(content))
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, checksum) {
+		return fmt.Errorf("sha256 checksum mismatch: expected %s, got %s", strings.ToLower(checksum), actual)
+	}
+	return nil
+}
+
+// ValidateChecksum returns an error if checksum isn't empty or a hex-encoded
+// SHA-256 checksum.
+func ValidateChecksum(checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	if bb, err := hex.DecodeString(checksum); err != nil || len(bb) != sha256.Size {
+		return fmt.Errorf("sha256 must be a hex-encoded SHA-256 checksum")
+	}
+	return nil
+}
+
+// RecordFetchFailure records a failed attempt to fetch the module content in
+// the metrics of the module loader. Module loaders call it when they fail to
+// retrieve the content before it can be loaded.
+func (c *ModuleComponent) RecordFetchFailure() {
+	c.metrics.observeFailure(failureReasonFetch)
+}
+
 // RunFlowController runs the flow controller that all module components start.
+// Loads of module content which don't return within a minute are reported
+// while the flow controller is running.
 func (c *ModuleComponent) RunFlowController(ctx context.Context) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.watchLoads(watchCtx)
+
 	err := c.mod.Run(ctx)
 	if err != nil {
 		level.Error(c.opts.Logger).Log("msg", "error running module", "id", c.opts.ID, "err", err)
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to run module: %s", err),
+			UpdateTime: time.Now(),
+		})
 	}
 }
 
 // CurrentHealth contains the implementation details for CurrentHealth in a module component.
+// The health of the components running within the module is rolled up into it,
+// so an unhealthy component makes the module component unhealthy.
 func (c *ModuleComponent) CurrentHealth() component.Health {
 	c.mut.RLock()
-	defer c.mut.RUnlock()
-	return c.health
+	health := c.health
+	c.mut.RUnlock()
+
+	if stuck, ok := c.stuckHealth(); ok {
+		health = component.LeastHealthy(health, stuck)
+	}
+
+	if hm, ok := c.mod.(component.HealthModule); ok {
+		return component.LeastHealthy(health, hm.CurrentHealth())
+	}
+	return health
 }
 
 // SetHealth contains the implementation details for setHealth in a module component.
@@ -92,30 +284,188 @@ func (c *ModuleComponent) setHealth(h component.Health) {
 	c.health = h
 }
 
-func (c *ModuleComponent) setLatestContent(content string) {
-	c.mut.Lock()
-	defer c.mut.Unlock()
-	c.latestContent = content
+// isUpToDate reports whether the module was last loaded with content
+// matching hash, args and req. A nil args matches empty arguments.
+func (c *ModuleComponent) isUpToDate(hash [sha256.Size]byte, args map[string]any, req Requirements) bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	argsEqual := len(args) == 0 && len(c.latestArgs) == 0 || reflect.DeepEqual(args, c.latestArgs)
+	return c.loaded && hash == c.latestHash && req == c.latestReq && argsEqual
 }
 
-func (c *ModuleComponent) getLatestContent() string {
+// contentChanged reports whether hash differs from the hash of the last
+// loaded content.
+func (c *ModuleComponent) contentChanged(hash [sha256.Size]byte) bool {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
-	return c.latestContent
+	return !c.loaded || hash != c.latestHash
 }
 
-func (c *ModuleComponent) setLatestArgs(args map[string]any) {
+func (c *ModuleComponent) setLatest(hash [sha256.Size]byte, args map[string]any, req Requirements, meta Meta) {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
+	c.latestHash = hash
+	c.latestReq = req
+	c.latestMeta = meta
 	c.latestArgs = make(map[string]any)
 	for key, value := range args {
 		c.latestArgs[key] = value
 	}
+	c.loaded = true
+}
+
+func (c *ModuleComponent) setExportNames(exports map[string]any) {
+	names := make([]string, 0, len(exports))
+	for name := range exports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.exportNames = names
+}
+
+// DebugInfo describes the module content which is currently loaded.
+type DebugInfo struct {
+	ContentSHA256 string               `river:"content_sha256,attr,optional"`
+	Name          string               `river:"module_name,attr,optional"`
+	Version       string               `river:"module_version,attr,optional"`
+	Exports       []string             `river:"exports,attr,optional"`
+	Components    []ComponentDebugInfo `river:"component,block,optional"`
+}
+
+// ComponentDebugInfo describes a component running within the module.
+type ComponentDebugInfo struct {
+	ID             string               `river:"id,attr"`
+	Health         component.HealthType `river:"health,attr"`
+	Message        string               `river:"message,attr,optional"`
+	LastEvaluation time.Time            `river:"last_evaluation,attr,optional"`
+}
+
+// DebugInfo returns information about the module content which is currently
+// loaded, for module loaders to include in their debug info.
+func (c *ModuleComponent) DebugInfo() DebugInfo {
+	c.mut.RLock()
+	if !c.loaded {
+		c.mut.RUnlock()
+		return DebugInfo{}
+	}
+	info := DebugInfo{
+		ContentSHA256: hex.EncodeToString(c.latestHash[:]),
+		Name:          c.latestMeta.Name,
+		Version:       c.latestMeta.Version,
+		Exports:       c.exportNames,
+	}
+	c.mut.RUnlock()
+
+	// The components are retrieved without holding c.mut, since the module
+	// sets the exports of c while it's being loaded.
+	if cm, ok := c.mod.(component.ComponentsModule); ok {
+		for _, state := range cm.Components() {
+			info.Components = append(info.Components, ComponentDebugInfo{
+				ID:             state.ID,
+				Health:         state.Health.Health,
+				Message:        state.Health.Message,
+				LastEvaluation: state.LastEvaluation,
+			})
+		}
+	}
+	return info
+}
+
+// Info describes the module which is currently loaded, so that tooling can
+// show what the module does and which arguments it accepts.
+type Info struct {
+	Name        string         `json:"name,omitempty"`
+	Version     string         `json:"version,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Arguments   []ArgumentMeta `json:"arguments,omitempty"`
+	Exports     []string       `json:"exports,omitempty"`
+}
+
+// InfoComponent is implemented by module loaders which describe the module
+// they load.
+type InfoComponent interface {
+	component.Component
+
+	// ModuleInfo returns information about the currently loaded module.
+	ModuleInfo() Info
 }
 
-func (c *ModuleComponent) getLatestArgs() map[string]any {
+// Info returns information about the module which is currently loaded, for
+// module loaders to implement InfoComponent.
+func (c *ModuleComponent) Info() Info {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
-	return c.latestArgs
+
+	if !c.loaded {
+		return Info{}
+	}
+	return Info{
+		Name:        c.latestMeta.Name,
+		Version:     c.latestMeta.Version,
+		Description: c.latestMeta.Description,
+		Arguments:   c.latestMeta.Arguments,
+		Exports:     c.exportNames,
+	}
+}
+
+// DefaultDirectoryGlob is the default pattern of the files which are loaded
+// when a module is read from a directory.
+const DefaultDirectoryGlob = "*.river"
+
+// ReadDirectory returns the combined content of all files at the top level of
+// dir whose names match glob. Subdirectories are not searched. ReadDirectory
+// returns an error if no files match.
+func ReadDirectory(dir, glob string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	files := make(map[string][]byte)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ok, err := filepath.Match(glob, entry.Name()); err != nil {
+			return "", err
+		} else if !ok {
+			continue
+		}
+
+		bb, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		files[entry.Name()] = bb
+	}
+
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files in %s match %q", dir, glob)
+	}
+	return CombineFiles(files), nil
+}
+
+// CombineFiles combines the content of files, keyed by their names, into a
+// single module source. Files are combined in lexical order of their names.
+func CombineFiles(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		// Files are separated by a comment naming them, which makes it easier
+		// to find the origin of errors in the combined content.
+		fmt.Fprintf(&sb, "// %s\n", name)
+		sb.Write(files[name])
+		sb.WriteString("\n")
+	}
+	return sb.String()
 }
@@ -0,0 +1,282 @@
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/river/encoding/riverjson"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestReadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.river"), []byte(`export "b" { value = 2 }`), 0664))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.river"), []byte(`export "a" { value = 1 }`), 0664))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`# Modules`), 0664))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.river"), 0775))
+
+	content, err := ReadDirectory(dir, DefaultDirectoryGlob)
+	require.NoError(t, err)
+	require.Equal(t, "// a.river\nexport \"a\" { value = 1 }\n// b.river\nexport \"b\" { value = 2 }\n", content)
+
+	_, err = ReadDirectory(dir, "*.yaml")
+	require.ErrorContains(t, err, `match "*.yaml"`)
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	const (
+		content = `export "a" { value = 1 }`
+		sum     = "44e87753c6912c77d5a79d79629a1f3f7afe81abaadf73ce0f48334d922fc619"
+	)
+
+	require.NoError(t, VerifyChecksum(content, ""))
+	require.NoError(t, VerifyChecksum(content, sum))
+	require.ErrorContains(t, VerifyChecksum(content+"\n", sum), "sha256 checksum mismatch")
+
+	require.NoError(t, ValidateChecksum(sum))
+	require.Error(t, ValidateChecksum("abc"))
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := newMetrics(reg, "module.string.test")
+	require.NoError(t, err)
+
+	m.observeSuccess("abc", true)
+	m.observeSuccess("abc", false)
+	m.observeFailure(failureReasonFetch)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_module_content_bytes Size in bytes of the most recently loaded module content.
+		# TYPE agent_module_content_bytes gauge
+		agent_module_content_bytes 3
+		# HELP agent_module_content_changes_total Total number of times the loaded module content changed.
+		# TYPE agent_module_content_changes_total counter
+		agent_module_content_changes_total 1
+		# HELP agent_module_load_attempts_total Total number of attempts to fetch and load the module.
+		# TYPE agent_module_load_attempts_total counter
+		agent_module_load_attempts_total 3
+		# HELP agent_module_load_failures_total Total number of failed attempts to fetch and load the module, by reason.
+		# TYPE agent_module_load_failures_total counter
+		agent_module_load_failures_total{reason="checksum"} 0
+		agent_module_load_failures_total{reason="fetch"} 1
+		agent_module_load_failures_total{reason="load"} 0
+		agent_module_load_failures_total{reason="version"} 0
+	`), "agent_module_content_bytes", "agent_module_content_changes_total", "agent_module_load_attempts_total", "agent_module_load_failures_total"))
+}
+
+func TestCountComponentGoroutines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Goroutines started by a component inherit its labels.
+	labels := pprof.Labels("component_id", "module.string.test/testcomponents.passthrough.a")
+	pprof.Do(ctx, labels, func(ctx context.Context) {
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-ctx.Done()
+			}()
+		}
+	})
+
+	counts := countComponentGoroutines()
+	require.Equal(t, 3, counts["module.string.test/testcomponents.passthrough.a"])
+}
+
+func TestParseMeta(t *testing.T) {
+	meta, err := ParseMeta(`
+		module_meta {
+			name              = "logs"
+			version           = "1.2.3"
+			min_agent_version = "0.1.0"
+		}
+
+		export "a" { value = 1 }
+	`)
+	require.NoError(t, err)
+	require.Equal(t, Meta{Name: "logs", Version: "1.2.3", MinAgentVersion: "0.1.0"}, meta)
+
+	meta, err = ParseMeta(`export "a" { value = 1 }`)
+	require.NoError(t, err)
+	require.Equal(t, Meta{}, meta)
+
+	_, err = ParseMeta(`module_meta { unknown = true }`)
+	require.ErrorContains(t, err, "decoding module_meta block")
+}
+
+func TestParseMeta_Arguments(t *testing.T) {
+	meta, err := ParseMeta(`
+		argument "targets" {
+			comment = "Targets to scrape."
+			type    = "list"
+		}
+
+		argument "interval" {
+			default = "1m"
+		}
+
+		argument "job" {
+			optional = true
+			default  = argument.interval.value
+		}
+
+		module_meta {
+			name        = "scrape"
+			description = "Scrapes the given targets."
+		}
+	`)
+	require.NoError(t, err)
+	require.Equal(t, Meta{
+		Name:        "scrape",
+		Description: "Scrapes the given targets.",
+		Arguments: []ArgumentMeta{
+			{Name: "targets", Comment: "Targets to scrape.", Type: "list"},
+			{Name: "interval", Optional: true},
+			{Name: "job", Optional: true},
+		},
+	}, meta)
+}
+
+func TestMeta_CheckVersion(t *testing.T) {
+	meta := Meta{Name: "logs", Version: "1.2.3"}
+
+	require.NoError(t, meta.CheckVersion(""))
+	require.NoError(t, meta.CheckVersion(">= 1.2"))
+	require.NoError(t, meta.CheckVersion("~1.2"))
+	require.ErrorContains(t, meta.CheckVersion(">= 1.3"), `module "logs" version 1.2.3 doesn't satisfy the version constraint ">= 1.3"`)
+	require.ErrorContains(t, Meta{}.CheckVersion(">= 1"), "doesn't declare a version")
+
+	require.NoError(t, ValidateVersionConstraint(">= 1.2, < 2"))
+	require.Error(t, ValidateVersionConstraint("not a constraint"))
+}
+
+func TestLoadVerifiedFlowSource_Unchanged(t *testing.T) {
+	var mod fakeModule
+	c, err := NewModuleComponent(component.Options{
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: fakeModuleController{mod: &mod},
+		OnStateChange:    func(component.Exports) {},
+	})
+	require.NoError(t, err)
+
+	const content = `module_meta { version = "1.0.0" }`
+	args := map[string]any{"a": 1}
+
+	require.NoError(t, c.LoadFlowSource(args, content))
+	require.NoError(t, c.LoadFlowSource(map[string]any{"a": 1}, content))
+	require.Equal(t, 1, mod.loads, "unchanged content must not be loaded again")
+
+	// Changed requirements must be checked against the loaded content again.
+	err = c.LoadVerifiedFlowSource(args, content, Requirements{Version: ">= 2"})
+	require.ErrorContains(t, err, "doesn't satisfy")
+	require.Equal(t, 1, mod.loads)
+
+	require.NoError(t, c.LoadFlowSource(map[string]any{"a": 2}, content))
+	require.NoError(t, c.LoadFlowSource(map[string]any{"a": 2}, content+"\n"))
+	require.Equal(t, 3, mod.loads)
+
+	// Module loaders without an arguments block pass nil arguments.
+	require.NoError(t, c.LoadFlowSource(nil, content))
+	require.NoError(t, c.LoadFlowSource(nil, content))
+	require.Equal(t, 4, mod.loads, "unchanged content without arguments must not be loaded again")
+}
+
+type fakeModuleController struct{ mod *fakeModule }
+
+func (c fakeModuleController) NewModule(_ string, export component.ExportFunc) (component.Module, error) {
+	c.mod.export = export
+	return c.mod, nil
+}
+
+type fakeModule struct {
+	loads  int
+	export component.ExportFunc
+}
+
+func (m *fakeModule) LoadConfig([]byte, map[string]any) error {
+	m.loads++
+	return nil
+}
+
+func (m *fakeModule) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestModuleComponent_DebugInfo(t *testing.T) {
+	var mod fakeModule
+	c, err := NewModuleComponent(component.Options{
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: fakeModuleController{mod: &mod},
+		OnStateChange:    func(component.Exports) {},
+	})
+	require.NoError(t, err)
+	require.Equal(t, DebugInfo{}, c.DebugInfo())
+
+	const content = `module_meta {
+	name    = "shared"
+	version = "1.0.0"
+}`
+	require.NoError(t, c.LoadFlowSource(nil, content))
+	mod.export(map[string]any{"receiver": nil, "output": 1})
+
+	info := c.DebugInfo()
+	require.Equal(t, DebugInfo{
+		ContentSHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+		Name:          "shared",
+		Version:       "1.0.0",
+		Exports:       []string{"output", "receiver"},
+	}, info)
+
+	bb, err := riverjson.MarshalBody(info)
+	require.NoError(t, err)
+	require.Contains(t, string(bb), `"module_name"`)
+}
+
+func TestLoadVerifiedFlowSourceContext_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var mod fakeModule
+	c, err := NewModuleComponent(component.Options{
+		ID:               "module.git.example",
+		Registerer:       prometheus.NewRegistry(),
+		Tracer:           provider,
+		ModuleController: fakeModuleController{mod: &mod},
+		OnStateChange:    func(component.Exports) {},
+	})
+	require.NoError(t, err)
+
+	ctx, span := c.StartFetch(context.Background())
+	require.NoError(t, c.LoadVerifiedFlowSourceContext(ctx, nil, `module_meta { version = "1.0.0" }`, Requirements{}))
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	names := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, s := range spans {
+		names[s.Name()] = s
+		require.Equal(t, span.SpanContext().TraceID(), s.SpanContext().TraceID())
+	}
+	require.Equal(t, span.SpanContext().SpanID(), names["LoadModule"].Parent().SpanID())
+	require.Equal(t, names["LoadModule"].SpanContext().SpanID(), names["VerifyModule"].Parent().SpanID())
+}
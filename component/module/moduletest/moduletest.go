@@ -0,0 +1,47 @@
+// Package moduletest provides utilities for testing module loader components.
+package moduletest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/agent/component"
+)
+
+// FakeModuleController is a component.ModuleController which creates modules
+// that record the configs they load.
+type FakeModuleController struct {
+	mut     sync.Mutex
+	configs []string
+}
+
+var _ component.ModuleController = (*FakeModuleController)(nil)
+
+// NewModule implements component.ModuleController.
+func (c *FakeModuleController) NewModule(string, component.ExportFunc) (component.Module, error) {
+	return fakeModule{ctrl: c}, nil
+}
+
+// Loaded returns the configs loaded by modules of c, in the order they were
+// loaded.
+func (c *FakeModuleController) Loaded() []string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return append([]string{}, c.configs...)
+}
+
+type fakeModule struct {
+	ctrl *FakeModuleController
+}
+
+func (m fakeModule) LoadConfig(config []byte, _ map[string]any) error {
+	m.ctrl.mut.Lock()
+	defer m.ctrl.mut.Unlock()
+	m.ctrl.configs = append(m.ctrl.configs, string(config))
+	return nil
+}
+
+func (fakeModule) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,325 @@
+// Package oci implements the module.oci component.
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+	"github.com/grafana/river/rivertypes"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "module.oci",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the module.oci component.
+type Arguments struct {
+	Reference     string        `river:"reference,attr"`
+	Glob          string        `river:"glob,attr,optional"`
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+	Insecure      bool          `river:"insecure,attr,optional"`
+
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
+	Username     string            `river:"username,attr,optional"`
+	Password     rivertypes.Secret `river:"password,attr,optional"`
+	DockerConfig string            `river:"docker_config,attr,optional"`
+
+	Retry     module.RetryArguments `river:"retry,block,optional"`
+	Arguments map[string]any        `river:"arguments,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Glob:          module.DefaultDirectoryGlob,
+	PollFrequency: time.Minute,
+	Retry:         module.DefaultRetryArguments,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if _, err := parseReference(args.Reference); err != nil {
+		return err
+	}
+	if _, err := path.Match(args.Glob, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", args.Glob, err)
+	}
+	if args.PollFrequency <= 0 {
+		return fmt.Errorf("poll_frequency must be greater than 0")
+	}
+	if args.DockerConfig != "" && (args.Username != "" || args.Password != "") {
+		return fmt.Errorf("at most one of docker_config and username/password may be set")
+	}
+	return module.ValidateVersionConstraint(args.Version)
+}
+
+// credentials returns the credentials used to authenticate against the
+// registry of ref.
+func (args *Arguments) credentials(ref reference) (credentials, error) {
+	if args.DockerConfig != "" {
+		return dockerConfigCredentials(args.DockerConfig, ref.Registry)
+	}
+	return credentials{Username: args.Username, Password: string(args.Password)}, nil
+}
+
+// Component implements the module.oci component.
+type Component struct {
+	opts component.Options
+	log  log.Logger
+	mod  *module.ModuleComponent
+
+	mut    sync.RWMutex
+	args   Arguments
+	client *client
+	digest string
+
+	argsChanged chan struct{}
+	refresh     chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.DebugComponent       = (*Component)(nil)
+	_ module.InfoComponent           = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
+)
+
+// New creates a new module.oci component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := module.NewModuleComponent(o)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{
+		opts: o,
+		log:  o.Logger,
+
+		mod: m,
+
+		argsChanged: make(chan struct{}, 1),
+		refresh:     make(chan struct{}, 1),
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go c.mod.RunFlowController(ctx)
+
+	c.mut.RLock()
+	timer := time.NewTimer(c.args.PollFrequency)
+	backoff := module.NewBackoff(c.args.Retry)
+	c.mut.RUnlock()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-c.argsChanged:
+			c.mut.RLock()
+			backoff.SetArguments(c.args.Retry)
+			module.ResetTimer(timer, c.args.PollFrequency)
+			c.mut.RUnlock()
+
+		case <-timer.C:
+			err := c.tickPollArtifact(ctx)
+			c.mut.RLock()
+			timer.Reset(backoff.Next(err, c.args.PollFrequency))
+			c.mut.RUnlock()
+
+		case <-c.refresh:
+			level.Info(c.log).Log("msg", "pulling module artifact on request")
+			err := c.tickPollArtifact(ctx)
+			c.mut.RLock()
+			module.ResetTimer(timer, backoff.Next(err, c.args.PollFrequency))
+			c.mut.RUnlock()
+		}
+	}
+}
+
+// Refresh implements component.RefreshableComponent and pulls the module
+// artifact as soon as possible.
+func (c *Component) Refresh() {
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Component) tickPollArtifact(ctx context.Context) error {
+	c.mut.Lock()
+	err := c.pollArtifact(ctx, c.client, c.args)
+	c.mut.Unlock()
+
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to pull module artifact", "err", err)
+	}
+	c.updateHealth(err)
+	return err
+}
+
+func (c *Component) updateHealth(err error) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	if err != nil {
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		c.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) (err error) {
+	defer func() {
+		c.updateHealth(err)
+	}()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+
+	ref, err := parseReference(newArgs.Reference)
+	if err != nil {
+		return err
+	}
+	creds, err := newArgs.credentials(ref)
+	if err != nil {
+		return err
+	}
+	client := newClient(http.DefaultClient, ref, creds, newArgs.Insecure)
+
+	// The artifact must be pulled again, since the new arguments may point
+	// to a different artifact or select different files.
+	c.digest = ""
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := c.pollArtifact(ctx, client, newArgs); err != nil {
+		return err
+	}
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case c.argsChanged <- struct{}{}:
+	default:
+	}
+
+	c.args = newArgs
+	c.client = client
+	return nil
+}
+
+// pollArtifact resolves the digest of the artifact and updates the
+// controller. The artifact is only pulled when its digest changed since the
+// last successful pull. pollArtifact must be called with c.mut held.
+func (c *Component) pollArtifact(ctx context.Context, client *client, args Arguments) (err error) {
+	ctx, span := c.mod.StartFetch(ctx)
+	span.SetAttributes(attribute.String("reference", client.ref.String()))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	digest, err := client.Resolve(ctx)
+	if err != nil {
+		c.mod.RecordFetchFailure()
+		return fmt.Errorf("resolving %s: %w", client.ref, err)
+	}
+	if digest == c.digest {
+		return nil
+	}
+
+	files, err := client.Pull(ctx, digest, args.Glob)
+	if err != nil {
+		c.mod.RecordFetchFailure()
+		return fmt.Errorf("pulling %s: %w", client.ref, err)
+	}
+	content := module.CombineFiles(files)
+	span.SetAttributes(attribute.String("digest", digest))
+	if err := c.mod.LoadVerifiedFlowSourceContext(ctx, args.Arguments, content, module.Requirements{Version: args.Version}); err != nil {
+		return err
+	}
+
+	if c.digest != "" {
+		level.Info(c.log).Log("msg", "loaded new module artifact", "reference", client.ref, "digest", digest)
+	}
+	c.digest = digest
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+
+	return component.LeastHealthy(c.health, c.mod.CurrentHealth())
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	type DebugInfo struct {
+		Digest string           `river:"digest,attr"`
+		Module module.DebugInfo `river:",squash"`
+	}
+
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	return DebugInfo{Digest: c.digest, Module: c.mod.DebugInfo()}
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
+}
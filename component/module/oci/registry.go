@@ -0,0 +1,531 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Media types of the manifests which can be pulled.
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// annotationTitle is the layer annotation which holds the name of the file
+// stored in the layer. It's set by tools such as ORAS when pushing files.
+const annotationTitle = "org.opencontainers.image.title"
+
+// annotationUnpack marks layers which ORAS created from a directory.
+const annotationUnpack = "io.deis.oras.content.unpack"
+
+// maxBlobSize limits the size of manifests and layers which are downloaded.
+const maxBlobSize = 32 << 20
+
+// reference is a parsed reference to an artifact in a registry.
+type reference struct {
+	Registry   string // Host and optional port of the registry.
+	Repository string // Repository in the registry.
+	Reference  string // Tag or digest of the artifact.
+}
+
+var repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+
+// parseReference parses a reference in the form
+// REGISTRY/REPOSITORY[:TAG|@DIGEST]. A missing tag defaults to latest.
+func parseReference(s string) (reference, error) {
+	registry, rest, ok := strings.Cut(s, "/")
+	if !ok || registry == "" || rest == "" {
+		return reference{}, fmt.Errorf("invalid reference %q: expected REGISTRY/REPOSITORY[:TAG|@DIGEST]", s)
+	}
+
+	ref := reference{Registry: registry, Reference: "latest"}
+	if repo, digest, ok := strings.Cut(rest, "@"); ok {
+		if !strings.HasPrefix(digest, "sha256:") {
+			return reference{}, fmt.Errorf("invalid reference %q: only sha256 digests are supported", s)
+		}
+		ref.Repository, ref.Reference = repo, digest
+	} else if i := strings.LastIndex(rest, ":"); i >= 0 {
+		ref.Repository, ref.Reference = rest[:i], rest[i+1:]
+	} else {
+		ref.Repository = rest
+	}
+
+	if !repositoryRegexp.MatchString(ref.Repository) {
+		return reference{}, fmt.Errorf("invalid reference %q: invalid repository name %q", s, ref.Repository)
+	}
+	if ref.Reference == "" {
+		return reference{}, fmt.Errorf("invalid reference %q: empty tag", s)
+	}
+	return ref, nil
+}
+
+func (r reference) String() string {
+	if strings.HasPrefix(r.Reference, "sha256:") {
+		return r.Registry + "/" + r.Repository + "@" + r.Reference
+	}
+	return r.Registry + "/" + r.Repository + ":" + r.Reference
+}
+
+// credentials authenticate requests against a registry.
+type credentials struct {
+	Username string
+	Password string
+}
+
+func (c credentials) empty() bool { return c.Username == "" && c.Password == "" }
+
+// dockerConfigCredentials returns the credentials for registry stored in the
+// Docker config file at path. Empty credentials are returned if the file
+// doesn't have credentials for registry.
+func dockerConfigCredentials(path, registry string) (credentials, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return credentials{}, err
+	}
+
+	var cfg struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(bb, &cfg); err != nil {
+		return credentials{}, fmt.Errorf("parsing docker config %s: %w", path, err)
+	}
+
+	for key, auth := range cfg.Auths {
+		if dockerConfigHost(key) != registry {
+			continue
+		}
+		if auth.Auth == "" {
+			return credentials{Username: auth.Username, Password: auth.Password}, nil
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return credentials{}, fmt.Errorf("decoding credentials for %s in docker config %s: %w", key, path, err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return credentials{}, fmt.Errorf("invalid credentials for %s in docker config %s", key, path)
+		}
+		return credentials{Username: username, Password: password}, nil
+	}
+	return credentials{}, nil
+}
+
+// dockerConfigHost returns the registry host of a key in the auths section
+// of a Docker config file, which may be a URL.
+func dockerConfigHost(key string) string {
+	key = strings.TrimPrefix(key, "https://")
+	key = strings.TrimPrefix(key, "http://")
+	key, _, _ = strings.Cut(key, "/")
+	return key
+}
+
+// client pulls artifacts from a registry using the OCI distribution API.
+type client struct {
+	httpClient *http.Client
+	scheme     string
+	ref        reference
+	creds      credentials
+
+	mut   sync.Mutex
+	token string
+}
+
+func newClient(httpClient *http.Client, ref reference, creds credentials, insecure bool) *client {
+	scheme := "https"
+	if insecure {
+		scheme = "http"
+	}
+	return &client{
+		httpClient: httpClient,
+		scheme:     scheme,
+		ref:        ref,
+		creds:      creds,
+	}
+}
+
+// manifest is the subset of an image manifest used to pull files.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Resolve returns the digest of the manifest which the reference currently
+// points to.
+func (c *client) Resolve(ctx context.Context) (string, error) {
+	resp, err := c.do(ctx, http.MethodHead, "manifests/"+c.ref.Reference, manifestAccept)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	// Registries aren't required to return the digest of a manifest, so fall
+	// back to downloading and hashing it.
+	_, digest, err := c.fetchManifest(ctx, c.ref.Reference)
+	return digest, err
+}
+
+// Pull downloads the manifest with the given digest and returns the files
+// stored in its layers whose names match glob.
+func (c *client) Pull(ctx context.Context, digest, glob string) (map[string][]byte, error) {
+	m, _, err := c.fetchManifest(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, layer := range m.Layers {
+		bb, err := c.fetchBlob(ctx, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if err := extractLayer(layer, bb, glob, files); err != nil {
+			return nil, fmt.Errorf("extracting layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files in %s match %q", c.ref, glob)
+	}
+	return files, nil
+}
+
+var manifestAccept = strings.Join([]string{
+	mediaTypeOCIManifest,
+	mediaTypeDockerManifest,
+	mediaTypeOCIIndex,
+	mediaTypeDockerList,
+}, ", ")
+
+func (c *client) fetchManifest(ctx context.Context, ref string) (manifest, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, "manifests/"+ref, manifestAccept)
+	if err != nil {
+		return manifest{}, "", err
+	}
+	defer resp.Body.Close()
+
+	bb, err := readLimited(resp.Body)
+	if err != nil {
+		return manifest{}, "", fmt.Errorf("reading manifest: %w", err)
+	}
+	digest := digestOf(bb)
+	if strings.HasPrefix(ref, "sha256:") && ref != digest {
+		return manifest{}, "", fmt.Errorf("manifest digest %s doesn't match the expected digest %s", digest, ref)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(bb, &m); err != nil {
+		return manifest{}, "", fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	mediaType := m.MediaType
+	if mediaType == "" {
+		mediaType = resp.Header.Get("Content-Type")
+	}
+	switch mediaType {
+	case mediaTypeOCIIndex, mediaTypeDockerList:
+		return manifest{}, "", fmt.Errorf("%s is an image index, which isn't supported", c.ref)
+	}
+	return m, digest, nil
+}
+
+func (c *client) fetchBlob(ctx context.Context, digest string) ([]byte, error) {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported digest %q", digest)
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, "blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bb, err := readLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", digest, err)
+	}
+	if actual := digestOf(bb); actual != digest {
+		return nil, fmt.Errorf("blob digest %s doesn't match the expected digest %s", actual, digest)
+	}
+	return bb, nil
+}
+
+// do sends a request to the registry API of the repository, authenticating
+// when the registry asks for it. An error is returned for responses which
+// aren't successful.
+func (c *client) do(ctx context.Context, method, endpoint, accept string) (*http.Response, error) {
+	u := fmt.Sprintf("%s://%s/v2/%s/%s", c.scheme, c.ref.Registry, c.ref.Repository, endpoint)
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		c.mut.Lock()
+		token := c.token
+		c.mut.Unlock()
+
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case !c.creds.empty():
+			req.SetBasicAuth(c.creds.Username, c.creds.Password)
+		}
+		return c.httpClient.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = send(); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: unexpected status code %s", method, u, resp.Status)
+	}
+	return resp, nil
+}
+
+// authenticate handles an authentication challenge returned by the registry.
+// Bearer challenges are answered by requesting a token from the
+// authorization service named by the challenge.
+func (c *client) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.creds.empty() {
+			return fmt.Errorf("registry %s requires credentials", c.ref.Registry)
+		}
+		// Credentials are already sent with every request when there's no
+		// token, so they must have been rejected.
+		return fmt.Errorf("registry %s rejected the credentials", c.ref.Registry)
+
+	case "bearer":
+		token, err := c.fetchToken(ctx, params)
+		if err != nil {
+			return err
+		}
+		c.mut.Lock()
+		c.token = token
+		c.mut.Unlock()
+		return nil
+
+	default:
+		return fmt.Errorf("registry %s requires unsupported authentication %q", c.ref.Registry, challenge)
+	}
+}
+
+func (c *client) fetchToken(ctx context.Context, params map[string]string) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry %s returned a bearer challenge without a realm", c.ref.Registry)
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm %q: %w", realm, err)
+	}
+
+	q := u.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.Repository + ":pull"
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if !c.creds.empty() {
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("requesting token from %s: unexpected status code %s", u.Host, resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("parsing token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response from %s didn't include a token", u.Host)
+}
+
+// parseChallenge parses the value of a WWW-Authenticate header, such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params = make(map[string]string)
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+	return scheme, params
+}
+
+// extractLayer adds the files stored in a layer which match glob to files.
+// Layers with a title annotation are single files, unless they're marked as
+// directories which ORAS stores as compressed tar archives. Untitled layers
+// are unpacked if they're tar archives and ignored otherwise.
+func extractLayer(layer descriptor, bb []byte, glob string, files map[string][]byte) error {
+	name := layer.Annotations[annotationTitle]
+	if name != "" && layer.Annotations[annotationUnpack] != "true" {
+		return addFile(files, name, bb, glob)
+	}
+
+	mediaType := layer.MediaType
+	switch {
+	case strings.HasSuffix(mediaType, "+gzip"), strings.HasSuffix(mediaType, ".gzip"):
+		gr, err := gzip.NewReader(bytes.NewReader(bb))
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		return extractTar(gr, glob, files)
+	case strings.HasSuffix(mediaType, ".tar"):
+		return extractTar(bytes.NewReader(bb), glob, files)
+	default:
+		return nil
+	}
+}
+
+func extractTar(r io.Reader, glob string, files map[string][]byte) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		bb, err := readLimited(tr)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", hdr.Name, err)
+		}
+		if err := addFile(files, hdr.Name, bb, glob); err != nil {
+			return err
+		}
+	}
+}
+
+// addFile adds a file to files if its name matches glob. Files are keyed by
+// their base name, so files in subdirectories are loaded as well.
+func addFile(files map[string][]byte, name string, bb []byte, glob string) error {
+	base := path.Base(name)
+	match, err := path.Match(glob, base)
+	if err != nil {
+		return err
+	} else if !match {
+		return nil
+	}
+	if _, exists := files[base]; exists {
+		return fmt.Errorf("artifact contains more than one file named %s", base)
+	}
+	files[base] = bb
+	return nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	bb, err := io.ReadAll(io.LimitReader(r, maxBlobSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bb) > maxBlobSize {
+		return nil, fmt.Errorf("content exceeds the maximum size of %d bytes", maxBlobSize)
+	}
+	return bb, nil
+}
+
+func digestOf(bb []byte) string {
+	sum := sha256.Sum256(bb)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
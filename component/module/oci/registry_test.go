@@ -0,0 +1,241 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect reference
+		err    string
+	}{
+		{
+			input:  "registry.example.com/configs/agent:v1",
+			expect: reference{Registry: "registry.example.com", Repository: "configs/agent", Reference: "v1"},
+		},
+		{
+			input:  "localhost:5000/agent",
+			expect: reference{Registry: "localhost:5000", Repository: "agent", Reference: "latest"},
+		},
+		{
+			input:  "localhost:5000/agent@sha256:abc",
+			expect: reference{Registry: "localhost:5000", Repository: "agent", Reference: "sha256:abc"},
+		},
+		{input: "agent", err: "expected REGISTRY/REPOSITORY"},
+		{input: "localhost/Agent:v1", err: "invalid repository name"},
+		{input: "localhost/agent:", err: "empty tag"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			ref, err := parseReference(tc.input)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, ref)
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:agent:pull"`)
+	require.Equal(t, "Bearer", scheme)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:agent:pull",
+	}, params)
+}
+
+func TestDockerConfigCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(fmt.Sprintf(`{"auths": {
+		"https://registry.example.com/v1/": {"auth": %q},
+		"other.example.com": {"username": "bob", "password": "secret"}
+	}}`, base64.StdEncoding.EncodeToString([]byte("alice:hunter2")))), 0644)
+	require.NoError(t, err)
+
+	creds, err := dockerConfigCredentials(path, "registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, credentials{Username: "alice", Password: "hunter2"}, creds)
+
+	creds, err = dockerConfigCredentials(path, "other.example.com")
+	require.NoError(t, err)
+	require.Equal(t, credentials{Username: "bob", Password: "secret"}, creds)
+
+	creds, err = dockerConfigCredentials(path, "unknown.example.com")
+	require.NoError(t, err)
+	require.True(t, creds.empty())
+}
+
+func TestClient_Pull(t *testing.T) {
+	reg := newTestRegistry(t, credentials{Username: "alice", Password: "hunter2"})
+
+	// An artifact with a single file and a directory pushed as an archive,
+	// the way ORAS stores them.
+	single := reg.addBlob([]byte("// single\n"))
+	archive := reg.addBlob(tarGzip(t, map[string]string{
+		"dir/a.river":   "// a\n",
+		"dir/README.md": "not a module",
+	}))
+	reg.setManifest("v1", manifest{
+		MediaType: mediaTypeOCIManifest,
+		Layers: []descriptor{
+			{MediaType: "application/vnd.oci.image.layer.v1.tar", Digest: single, Annotations: map[string]string{annotationTitle: "b.river"}},
+			{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: archive, Annotations: map[string]string{annotationTitle: "dir", annotationUnpack: "true"}},
+		},
+	})
+
+	ref := reference{Registry: reg.host(), Repository: "configs/agent", Reference: "v1"}
+
+	t.Run("authenticated", func(t *testing.T) {
+		c := newClient(reg.srv.Client(), ref, credentials{Username: "alice", Password: "hunter2"}, true)
+
+		digest, err := c.Resolve(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, reg.manifests["v1"], digest)
+
+		files, err := c.Pull(context.Background(), digest, "*.river")
+		require.NoError(t, err)
+		require.Equal(t, map[string][]byte{
+			"a.river": []byte("// a\n"),
+			"b.river": []byte("// single\n"),
+		}, files)
+	})
+
+	t.Run("wrong credentials", func(t *testing.T) {
+		c := newClient(reg.srv.Client(), ref, credentials{Username: "alice", Password: "wrong"}, true)
+
+		_, err := c.Resolve(context.Background())
+		require.ErrorContains(t, err, "unexpected status code 401")
+	})
+
+	t.Run("no matching files", func(t *testing.T) {
+		c := newClient(reg.srv.Client(), ref, credentials{Username: "alice", Password: "hunter2"}, true)
+
+		_, err := c.Pull(context.Background(), reg.manifests["v1"], "*.txt")
+		require.ErrorContains(t, err, `no files in `+ref.String()+` match "*.txt"`)
+	})
+}
+
+// testRegistry is a minimal registry which serves manifests and blobs to
+// clients authenticated with a bearer token.
+type testRegistry struct {
+	srv   *httptest.Server
+	creds credentials
+
+	manifests map[string]string // Tags to digests of manifests.
+	blobs     map[string][]byte // Digests to content of manifests and blobs.
+}
+
+const testToken = "test-token"
+
+func newTestRegistry(t *testing.T, creds credentials) *testRegistry {
+	reg := &testRegistry{
+		creds:     creds,
+		manifests: make(map[string]string),
+		blobs:     make(map[string][]byte),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != creds.Username || password != creds.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("scope") != "repository:configs/agent:pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": testToken})
+	})
+	mux.HandleFunc("/v2/configs/agent/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, reg.srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		kind, ref, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/configs/agent/"), "/")
+		switch kind {
+		case "manifests":
+			if digest, ok := reg.manifests[ref]; ok {
+				ref = digest
+			}
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			w.Header().Set("Docker-Content-Digest", ref)
+		case "blobs":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		bb, ok := reg.blobs[ref]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodHead {
+			_, _ = w.Write(bb)
+		}
+	})
+
+	reg.srv = httptest.NewServer(mux)
+	t.Cleanup(reg.srv.Close)
+	return reg
+}
+
+func (reg *testRegistry) host() string {
+	return strings.TrimPrefix(reg.srv.URL, "http://")
+}
+
+func (reg *testRegistry) addBlob(bb []byte) string {
+	digest := digestOf(bb)
+	reg.blobs[digest] = bb
+	return digest
+}
+
+func (reg *testRegistry) setManifest(tag string, m manifest) {
+	bb, err := json.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	reg.manifests[tag] = reg.addBlob(bb)
+}
+
+func tarGzip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
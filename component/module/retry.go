@@ -0,0 +1,97 @@
+package module
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryArguments configures how module loaders which poll for changes retry
+// after failing to load a module.
+type RetryArguments struct {
+	MinBackoff time.Duration `river:"min_backoff,attr,optional"`
+	MaxBackoff time.Duration `river:"max_backoff,attr,optional"`
+	Jitter     float64       `river:"jitter,attr,optional"`
+}
+
+// DefaultRetryArguments holds default settings for RetryArguments.
+var DefaultRetryArguments = RetryArguments{
+	MinBackoff: 10 * time.Second,
+	MaxBackoff: 5 * time.Minute,
+	Jitter:     0.1,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *RetryArguments) SetToDefault() {
+	*args = DefaultRetryArguments
+}
+
+// Validate implements river.Validator.
+func (args *RetryArguments) Validate() error {
+	if args.MinBackoff <= 0 {
+		return fmt.Errorf("min_backoff must be greater than 0")
+	}
+	if args.MaxBackoff < args.MinBackoff {
+		return fmt.Errorf("max_backoff must not be less than min_backoff")
+	}
+	if args.Jitter < 0 || args.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff tracks consecutive failures of a module loader to compute when it
+// should poll again. The zero value is not usable; create a Backoff with
+// NewBackoff.
+type Backoff struct {
+	args     RetryArguments
+	failures int
+}
+
+// NewBackoff creates a new Backoff with the given settings.
+func NewBackoff(args RetryArguments) *Backoff {
+	return &Backoff{args: args}
+}
+
+// SetArguments changes the settings of b and resets its failures.
+func (b *Backoff) SetArguments(args RetryArguments) {
+	b.args = args
+	b.failures = 0
+}
+
+// Next records the result of a poll and returns how long to wait before
+// polling again. After a successful poll, interval is returned. After a
+// failed poll, the wait starts at the minimum backoff and doubles with every
+// consecutive failure up to the maximum backoff, with random jitter applied.
+func (b *Backoff) Next(err error, interval time.Duration) time.Duration {
+	if err == nil {
+		b.failures = 0
+		return interval
+	}
+
+	backoff := b.args.MinBackoff
+	for i := 0; i < b.failures && backoff < b.args.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > b.args.MaxBackoff {
+		backoff = b.args.MaxBackoff
+	}
+	b.failures++
+
+	if b.args.Jitter > 0 {
+		backoff += time.Duration(b.args.Jitter * (2*rand.Float64() - 1) * float64(backoff))
+	}
+	return backoff
+}
+
+// ResetTimer stops t and resets it to fire after d, discarding a pending
+// expiration which hasn't been received yet.
+func ResetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
@@ -0,0 +1,39 @@
+package module
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	b := NewBackoff(RetryArguments{
+		MinBackoff: time.Second,
+		MaxBackoff: 5 * time.Second,
+	})
+
+	require.Equal(t, time.Minute, b.Next(nil, time.Minute))
+	require.Equal(t, 1*time.Second, b.Next(errFailed, time.Minute))
+	require.Equal(t, 2*time.Second, b.Next(errFailed, time.Minute))
+	require.Equal(t, 4*time.Second, b.Next(errFailed, time.Minute))
+	require.Equal(t, 5*time.Second, b.Next(errFailed, time.Minute))
+	require.Equal(t, 5*time.Second, b.Next(errFailed, time.Minute))
+
+	// A successful poll resets the backoff.
+	require.Equal(t, time.Minute, b.Next(nil, time.Minute))
+	require.Equal(t, 1*time.Second, b.Next(errFailed, time.Minute))
+
+	b.SetArguments(RetryArguments{
+		MinBackoff: 10 * time.Second,
+		MaxBackoff: time.Minute,
+		Jitter:     0.5,
+	})
+	for i := 0; i < 100; i++ {
+		require.InDelta(t, 10*time.Second, b.Next(errFailed, time.Minute), float64(5*time.Second))
+		b.Next(nil, time.Minute)
+	}
+}
@@ -0,0 +1,210 @@
+package s3
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	remote_s3 "github.com/grafana/agent/component/remote/s3"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/river/rivertypes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "module.s3",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the module.s3 component.
+type Arguments struct {
+	RemoteS3Arguments remote_s3.Arguments `river:",squash"`
+
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
+	Arguments map[string]any `river:"arguments,block,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	args.RemoteS3Arguments.SetToDefault()
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if err := args.RemoteS3Arguments.Validate(); err != nil {
+		return err
+	}
+	return module.ValidateVersionConstraint(args.Version)
+}
+
+// requirements returns the requirements which the module content must meet.
+func (args *Arguments) requirements() module.Requirements {
+	return module.Requirements{Version: args.Version}
+}
+
+// Component implements the module.s3 component.
+type Component struct {
+	opts component.Options
+	mod  *module.ModuleComponent
+
+	mut     sync.RWMutex
+	args    Arguments
+	content rivertypes.OptionalSecret
+
+	managedRemoteS3 *remote_s3.Component
+	inUpdate        atomic.Bool
+	isCreated       atomic.Bool
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+	_ module.InfoComponent      = (*Component)(nil)
+)
+
+// New creates a new module.s3 component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	m, err := module.NewModuleComponent(o)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts: o,
+		mod:  m,
+		args: args,
+	}
+	defer c.isCreated.Store(true)
+
+	c.managedRemoteS3, err = c.newManagedRemoteS3(o)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newManagedRemoteS3 creates the new remote.s3 managed component.
+func (c *Component) newManagedRemoteS3(o component.Options) (*remote_s3.Component, error) {
+	remoteS3Opts := o
+	remoteS3Opts.OnStateChange = func(e component.Exports) {
+		c.setContent(e.(remote_s3.Exports).Content)
+
+		if !c.inUpdate.Load() && c.isCreated.Load() {
+			// Any errors found here are reported via component health
+			args := c.getArgs()
+			_ = c.mod.LoadVerifiedFlowSource(args.Arguments, c.getContent().Value, args.requirements())
+		}
+	}
+
+	return remote_s3.New(remoteS3Opts, c.getArgs().RemoteS3Arguments)
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan error, 1)
+	go func() {
+		err := c.managedRemoteS3.Run(ctx)
+		if err != nil {
+			ch <- err
+		}
+	}()
+
+	go c.mod.RunFlowController(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-ch:
+			return err
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.inUpdate.Store(true)
+	defer c.inUpdate.Store(false)
+
+	newArgs := args.(Arguments)
+	c.setArgs(newArgs)
+
+	err := c.managedRemoteS3.Update(newArgs.RemoteS3Arguments)
+	if err != nil {
+		return err
+	}
+
+	// Force a content load here and bubble up any error. This will catch problems
+	// on initial load.
+	return c.mod.LoadVerifiedFlowSource(newArgs.Arguments, c.getContent().Value, newArgs.requirements())
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	leastHealthy := component.LeastHealthy(
+		c.managedRemoteS3.CurrentHealth(),
+		c.mod.CurrentHealth(),
+	)
+
+	if leastHealthy.Health == component.HealthTypeHealthy {
+		return c.mod.CurrentHealth()
+	}
+	return leastHealthy
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return c.mod.DebugInfo()
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
+}
+
+// getArgs is a goroutine safe way to get args
+func (c *Component) getArgs() Arguments {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args
+}
+
+// setArgs is a goroutine safe way to set args
+func (c *Component) setArgs(args Arguments) {
+	c.mut.Lock()
+	c.args = args
+	c.mut.Unlock()
+}
+
+// getContent is a goroutine safe way to get content
+func (c *Component) getContent() rivertypes.OptionalSecret {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.content
+}
+
+// setContent is a goroutine safe way to set content
+func (c *Component) setContent(content rivertypes.OptionalSecret) {
+	c.mut.Lock()
+	c.content = content
+	c.mut.Unlock()
+}
@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module/moduletest"
+	remote_s3 "github.com/grafana/agent/component/remote/s3"
+	"github.com/grafana/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "valid",
+			config: `
+				path    = "s3://bucket/module.river"
+				version = ">= 1.0"
+			`,
+		},
+		{
+			name: "poll frequency too low",
+			config: `
+				path           = "s3://bucket/module.river"
+				poll_frequency = "10s"
+			`,
+			expectError: "poll_frequency must be greater than 30s",
+		},
+		{
+			name: "invalid version constraint",
+			config: `
+				path    = "s3://bucket/module.river"
+				version = "not a version"
+			`,
+			expectError: `invalid version constraint "not a version"`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			if tc.expectError == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectError)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	// The bucket can't be reached, since the server is closed right away.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	ctrl := &moduletest.FakeModuleController{}
+	opts := component.Options{
+		ID:               "module.s3.test",
+		Logger:           log.NewNopLogger(),
+		DataPath:         t.TempDir(),
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: ctrl,
+		OnStateChange:    func(component.Exports) {},
+	}
+
+	var args Arguments
+	args.SetToDefault()
+	args.RemoteS3Arguments.Path = "s3://bucket/module.river"
+	args.RemoteS3Arguments.Options = remote_s3.Client{
+		AccessKey:    "key",
+		Secret:       "secret",
+		Endpoint:     srv.URL,
+		UsePathStyle: true,
+		Region:       "us-east-1",
+	}
+
+	// A failed download doesn't fail building the component; it's reported
+	// through the health of the component instead.
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, component.HealthTypeUnhealthy, c.CurrentHealth().Health)
+
+	args.Arguments = map[string]any{"key": "value"}
+	require.NoError(t, c.Update(args))
+	require.Equal(t, args, c.getArgs())
+}
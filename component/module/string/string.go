@@ -5,14 +5,16 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/river/rivertypes"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "module.string",
-		Args:    Arguments{},
-		Exports: module.Exports{},
+		Name:      "module.string",
+		Stability: featuregate.StabilityBeta,
+		Args:      Arguments{},
+		Exports:   module.Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -26,10 +28,18 @@ type Arguments struct {
 	// Content to load for the module.
 	Content rivertypes.OptionalSecret `river:"content,attr"`
 
+	// Version is a constraint on the version declared by the module.
+	Version string `river:"version,attr,optional"`
+
 	// Arguments to pass into the module.
 	Arguments map[string]any `river:"arguments,block,optional"`
 }
 
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return module.ValidateVersionConstraint(args.Version)
+}
+
 // Component implements the module.string component.
 type Component struct {
 	mod *module.ModuleComponent
@@ -38,6 +48,8 @@ type Component struct {
 var (
 	_ component.Component       = (*Component)(nil)
 	_ component.HealthComponent = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+	_ module.InfoComponent      = (*Component)(nil)
 )
 
 // New creates a new module.string component.
@@ -66,10 +78,22 @@ func (c *Component) Run(ctx context.Context) error {
 func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
 
-	return c.mod.LoadFlowSource(newArgs.Arguments, newArgs.Content.Value)
+	return c.mod.LoadVerifiedFlowSource(newArgs.Arguments, newArgs.Content.Value, module.Requirements{
+		Version: newArgs.Version,
+	})
 }
 
 // CurrentHealth implements component.HealthComponent.
 func (c *Component) CurrentHealth() component.Health {
 	return c.mod.CurrentHealth()
 }
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return c.mod.DebugInfo()
+}
+
+// ModuleInfo implements module.InfoComponent.
+func (c *Component) ModuleInfo() module.Info {
+	return c.mod.Info()
+}
@@ -0,0 +1,97 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+)
+
+// stuckLoadTimeout is how long loading module content may take before the
+// load is considered stuck.
+const stuckLoadTimeout = time.Minute
+
+// startLoad records that loading module content started. finishLoad must be
+// called once the load returns.
+func (c *ModuleComponent) startLoad() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.loadsInFlight == 0 {
+		c.loadStarted = time.Now()
+	}
+	c.loadsInFlight++
+}
+
+// finishLoad records that a load started by startLoad returned.
+func (c *ModuleComponent) finishLoad() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.loadsInFlight--
+	if c.loadsInFlight == 0 {
+		c.loadStarted = time.Time{}
+		c.loadStuck = false
+	}
+}
+
+// stuckHealth returns the health to report while a load is stuck, and false
+// if no load is stuck.
+func (c *ModuleComponent) stuckHealth() (component.Health, bool) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	if !c.loadStuck {
+		return component.Health{}, false
+	}
+	return component.Health{
+		Health:     component.HealthTypeUnhealthy,
+		Message:    fmt.Sprintf("module content update hasn't been applied after %s", time.Since(c.loadStarted).Round(time.Second)),
+		UpdateTime: c.loadStarted.Add(c.stuckLoadTimeout),
+	}, true
+}
+
+// watchLoads checks for stuck loads until ctx is canceled. The goroutines of
+// the module loader and of the components of its module are logged once for
+// every stuck load, so that it can be found out what the load is waiting
+// for. The module loader is reported as unhealthy until the load returns.
+func (c *ModuleComponent) watchLoads(ctx context.Context) {
+	ticker := time.NewTicker(c.stuckLoadTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if elapsed, stuck := c.checkStuckLoad(); stuck {
+				c.metrics.stuckLoads.Inc()
+				level.Error(c.opts.Logger).Log(
+					"msg", "module content update hasn't been applied",
+					"id", c.opts.ID,
+					"duration", elapsed,
+					"goroutines", dumpComponentGoroutines(c.opts.ID),
+				)
+			}
+		}
+	}
+}
+
+// checkStuckLoad marks the load in flight as stuck if it exceeded the
+// timeout. checkStuckLoad only reports a load as stuck once.
+func (c *ModuleComponent) checkStuckLoad() (time.Duration, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.loadsInFlight == 0 || c.loadStuck {
+		return 0, false
+	}
+	elapsed := time.Since(c.loadStarted)
+	if elapsed < c.stuckLoadTimeout {
+		return 0, false
+	}
+	c.loadStuck = true
+	return elapsed, true
+}
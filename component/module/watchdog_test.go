@@ -0,0 +1,93 @@
+package module
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchLoads(t *testing.T) {
+	var (
+		mod     = blockingModule{release: make(chan struct{})}
+		logs    syncBuffer
+		logger  = newTestLogger(t, &logs)
+		loadErr = make(chan error, 1)
+	)
+	c, err := NewModuleComponent(component.Options{
+		ID:               "module.string.stuck",
+		Logger:           logger,
+		Registerer:       prometheus.NewRegistry(),
+		ModuleController: fakeModuleController{mod: &mod.fakeModule},
+		OnStateChange:    func(component.Exports) {},
+	})
+	require.NoError(t, err)
+	c.mod = &mod
+	c.stuckLoadTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.RunFlowController(ctx)
+
+	pprof.Do(ctx, pprof.Labels("component_id", "module.string.stuck"), func(context.Context) {
+		go func() { loadErr <- c.LoadFlowSource(nil, "") }()
+	})
+
+	require.Eventually(t, func() bool {
+		return c.CurrentHealth().Health == component.HealthTypeUnhealthy
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, c.CurrentHealth().Message, "module content update hasn't been applied")
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.stuckLoads))
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "blockingModule")
+	}, 5*time.Second, 10*time.Millisecond, "goroutine of the stuck load must be logged")
+
+	close(mod.release)
+	require.NoError(t, <-loadErr)
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.stuckLoads), "a stuck load must only be reported once")
+}
+
+// blockingModule is a module whose loads block until release is closed.
+type blockingModule struct {
+	fakeModule
+	release chan struct{}
+}
+
+func (m *blockingModule) LoadConfig(config []byte, args map[string]any) error {
+	<-m.release
+	return m.fakeModule.LoadConfig(config, args)
+}
+
+type syncBuffer struct {
+	mut sync.Mutex
+	sb  strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return b.sb.String()
+}
+
+func newTestLogger(t *testing.T, w *syncBuffer) *logging.Logger {
+	t.Helper()
+	l, err := logging.New(w, logging.DefaultOptions)
+	require.NoError(t, err)
+	return l
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/internal/agentseed"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/useragent"
 	"github.com/grafana/agent/pkg/flow/logging/level"
 	"github.com/grafana/agent/pkg/metrics/wal"
@@ -39,9 +40,10 @@ func init() {
 	remote.UserAgent = useragent.Get()
 
 	component.Register(component.Registration{
-		Name:    "prometheus.remote_write",
-		Args:    Arguments{},
-		Exports: Exports{},
+		Name:      "prometheus.remote_write",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
+		Exports:   Exports{},
 
 		Build: func(o component.Options, c component.Arguments) (component.Component, error) {
 			return New(o, c.(Arguments))
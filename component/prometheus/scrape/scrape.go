@@ -12,6 +12,7 @@ import (
 	component_config "github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/useragent"
 	"github.com/grafana/agent/pkg/flow/logging/level"
 	"github.com/grafana/agent/service/cluster"
@@ -30,8 +31,9 @@ func init() {
 	scrape.UserAgent = useragent.Get()
 
 	component.Register(component.Registration{
-		Name: "prometheus.scrape",
-		Args: Arguments{},
+		Name:      "prometheus.scrape",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -120,16 +122,20 @@ type Component struct {
 	cluster cluster.Cluster
 
 	reloadTargets chan struct{}
+	stopOnce      sync.Once     // Guards stopping the scraper, which may only happen once.
+	stopped       chan struct{} // Closed once the scraper is stopped.
 
 	mut          sync.RWMutex
 	args         Arguments
+	drained      bool
 	scraper      *scrape.Manager
 	appendable   *prometheus.Fanout
 	targetsGauge client_prometheus.Gauge
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component          = (*Component)(nil)
+	_ component.DrainableComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.scrape component.
@@ -174,6 +180,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		opts:          o,
 		cluster:       clusterData,
 		reloadTargets: make(chan struct{}, 1),
+		stopped:       make(chan struct{}),
 		scraper:       scraper,
 		appendable:    flowAppendable,
 		targetsGauge:  targetsGauge,
@@ -189,7 +196,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
-	defer c.scraper.Stop()
+	defer c.stopScraper()
 
 	targetSetsChan := make(chan map[string][]*targetgroup.Group)
 
@@ -222,6 +229,9 @@ func (c *Component) Run(ctx context.Context) error {
 			select {
 			case targetSetsChan <- promTargets:
 				level.Debug(c.opts.Logger).Log("msg", "passed new targets to scrape manager")
+			case <-c.stopped:
+				// The scrape manager doesn't receive targets after it's stopped
+				// for draining.
 			case <-ctx.Done():
 			}
 		}
@@ -238,6 +248,12 @@ func (c *Component) Update(args component.Arguments) error {
 
 	c.appendable.UpdateChildren(newArgs.ForwardTo)
 
+	// Applying a new config would restart the scrape loops of a drained
+	// component.
+	if c.drained {
+		return nil
+	}
+
 	sc := getPromScrapeConfigs(c.opts.ID, newArgs)
 	err := c.scraper.ApplyConfig(&config.Config{
 		ScrapeConfigs: []*config.ScrapeConfig{sc},
@@ -255,6 +271,26 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// Drain implements component.DrainableComponent and stops scraping targets.
+// Drain waits for scrapes which are in progress, so their samples are still
+// forwarded.
+func (c *Component) Drain(_ context.Context) error {
+	c.mut.Lock()
+	c.drained = true
+	c.mut.Unlock()
+
+	c.stopScraper()
+	level.Info(c.opts.Logger).Log("msg", "stopped scraping targets for draining")
+	return nil
+}
+
+func (c *Component) stopScraper() {
+	c.stopOnce.Do(func() {
+		c.scraper.Stop()
+		close(c.stopped)
+	})
+}
+
 // NotifyClusterChange implements component.ClusterComponent.
 func (c *Component) NotifyClusterChange() {
 	c.mut.RLock()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -210,6 +211,86 @@ func TestCustomDialer(t *testing.T) {
 	require.NoError(t, err, "custom dialer was not used")
 }
 
+func TestDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		scrapes       atomic.Int64
+		scrapeTrigger = util.NewWaitTrigger()
+
+		srv = &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				scrapes.Add(1)
+				scrapeTrigger.Trigger()
+				promhttp.HandlerFor(prometheus_client.NewRegistry(), promhttp.HandlerOpts{}).ServeHTTP(w, r)
+			}),
+		}
+
+		memLis = memconn.NewListener(util.TestLogger(t))
+	)
+
+	go srv.Serve(memLis)
+	defer srv.Shutdown(ctx)
+
+	var config = `
+	targets         = [{ __address__ = "inmemory:80" }]
+	forward_to      = []
+	scrape_interval = "100ms"
+	scrape_timeout  = "85ms"
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(config), &args))
+
+	opts := component.Options{
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus_client.NewRegistry(),
+		GetServiceData: func(name string) (interface{}, error) {
+			switch name {
+			case http_service.ServiceName:
+				return http_service.Data{
+					HTTPListenAddr:   "inmemory:80",
+					MemoryListenAddr: "inmemory:80",
+					BaseHTTPPath:     "/",
+					DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
+						return memLis.DialContext(ctx)
+					},
+				}, nil
+
+			case cluster.ServiceName:
+				return cluster.Mock(), nil
+			case labelstore.ServiceName:
+				return labelstore.New(nil, prometheus_client.DefaultRegisterer), nil
+
+			default:
+				return nil, fmt.Errorf("service %q does not exist", name)
+			}
+		},
+	}
+
+	s, err := New(opts, args)
+	require.NoError(t, err)
+	go s.Run(ctx)
+
+	require.NoError(t, scrapeTrigger.Wait(1*time.Minute))
+	require.NoError(t, s.Drain(ctx))
+
+	// Updating a drained component must not restart scraping.
+	require.NoError(t, s.Update(args))
+
+	// Reloading targets must not block the run loop.
+	for i := 0; i < 3; i++ {
+		s.reloadTargets <- struct{}{}
+		require.Eventually(t, func() bool {
+			return len(s.reloadTargets) == 0
+		}, 5*time.Second, 10*time.Millisecond, "run loop blocked after draining")
+	}
+
+	drained := scrapes.Load()
+	time.Sleep(500 * time.Millisecond)
+	require.Equal(t, drained, scrapes.Load(), "targets were scraped after draining")
+}
+
 func TestValidateScrapeConfig(t *testing.T) {
 	var exampleRiverConfig = `
 	targets         = [{ "target1" = "target1" }]
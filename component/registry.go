@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/regexp"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
@@ -54,6 +56,49 @@ type Module interface {
 	Run(context.Context) error
 }
 
+// ContextModule is an optional extension interface for Modules which accept
+// a context when loading config. The span in the context, if any, is the
+// parent of the spans created while the config is loaded.
+type ContextModule interface {
+	Module
+
+	// LoadConfigContext is like [Module.LoadConfig], but uses ctx for tracing.
+	LoadConfigContext(ctx context.Context, config []byte, args map[string]any) error
+}
+
+// HealthModule is an optional extension interface for Modules which report
+// the health of the components running within them.
+type HealthModule interface {
+	Module
+
+	// CurrentHealth returns the least healthy of the components running
+	// within the Module. Components of nested modules are included through
+	// the health of their module loaders.
+	CurrentHealth() Health
+}
+
+// ComponentsModule is an optional extension interface for Modules which
+// describe the components running within them.
+type ComponentsModule interface {
+	Module
+
+	// Components returns the current state of the components running within
+	// the Module.
+	Components() []ComponentState
+}
+
+// ComponentState describes a component running within a Module.
+type ComponentState struct {
+	// ID of the component, local to the Module.
+	ID string
+
+	// Current health of the component.
+	Health Health
+
+	// Time when the component was last evaluated.
+	LastEvaluation time.Time
+}
+
 // ExportFunc is used for onExport of the Module
 type ExportFunc func(exports map[string]any)
 
@@ -119,6 +164,12 @@ type Registration struct {
 	// any number of underscores or alphanumeric ASCII characters.
 	Name string
 
+	// Stability is the overall stability level of the component. Components
+	// may not be used if their stability level is lower than the minimum
+	// stability level the Flow controller was configured with. Stability must
+	// be set.
+	Stability featuregate.Stability
+
 	// An example Arguments value that the registered component expects to
 	// receive as input. Components should provide the zero value of their
 	// Arguments type here.
@@ -139,12 +190,16 @@ func (r Registration) CloneArguments() Arguments {
 }
 
 // Register registers a component. Register will panic if the name is in use by
-// another component, if the name is invalid, or if the component name has a
-// suffix length mismatch with an existing component.
+// another component, if the name is invalid, if the component name has a
+// suffix length mismatch with an existing component, or if the stability
+// level of the component is undefined.
 func Register(r Registration) {
 	if _, exist := registered[r.Name]; exist {
 		panic(fmt.Sprintf("Component name %q already registered", r.Name))
 	}
+	if r.Stability == featuregate.StabilityUndefined {
+		panic(fmt.Sprintf("Component %q has an undefined stability level", r.Name))
+	}
 
 	parsed, err := parseComponentName(r.Name)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,9 +14,11 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
 	common_config "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/internal/useragent"
 	"github.com/grafana/agent/pkg/flow/logging/level"
 	"github.com/grafana/river/rivertypes"
+	"github.com/prometheus/client_golang/prometheus"
 	prom_config "github.com/prometheus/common/config"
 )
 
@@ -23,9 +26,10 @@ var userAgent = useragent.Get()
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "remote.http",
-		Args:    Arguments{},
-		Exports: Exports{},
+		Name:      "remote.http",
+		Stability: featuregate.StabilityStable,
+		Args:      Arguments{},
+		Exports:   Exports{},
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
@@ -93,17 +97,29 @@ type Component struct {
 	cli         *http.Client
 	lastPoll    time.Time
 	lastExports Exports // Used for determining whether exports should be updated
+	cache       cacheState
 
 	// Updated is written to whenever args updates.
 	updated chan struct{}
 
 	healthMut sync.RWMutex
 	health    component.Health
+
+	skippedFetches prometheus.Counter
+}
+
+// cacheState holds the caching information of the last response, which is
+// used to avoid downloading content which didn't change.
+type cacheState struct {
+	etag         string    // ETag of the last response.
+	lastModified string    // Last-Modified header of the last response.
+	freshUntil   time.Time // Content isn't requested again before freshUntil.
 }
 
 var (
-	_ component.Component       = (*Component)(nil)
-	_ component.HealthComponent = (*Component)(nil)
+	_ component.Component            = (*Component)(nil)
+	_ component.HealthComponent      = (*Component)(nil)
+	_ component.RefreshableComponent = (*Component)(nil)
 )
 
 // New returns a new, unstarted, remote.http component.
@@ -119,8 +135,16 @@ func New(opts component.Options, args Arguments) (*Component, error) {
 			Message:    "component started",
 			UpdateTime: time.Now(),
 		},
+
+		skippedFetches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_remote_http_skipped_fetches_total",
+			Help: "Number of polls which didn't download the content because it was cached or not modified",
+		}),
 	}
 
+	if err := opts.Registerer.Register(c.skippedFetches); err != nil {
+		return nil, err
+	}
 	if err := c.Update(args); err != nil {
 		return nil, err
 	}
@@ -141,6 +165,20 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// Refresh implements component.RefreshableComponent and polls the endpoint
+// as soon as possible.
+func (c *Component) Refresh() {
+	c.mut.Lock()
+	c.lastPoll = time.Time{}
+	c.cache.freshUntil = time.Time{}
+	c.mut.Unlock()
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+}
+
 // nextPoll returns how long to wait to poll given the last time a
 // poll occurred. nextPoll returns 0 if a poll should occur immediately.
 func (c *Component) nextPoll() time.Duration {
@@ -191,6 +229,13 @@ func (c *Component) pollError() error {
 
 	c.lastPoll = time.Now()
 
+	// Don't request content which is still fresh according to the
+	// Cache-Control header of the last response.
+	if c.lastPoll.Before(c.cache.freshUntil) {
+		c.skippedFetches.Inc()
+		return nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), c.args.PollTimeout)
 	defer cancel()
 
@@ -207,6 +252,7 @@ func (c *Component) pollError() error {
 	for name, value := range c.args.Headers {
 		req.Header.Set(name, value)
 	}
+	c.setConditionalHeaders(req)
 	req = req.WithContext(ctx)
 
 	resp, err := c.cli.Do(req)
@@ -221,11 +267,18 @@ func (c *Component) pollError() error {
 		return fmt.Errorf("reading response: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusNotModified {
+		c.cache.freshUntil = freshUntil(resp.Header, c.lastPoll)
+		c.skippedFetches.Inc()
+		return nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		level.Error(c.log).Log("msg", "unexpected status code from response", "status", resp.Status)
 		return fmt.Errorf("unexpected status code %s", resp.Status)
 	}
 
+	c.cache = newCacheState(resp.Header, c.lastPoll)
+
 	stringContent := strings.TrimSpace(string(bb))
 
 	newExports := Exports{
@@ -244,6 +297,65 @@ func (c *Component) pollError() error {
 	return nil
 }
 
+// setConditionalHeaders makes req conditional on the content having changed
+// since the last response. Headers which were configured explicitly are
+// kept.
+func (c *Component) setConditionalHeaders(req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return
+	}
+	if c.cache.etag != "" && req.Header.Get("If-None-Match") == "" {
+		req.Header.Set("If-None-Match", c.cache.etag)
+	}
+	if c.cache.lastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+		req.Header.Set("If-Modified-Since", c.cache.lastModified)
+	}
+}
+
+// newCacheState returns the caching information of a response received at
+// now.
+func newCacheState(h http.Header, now time.Time) cacheState {
+	if hasCacheDirective(h, "no-store") {
+		return cacheState{}
+	}
+	return cacheState{
+		etag:         h.Get("ETag"),
+		lastModified: h.Get("Last-Modified"),
+		freshUntil:   freshUntil(h, now),
+	}
+}
+
+// freshUntil returns until when a response received at now may be used
+// without requesting it again, based on the max-age directive of its
+// Cache-Control header. The zero time is returned if the response must
+// always be requested again.
+func freshUntil(h http.Header, now time.Time) time.Time {
+	if hasCacheDirective(h, "no-cache") || hasCacheDirective(h, "no-store") {
+		return time.Time{}
+	}
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil || seconds <= 0 {
+			return time.Time{}
+		}
+		return now.Add(time.Duration(seconds) * time.Second)
+	}
+	return time.Time{}
+}
+
+func hasCacheDirective(h http.Header, name string) bool {
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), name) {
+			return true
+		}
+	}
+	return false
+}
+
 // Update updates the remote.http component. After the update completes, a
 // poll is forced.
 func (c *Component) Update(args component.Arguments) (err error) {
@@ -267,6 +379,10 @@ func (c *Component) Update(args component.Arguments) (err error) {
 	newArgs := args.(Arguments)
 	c.args = newArgs
 
+	// The request may have changed, so the cached response can't be used
+	// anymore.
+	c.cache = cacheState{}
+
 	// Override default UserAgent if another is provided in "headers" section
 	customUserAgent, exist := c.args.Headers["User-Agent"]
 	if !exist {
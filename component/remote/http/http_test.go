@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -94,6 +95,55 @@ func Test(t *testing.T) {
 	})
 }
 
+func TestConditionalRequests(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	var notModified atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprintln(w, "Hello, world!")
+	}))
+	defer srv.Close()
+
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "remote.http")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		url = "%s"
+
+		poll_frequency = "50ms"
+		poll_timeout   = "25ms"
+	`, srv.URL)
+	var args http_component.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	eventually(t, 50*time.Millisecond, 100*time.Millisecond, 10, func() error {
+		if n := notModified.Load(); n < 2 {
+			return fmt.Errorf("expected at least 2 conditional requests, got %d", n)
+		}
+		return nil
+	})
+
+	// Responses without content must not change the exports.
+	require.Equal(t, http_component.Exports{
+		Content: rivertypes.OptionalSecret{Value: "Hello, world!"},
+	}, ctrl.Exports())
+}
+
 func TestUnmarshalValidation(t *testing.T) {
 	var tests = []struct {
 		testname      string
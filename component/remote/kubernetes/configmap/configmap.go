@@ -3,13 +3,15 @@ package configmap
 import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/remote/kubernetes"
+	"github.com/grafana/agent/internal/featuregate"
 )
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "remote.kubernetes.configmap",
-		Args:    kubernetes.Arguments{},
-		Exports: kubernetes.Exports{},
+		Name:      "remote.kubernetes.configmap",
+		Stability: featuregate.StabilityStable,
+		Args:      kubernetes.Arguments{},
+		Exports:   kubernetes.Exports{},
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return kubernetes.New(opts, args.(kubernetes.Arguments), kubernetes.TypeConfigMap)
 		},
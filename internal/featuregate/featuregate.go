@@ -0,0 +1,98 @@
+// Package featuregate provides a way to gate features, such as components,
+// based on their stability level and a user-defined minimum allowed stability
+// level.
+package featuregate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Stability is the stability level of a feature.
+type Stability int
+
+const (
+	// StabilityUndefined is the zero value of Stability. Features must not use
+	// StabilityUndefined; it is only used to detect features which forgot to
+	// set their stability level. When used as the minimum allowed stability
+	// level, features of every stability level are allowed.
+	StabilityUndefined Stability = iota
+
+	// StabilityExperimental is used for features which are new and may change
+	// or be removed without notice.
+	StabilityExperimental
+
+	// StabilityBeta is used for features which are mostly complete but may
+	// still receive breaking changes.
+	StabilityBeta
+
+	// StabilityStable is used for features which are covered by backwards
+	// compatibility guarantees.
+	StabilityStable
+)
+
+var _ pflag.Value = (*Stability)(nil)
+
+// AllowedValues returns the string representations of the stability levels
+// which users may provide.
+func AllowedValues() []string {
+	return []string{
+		StabilityExperimental.String(),
+		StabilityBeta.String(),
+		StabilityStable.String(),
+	}
+}
+
+// CheckAllowed returns an error if a feature with the provided stability may
+// not be used because it is less stable than minStability. name is used in
+// the error message to describe the feature.
+func CheckAllowed(stability Stability, minStability Stability, name string) error {
+	if minStability == StabilityUndefined {
+		return nil
+	}
+	if stability == StabilityUndefined {
+		return fmt.Errorf("stability level of %s is not defined; this is a bug in Grafana Agent", name)
+	}
+	if stability < minStability {
+		return fmt.Errorf(
+			"%s is at stability level %q, which is below the minimum allowed stability level %q; "+
+				"use the --stability.level command-line flag to enable %s features",
+			name, stability, minStability, stability,
+		)
+	}
+	return nil
+}
+
+// String returns the string representation of s.
+func (s Stability) String() string {
+	switch s {
+	case StabilityExperimental:
+		return "experimental"
+	case StabilityBeta:
+		return "beta"
+	case StabilityStable:
+		return "stable"
+	default:
+		return "undefined"
+	}
+}
+
+// Set implements [pflag.Value] and parses text into s.
+func (s *Stability) Set(text string) error {
+	switch strings.ToLower(text) {
+	case "experimental":
+		*s = StabilityExperimental
+	case "beta":
+		*s = StabilityBeta
+	case "stable":
+		*s = StabilityStable
+	default:
+		return fmt.Errorf("unknown stability level %q, must be one of: %s", text, strings.Join(AllowedValues(), ", "))
+	}
+	return nil
+}
+
+// Type implements [pflag.Value].
+func (s *Stability) Type() string { return "stability" }
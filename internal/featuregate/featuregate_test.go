@@ -0,0 +1,41 @@
+package featuregate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAllowed(t *testing.T) {
+	tt := []struct {
+		stability, minStability Stability
+		expectErr               bool
+	}{
+		{StabilityExperimental, StabilityUndefined, false},
+		{StabilityExperimental, StabilityExperimental, false},
+		{StabilityExperimental, StabilityBeta, true},
+		{StabilityBeta, StabilityBeta, false},
+		{StabilityBeta, StabilityStable, true},
+		{StabilityStable, StabilityExperimental, false},
+		{StabilityUndefined, StabilityExperimental, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.stability.String()+"/"+tc.minStability.String(), func(t *testing.T) {
+			err := CheckAllowed(tc.stability, tc.minStability, "test")
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStability_Set(t *testing.T) {
+	var s Stability
+	require.NoError(t, s.Set("beta"))
+	require.Equal(t, StabilityBeta, s)
+
+	require.EqualError(t, s.Set("alpha"), `unknown stability level "alpha", must be one of: experimental, beta, stable`)
+}
@@ -0,0 +1,195 @@
+// Package selfmonitor periodically pushes the metrics of the running process
+// to a Prometheus remote write endpoint.
+//
+// Pushing metrics is independent of the components defined in the
+// configuration, so the health of the process can be observed even when the
+// pipelines which would normally collect its metrics are broken.
+package selfmonitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// Options configures a Pusher.
+type Options struct {
+	Logger   log.Logger
+	Gatherer prometheus.Gatherer // Where metrics are gathered from.
+
+	URL      *url.URL      // Remote write endpoint to push metrics to.
+	Interval time.Duration // How often to push metrics.
+	Timeout  time.Duration // Timeout of each push.
+
+	// Labels are added to every pushed series, unless the series already
+	// has a label with the same name.
+	Labels map[string]string
+}
+
+// Pusher pushes gathered metrics to a remote write endpoint on an interval.
+type Pusher struct {
+	opts   Options
+	client remote.WriteClient
+}
+
+// New creates a new Pusher. Call Run to start pushing metrics.
+func New(opts Options) (*Pusher, error) {
+	if opts.URL == nil {
+		return nil, fmt.Errorf("remote write URL must be set")
+	}
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("push interval must be greater than 0")
+	}
+	if opts.Timeout <= 0 || opts.Timeout > opts.Interval {
+		opts.Timeout = opts.Interval
+	}
+
+	client, err := remote.NewWriteClient("self-monitoring", &remote.ClientConfig{
+		URL:              &config_util.URL{URL: opts.URL},
+		Timeout:          model.Duration(opts.Timeout),
+		HTTPClientConfig: config_util.DefaultHTTPClientConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pusher{opts: opts, client: client}, nil
+}
+
+// Run pushes metrics on an interval until ctx is canceled. Failed pushes are
+// logged and not retried; the next push sends the latest values instead.
+func (p *Pusher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.push(ctx); err != nil {
+				level.Warn(p.opts.Logger).Log("msg", "failed to push self-monitoring metrics", "err", err)
+			}
+		}
+	}
+}
+
+func (p *Pusher) push(ctx context.Context) error {
+	mfs, err := p.opts.Gatherer.Gather()
+	if err != nil && len(mfs) == 0 {
+		return fmt.Errorf("gathering metrics: %w", err)
+	}
+
+	req := buildWriteRequest(mfs, p.opts.Labels, time.Now())
+	bb, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+	return p.client.Store(ctx, snappy.Encode(nil, bb), 0)
+}
+
+// buildWriteRequest converts gathered metric families into a remote write
+// request. Summaries and classic histograms are converted into their
+// individual series; native histograms are not supported and are dropped.
+func buildWriteRequest(mfs []*dto.MetricFamily, extraLabels map[string]string, now time.Time) *prompb.WriteRequest {
+	var (
+		req = &prompb.WriteRequest{}
+		ts  = now.UnixMilli()
+	)
+
+	appendSeries := func(name string, m *dto.Metric, value float64, extra ...string) {
+		labels := seriesLabels(name, m, extraLabels, extra...)
+		req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+			Labels:  labels,
+			Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+		})
+	}
+
+	for _, mf := range mfs {
+		name := mf.GetName()
+
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				appendSeries(name, m, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				appendSeries(name, m, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				appendSeries(name, m, m.GetUntyped().GetValue())
+
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.GetQuantile() {
+					appendSeries(name, m, q.GetValue(), model.QuantileLabel, formatFloat(q.GetQuantile()))
+				}
+				appendSeries(name+"_sum", m, s.GetSampleSum())
+				appendSeries(name+"_count", m, float64(s.GetSampleCount()))
+
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				h := m.GetHistogram()
+				if len(h.GetBucket()) == 0 {
+					// Native histograms without classic buckets.
+					continue
+				}
+				for _, b := range h.GetBucket() {
+					appendSeries(name+"_bucket", m, float64(b.GetCumulativeCount()), model.BucketLabel, formatFloat(b.GetUpperBound()))
+				}
+				appendSeries(name+"_bucket", m, float64(h.GetSampleCount()), model.BucketLabel, "+Inf")
+				appendSeries(name+"_sum", m, h.GetSampleSum())
+				appendSeries(name+"_count", m, float64(h.GetSampleCount()))
+			}
+		}
+	}
+
+	return req
+}
+
+// seriesLabels returns the sorted labels of a series. extra holds pairs of
+// label names and values which are specific to the series, such as the
+// bucket of a histogram.
+func seriesLabels(name string, m *dto.Metric, extraLabels map[string]string, extra ...string) []prompb.Label {
+	labels := make([]prompb.Label, 0, 1+len(m.GetLabel())+len(extraLabels)+len(extra)/2)
+	labels = append(labels, prompb.Label{Name: model.MetricNameLabel, Value: name})
+
+	seen := make(map[string]struct{}, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		labels = append(labels, prompb.Label{Name: lp.GetName(), Value: lp.GetValue()})
+		seen[lp.GetName()] = struct{}{}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels = append(labels, prompb.Label{Name: extra[i], Value: extra[i+1]})
+		seen[extra[i]] = struct{}{}
+	}
+	for name, value := range extraLabels {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		labels = append(labels, prompb.Label{Name: name, Value: value})
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, +1) {
+		return "+Inf"
+	}
+	return model.SampleValue(f).String()
+}
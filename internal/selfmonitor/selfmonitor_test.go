@@ -0,0 +1,114 @@
+package selfmonitor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildWriteRequest(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_total",
+	}, []string{"component_id"})
+	counter.WithLabelValues("local.file.a").Add(3)
+
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_seconds",
+		Buckets: []float64{0.5, 1},
+	})
+	histogram.Observe(0.75)
+
+	reg.MustRegister(counter, histogram)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+
+	// Extra labels don't override labels of the series.
+	now := time.UnixMilli(1000)
+	req := buildWriteRequest(mfs, map[string]string{"instance": "agent-0", "component_id": "default"}, now)
+
+	var series []string
+	for _, ts := range req.Timeseries {
+		require.Equal(t, []prompb.Sample{{Value: ts.Samples[0].Value, Timestamp: 1000}}, ts.Samples)
+
+		var s string
+		for _, l := range ts.Labels {
+			s += l.Name + "=" + l.Value + " "
+		}
+		series = append(series, s+formatFloat(ts.Samples[0].Value))
+	}
+
+	require.Equal(t, []string{
+		"__name__=test_seconds_bucket component_id=default instance=agent-0 le=0.5 0",
+		"__name__=test_seconds_bucket component_id=default instance=agent-0 le=1 1",
+		"__name__=test_seconds_bucket component_id=default instance=agent-0 le=+Inf 1",
+		"__name__=test_seconds_sum component_id=default instance=agent-0 0.75",
+		"__name__=test_seconds_count component_id=default instance=agent-0 1",
+		"__name__=test_total component_id=local.file.a instance=agent-0 3",
+	}, series)
+}
+
+func TestPusher(t *testing.T) {
+	// The handler only forwards the bodies of requests, since assertions
+	// must be made from the test goroutine. Pushes made while a body is
+	// waiting to be checked are dropped.
+	received := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case received <- body:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge"})
+	gauge.Set(42)
+	reg.MustRegister(gauge)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	p, err := New(Options{
+		Logger:   log.NewNopLogger(),
+		Gatherer: reg,
+		URL:      u,
+		Interval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	select {
+	case body := <-received:
+		bb, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+
+		var req prompb.WriteRequest
+		require.NoError(t, req.Unmarshal(bb))
+		require.Len(t, req.Timeseries, 1)
+		require.Equal(t, []prompb.Label{{Name: "__name__", Value: "test_gauge"}}, req.Timeseries[0].Labels)
+		require.Equal(t, float64(42), req.Timeseries[0].Samples[0].Value)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no metrics were pushed")
+	}
+}
@@ -3,18 +3,30 @@ package vcs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 type GitRepoOptions struct {
 	Repository string
 	Revision   string
 	Auth       GitAuthConfig
+	Transport  GitTransportConfig
+
+	// SparseCheckoutDirectories limits the files which can be read to the
+	// given directories of the repository. When set, files aren't checked out
+	// to disk at all; they're read from the fetched commit instead.
+	SparseCheckoutDirectories []string
 }
 
 // GitRepo manages a Git repository for the purposes of retrieving a file from
@@ -39,6 +51,14 @@ func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*
 		err  error
 	)
 
+	caBundle, err := opts.Transport.caBundle()
+	if err != nil {
+		return nil, DownloadFailedError{
+			Repository: opts.Repository,
+			Inner:      err,
+		}
+	}
+
 	if !isRepoCloned(storagePath) {
 		repo, err = git.PlainCloneContext(ctx, storagePath, false, &git.CloneOptions{
 			URL:               opts.Repository,
@@ -46,6 +66,10 @@ func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*
 			Auth:              opts.Auth.Convert(),
 			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
 			Tags:              git.AllTags,
+			NoCheckout:        len(opts.SparseCheckoutDirectories) > 0,
+			InsecureSkipTLS:   opts.Transport.insecureSkipVerify(),
+			CABundle:          caBundle,
+			ProxyOptions:      opts.Transport.proxyOptions(),
 		})
 	} else {
 		repo, err = git.PlainOpen(storagePath)
@@ -59,9 +83,13 @@ func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*
 
 	// Fetch the latest contents. This may be a no-op if we just did a clone.
 	fetchRepoErr := repo.FetchContext(ctx, &git.FetchOptions{
-		RemoteName: "origin",
-		Force:      true,
-		Auth:       opts.Auth.Convert(),
+		RemoteName:      "origin",
+		Force:           true,
+		Auth:            opts.Auth.Convert(),
+		Tags:            git.AllTags,
+		InsecureSkipTLS: opts.Transport.insecureSkipVerify(),
+		CABundle:        caBundle,
+		ProxyOptions:    opts.Transport.proxyOptions(),
 	})
 	if fetchRepoErr != nil && !errors.Is(fetchRepoErr, git.NoErrAlreadyUpToDate) {
 		workTree, err := repo.Worktree()
@@ -88,19 +116,15 @@ func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*
 	if err != nil {
 		return nil, err
 	}
-	err = workTree.Reset(&git.ResetOptions{
-		Commit: hash,
-		Mode:   git.HardReset,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return &GitRepo{
+	r := &GitRepo{
 		opts:     opts,
 		repo:     repo,
 		workTree: workTree,
-	}, err
+	}
+	if err := r.reset(hash); err != nil {
+		return nil, err
+	}
+	return r, nil
 }
 
 func isRepoCloned(dir string) bool {
@@ -111,11 +135,22 @@ func isRepoCloned(dir string) bool {
 // Update updates the repository by fetching new content and re-checking out to
 // latest version of Revision.
 func (repo *GitRepo) Update(ctx context.Context) error {
-	var err error
+	caBundle, err := repo.opts.Transport.caBundle()
+	if err != nil {
+		return UpdateFailedError{
+			Repository: repo.opts.Repository,
+			Inner:      err,
+		}
+	}
+
 	fetchRepoErr := repo.repo.FetchContext(ctx, &git.FetchOptions{
-		RemoteName: "origin",
-		Force:      true,
-		Auth:       repo.opts.Auth.Convert(),
+		RemoteName:      "origin",
+		Force:           true,
+		Auth:            repo.opts.Auth.Convert(),
+		Tags:            git.AllTags,
+		InsecureSkipTLS: repo.opts.Transport.insecureSkipVerify(),
+		CABundle:        caBundle,
+		ProxyOptions:    repo.opts.Transport.proxyOptions(),
 	})
 	if fetchRepoErr != nil && !errors.Is(fetchRepoErr, git.NoErrAlreadyUpToDate) {
 		return UpdateFailedError{
@@ -129,19 +164,32 @@ func (repo *GitRepo) Update(ctx context.Context) error {
 	if err != nil {
 		return InvalidRevisionError{Revision: repo.opts.Revision}
 	}
-	err = repo.workTree.Reset(&git.ResetOptions{
+	return repo.reset(hash)
+}
+
+// reset moves the repository to the commit at hash. The files of sparse
+// repositories aren't checked out, so only HEAD is moved.
+func (repo *GitRepo) reset(hash plumbing.Hash) error {
+	mode := git.HardReset
+	if repo.isSparse() {
+		mode = git.SoftReset
+	}
+	return repo.workTree.Reset(&git.ResetOptions{
 		Commit: hash,
-		Mode:   git.HardReset,
+		Mode:   mode,
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	return nil
+func (repo *GitRepo) isSparse() bool {
+	return len(repo.opts.SparseCheckoutDirectories) > 0
 }
 
 // ReadFile returns a file from the repository specified by path.
 func (repo *GitRepo) ReadFile(path string) ([]byte, error) {
+	if repo.isSparse() {
+		return repo.readCommitFile(path)
+	}
+
 	f, err := repo.workTree.Filesystem.Open(path)
 	if err != nil {
 		return nil, err
@@ -151,6 +199,138 @@ func (repo *GitRepo) ReadFile(path string) ([]byte, error) {
 	return io.ReadAll(f)
 }
 
+// IsDir returns true if path is a directory of the repository.
+func (repo *GitRepo) IsDir(name string) bool {
+	if repo.isSparse() {
+		_, err := repo.commitTree(name)
+		return err == nil
+	}
+
+	fi, err := repo.workTree.Filesystem.Stat(name)
+	return err == nil && fi.IsDir()
+}
+
+// ReadDir returns the contents of the files at the top level of the
+// directory at path whose names match glob, keyed by their names.
+func (repo *GitRepo) ReadDir(name, glob string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	if repo.isSparse() {
+		tree, err := repo.commitTree(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range tree.Entries {
+			if !entry.Mode.IsFile() {
+				continue
+			}
+			if ok, err := filepath.Match(glob, entry.Name); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+
+			f, err := tree.TreeEntryFile(&entry)
+			if err != nil {
+				return nil, err
+			}
+			contents, err := f.Contents()
+			if err != nil {
+				return nil, err
+			}
+			files[entry.Name] = []byte(contents)
+		}
+		return files, nil
+	}
+
+	entries, err := repo.workTree.Filesystem.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ok, err := filepath.Match(glob, entry.Name()); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		bb, err := repo.ReadFile(path.Join(name, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = bb
+	}
+	return files, nil
+}
+
+// commitTree returns the tree of the directory at name in the current commit
+// of a sparse repository.
+func (repo *GitRepo) commitTree(name string) (*object.Tree, error) {
+	name = path.Clean(strings.Trim(filepath.ToSlash(name), "/"))
+	if !inDirectories(name, repo.opts.SparseCheckoutDirectories) {
+		return nil, fmt.Errorf("%s is outside of the sparse checkout directories: %w", name, fs.ErrNotExist)
+	}
+
+	ref, err := repo.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil || name == "." {
+		return tree, err
+	}
+	return tree.Tree(name)
+}
+
+// readCommitFile reads a file of a sparse repository from the current commit.
+// Files outside of the sparse checkout directories are reported as not
+// existing.
+func (repo *GitRepo) readCommitFile(name string) ([]byte, error) {
+	notExist := &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+
+	name = path.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))
+	if !inDirectories(name, repo.opts.SparseCheckoutDirectories) {
+		return nil, fmt.Errorf("%s is outside of the sparse checkout directories: %w", name, fs.ErrNotExist)
+	}
+
+	ref, err := repo.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	f, err := commit.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, notExist
+	} else if err != nil {
+		return nil, err
+	}
+
+	contents, err := f.Contents()
+	return []byte(contents), err
+}
+
+// inDirectories returns true if the slash-separated path name is one of dirs
+// or inside of one of them.
+func inDirectories(name string, dirs []string) bool {
+	for _, dir := range dirs {
+		dir = path.Clean(strings.Trim(filepath.ToSlash(dir), "/"))
+		if dir == "." || name == dir || strings.HasPrefix(name, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // CurrentRevision returns the current revision of the repository (by SHA).
 func (repo *GitRepo) CurrentRevision() (string, error) {
 	ref, err := repo.repo.Head()
@@ -161,6 +341,10 @@ func (repo *GitRepo) CurrentRevision() (string, error) {
 }
 
 func findRevision(rev string, repo *git.Repository) (plumbing.Hash, error) {
+	if IsTagPattern(rev) {
+		return findTagPattern(rev, repo)
+	}
+
 	// Try looking for the revision in the following order:
 	//
 	// 1. Search by tag name.
@@ -175,9 +359,73 @@ func findRevision(rev string, repo *git.Repository) (plumbing.Hash, error) {
 		return remoteRef.Hash(), nil
 	}
 
+	// HEAD of a clone points at a local branch, which is moved whenever the
+	// repository is reset. Resolve it through the remote branch it was cloned
+	// from instead, so that HEAD follows the remote.
+	if rev == plumbing.HEAD.String() {
+		if headRef, err := repo.Reference(plumbing.HEAD, false); err == nil && headRef.Type() == plumbing.SymbolicReference {
+			name := plumbing.NewRemoteReferenceName("origin", headRef.Target().Short())
+			if remoteRef, err := repo.Reference(name, true); err == nil {
+				return remoteRef.Hash(), nil
+			}
+		}
+	}
+
 	if hash, err := repo.ResolveRevision(plumbing.Revision(rev)); err == nil {
 		return *hash, nil
 	}
 
 	return plumbing.ZeroHash, plumbing.ErrReferenceNotFound
 }
+
+// IsTagPattern returns true if rev is a pattern which selects tags, such as
+// v1.*, rather than a single revision.
+func IsTagPattern(rev string) bool {
+	return strings.ContainsAny(rev, "*?[")
+}
+
+// findTagPattern returns the commit of the tag with the highest semantic
+// version whose name matches pattern. Tags which aren't semantic versions are
+// ignored, and so are pre-releases unless pattern contains a hyphen.
+func findTagPattern(pattern string, repo *git.Repository) (plumbing.Hash, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	var (
+		best        string
+		bestVersion *semver.Version
+	)
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if ok, err := path.Match(pattern, name); err != nil || !ok {
+			return err
+		}
+
+		version, err := semver.NewVersion(name)
+		if err != nil {
+			return nil
+		}
+		if version.Prerelease() != "" && !strings.Contains(pattern, "-") {
+			return nil
+		}
+
+		if best == "" || version.GreaterThan(bestVersion) {
+			best, bestVersion = name, version
+		}
+		return nil
+	})
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if best == "" {
+		return plumbing.ZeroHash, plumbing.ErrReferenceNotFound
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(plumbing.NewTagReferenceName(best)))
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return *hash, nil
+}
@@ -2,6 +2,11 @@ package vcs_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
@@ -59,6 +64,196 @@ func Test_GitRepo(t *testing.T) {
 	require.Equal(t, "See you later!", string(bb))
 }
 
+func Test_GitRepo_Head(t *testing.T) {
+	origRepo := initRepository(t)
+
+	// commitFile commits a.txt with the given contents.
+	commitFile := func(contents string) {
+		require.NoError(t, origRepo.WriteFile("a.txt", []byte(contents)))
+
+		_, err := origRepo.Worktree.Add(".")
+		require.NoError(t, err)
+
+		_, err = origRepo.Worktree.Commit(contents, &git.CommitOptions{})
+		require.NoError(t, err)
+	}
+
+	commitFile("Hello, world!")
+
+	newRepo, err := vcs.NewGitRepo(context.Background(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: origRepo.Directory,
+		Revision:   "HEAD",
+	})
+	require.NoError(t, err)
+
+	bb, err := newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Hello, world!", string(bb))
+
+	// HEAD follows the remote rather than the local branch of the clone.
+	commitFile("See you later!")
+
+	require.NoError(t, newRepo.Update(context.Background()))
+
+	bb, err = newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "See you later!", string(bb))
+}
+
+func Test_GitRepo_SparseCheckout(t *testing.T) {
+	origRepo := initRepository(t)
+
+	// Write files into two directories of the repository and commit them.
+	{
+		require.NoError(t, origRepo.WriteFile("modules/a.river", []byte("a")))
+		require.NoError(t, origRepo.WriteFile("other/b.txt", []byte("b")))
+
+		_, err := origRepo.Worktree.Add(".")
+		require.NoError(t, err)
+
+		_, err = origRepo.Worktree.Commit("initial commit", &git.CommitOptions{})
+		require.NoError(t, err)
+	}
+
+	origRef, err := origRepo.CurrentRef()
+	require.NoError(t, err)
+
+	newRepoDir := t.TempDir()
+	newRepo, err := vcs.NewGitRepo(context.Background(), newRepoDir, vcs.GitRepoOptions{
+		Repository:                origRepo.Directory,
+		Revision:                  origRef,
+		SparseCheckoutDirectories: []string{"modules"},
+	})
+	require.NoError(t, err)
+
+	bb, err := newRepo.ReadFile("modules/a.river")
+	require.NoError(t, err)
+	require.Equal(t, "a", string(bb))
+
+	_, err = newRepo.ReadFile("other/b.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// Files aren't checked out to disk.
+	require.NoFileExists(t, filepath.Join(newRepoDir, "modules", "a.river"))
+	require.NoFileExists(t, filepath.Join(newRepoDir, "other", "b.txt"))
+
+	// Update the file.
+	{
+		require.NoError(t, origRepo.WriteFile("modules/a.river", []byte("a2")))
+
+		_, err := origRepo.Worktree.Add(".")
+		require.NoError(t, err)
+
+		_, err = origRepo.Worktree.Commit("commit 2", &git.CommitOptions{})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, newRepo.Update(context.Background()))
+
+	bb, err = newRepo.ReadFile("modules/a.river")
+	require.NoError(t, err)
+	require.Equal(t, "a2", string(bb))
+}
+
+func Test_GitRepo_ReadDir(t *testing.T) {
+	origRepo := initRepository(t)
+
+	// Write a directory of modules into the repository and commit it.
+	{
+		require.NoError(t, origRepo.WriteFile("modules/a.river", []byte("a")))
+		require.NoError(t, origRepo.WriteFile("modules/b.river", []byte("b")))
+		require.NoError(t, origRepo.WriteFile("modules/README.md", []byte("readme")))
+		require.NoError(t, origRepo.WriteFile("modules/nested/c.river", []byte("c")))
+
+		_, err := origRepo.Worktree.Add(".")
+		require.NoError(t, err)
+
+		_, err = origRepo.Worktree.Commit("initial commit", &git.CommitOptions{})
+		require.NoError(t, err)
+	}
+
+	origRef, err := origRepo.CurrentRef()
+	require.NoError(t, err)
+
+	for _, sparse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sparse=%v", sparse), func(t *testing.T) {
+			opts := vcs.GitRepoOptions{
+				Repository: origRepo.Directory,
+				Revision:   origRef,
+			}
+			if sparse {
+				opts.SparseCheckoutDirectories = []string{"modules"}
+			}
+
+			newRepo, err := vcs.NewGitRepo(context.Background(), t.TempDir(), opts)
+			require.NoError(t, err)
+
+			require.True(t, newRepo.IsDir("modules"))
+			require.False(t, newRepo.IsDir("modules/a.river"))
+
+			files, err := newRepo.ReadDir("modules", "*.river")
+			require.NoError(t, err)
+			require.Equal(t, map[string][]byte{
+				"a.river": []byte("a"),
+				"b.river": []byte("b"),
+			}, files)
+		})
+	}
+}
+
+func Test_GitRepo_TagPattern(t *testing.T) {
+	origRepo := initRepository(t)
+
+	// commitTag commits a file with the tag as its contents and tags the
+	// commit.
+	commitTag := func(tag string, annotated bool) {
+		require.NoError(t, origRepo.WriteFile("a.txt", []byte(tag)))
+
+		_, err := origRepo.Worktree.Add(".")
+		require.NoError(t, err)
+
+		hash, err := origRepo.Worktree.Commit(tag, &git.CommitOptions{})
+		require.NoError(t, err)
+
+		var opts *git.CreateTagOptions
+		if annotated {
+			opts = &git.CreateTagOptions{Message: tag}
+		}
+		_, err = origRepo.Repo.CreateTag(tag, hash, opts)
+		require.NoError(t, err)
+	}
+
+	commitTag("v1.2.0", true)
+	commitTag("v1.10.0", false)
+	commitTag("v1.11.0-rc.1", false)
+	commitTag("v2.0.0", false)
+
+	newRepo, err := vcs.NewGitRepo(context.Background(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: origRepo.Directory,
+		Revision:   "v1.*",
+	})
+	require.NoError(t, err)
+
+	bb, err := newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "v1.10.0", string(bb))
+
+	// Tagging a new matching version updates the repository to it.
+	commitTag("v1.11.0", true)
+
+	require.NoError(t, newRepo.Update(context.Background()))
+
+	bb, err = newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "v1.11.0", string(bb))
+
+	_, err = vcs.NewGitRepo(context.Background(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: origRepo.Directory,
+		Revision:   "v3.*",
+	})
+	require.ErrorAs(t, err, &vcs.InvalidRevisionError{})
+}
+
 type testRepository struct {
 	Directory string
 	Repo      *git.Repository
@@ -112,3 +307,33 @@ func initRepository(t *testing.T) *testRepository {
 		Worktree:  worktree,
 	}
 }
+
+func Test_GitRepo_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer proxy.Close()
+
+	_, err := vcs.NewGitRepo(context.Background(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: "http://git.example.invalid/agent-modules.git",
+		Revision:   "HEAD",
+		Transport:  vcs.GitTransportConfig{ProxyURL: proxy.URL},
+	})
+	require.ErrorAs(t, err, &vcs.DownloadFailedError{})
+	require.NotEmpty(t, proxied)
+	require.Contains(t, proxied[0], "http://git.example.invalid/agent-modules.git/info/refs")
+}
+
+func Test_GitRepo_CAFile(t *testing.T) {
+	_, err := vcs.NewGitRepo(context.Background(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: "https://git.example.invalid/agent-modules.git",
+		Revision:   "HEAD",
+		Transport: vcs.GitTransportConfig{
+			TLSConfig: &vcs.GitTLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		},
+	})
+	require.ErrorAs(t, err, &vcs.DownloadFailedError{})
+	require.ErrorContains(t, err, "reading ca_file")
+}
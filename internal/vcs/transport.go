@@ -0,0 +1,69 @@
+package vcs
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// GitTransportConfig configures how repositories are reached over HTTP(S).
+type GitTransportConfig struct {
+	ProxyURL  string        `river:"proxy_url,attr,optional"`
+	TLSConfig *GitTLSConfig `river:"tls_config,block,optional"`
+}
+
+// GitTLSConfig configures TLS for repositories retrieved over HTTPS.
+type GitTLSConfig struct {
+	CA                 string `river:"ca_pem,attr,optional"`
+	CAFile             string `river:"ca_file,attr,optional"`
+	InsecureSkipVerify bool   `river:"insecure_skip_verify,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (t *GitTransportConfig) Validate() error {
+	if t.ProxyURL != "" {
+		u, err := url.Parse(t.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid proxy_url %q: expected SCHEME://HOST[:PORT]", t.ProxyURL)
+		}
+	}
+	return nil
+}
+
+// Validate implements river.Validator.
+func (c *GitTLSConfig) Validate() error {
+	if c.CA != "" && c.CAFile != "" {
+		return fmt.Errorf("at most one of ca_pem and ca_file must be configured")
+	}
+	return nil
+}
+
+// caBundle returns the PEM-encoded certificate authorities which are trusted
+// in addition to the system pool. The CA file is read on every call so that
+// rotated certificates are picked up on the next fetch.
+func (t *GitTransportConfig) caBundle() ([]byte, error) {
+	if t.TLSConfig == nil {
+		return nil, nil
+	}
+	if t.TLSConfig.CAFile != "" {
+		bb, err := os.ReadFile(t.TLSConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading ca_file: %w", err)
+		}
+		return bb, nil
+	}
+	return []byte(t.TLSConfig.CA), nil
+}
+
+func (t *GitTransportConfig) insecureSkipVerify() bool {
+	return t.TLSConfig != nil && t.TLSConfig.InsecureSkipVerify
+}
+
+func (t *GitTransportConfig) proxyOptions() transport.ProxyOptions {
+	return transport.ProxyOptions{URL: t.ProxyURL}
+}
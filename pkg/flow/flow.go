@@ -47,10 +47,13 @@ package flow
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/worker"
 	"github.com/grafana/agent/pkg/flow/logging"
@@ -96,11 +99,77 @@ type Options struct {
 	// loaded config source.
 	OnExportsChange func(exports map[string]any)
 
+	// MaxComponentSeries is the maximum number of series each component may
+	// expose through its metrics registerer. Series over the limit are dropped
+	// when metrics are collected and the number of dropped series is reported
+	// by the agent_component_metrics_truncated_series metric. A value of 0
+	// disables the limit.
+	MaxComponentSeries int
+
 	// List of Services to run with the Flow controller.
 	//
 	// Services are configured when LoadFile is invoked. Services are started
 	// when the Flow controller runs after LoadFile is invoked at least once.
 	Services []service.Service
+
+	// MinStability is the minimum stability level of components which may be
+	// used in the loaded configuration. If unset, components of every
+	// stability level may be used.
+	MinStability featuregate.Stability
+
+	// EvaluationTimeout is the maximum amount of time to wait for a single
+	// component or config block to be evaluated. Components whose evaluation
+	// exceeds the timeout are marked unhealthy. A value of 0 disables the
+	// timeout.
+	EvaluationTimeout time.Duration
+
+	// MaxModuleDepth is the maximum number of modules which may be nested in
+	// each other. Module loaders which would nest modules deeper fail to be
+	// built. A value of 0 disables the limit.
+	MaxModuleDepth int
+
+	// MaxModules is the maximum number of modules, including nested modules,
+	// which may run at the same time. Modules over the limit aren't run, so
+	// the components inside of them don't run either. A value of 0 disables
+	// the limit.
+	MaxModules int
+
+	// AuditLogger, if set, receives a structured entry for every configuration
+	// load of the controller and its modules. See [Flow.LoadSource].
+	AuditLogger log.Logger
+
+	// MaxConcurrentEvaluations is the maximum number of nodes evaluated at the
+	// same time. When a config is loaded, nodes which don't depend on each
+	// other are evaluated concurrently, up to this limit; a value of 0 or 1
+	// evaluates them one at a time. If set, it's also the number of workers
+	// which evaluate the dependants of updated nodes, which otherwise
+	// defaults to the number of CPUs.
+	MaxConcurrentEvaluations int
+
+	// RollbackOnFailure validates every configuration loaded after the first
+	// successful load before applying it. Configurations which fail
+	// validation aren't applied, so the previous configuration keeps running.
+	// The failed load is reported by [Flow.LoadStatus]. Modules validate the
+	// configurations they load the same way.
+	RollbackOnFailure bool
+
+	// MinUpdateInterval is the minimum amount of time between evaluations of
+	// the dependants of a single node after its exports change. Updates of a
+	// node which happen more often are coalesced and held back until the
+	// interval passes. A value of 0 disables the limit.
+	MinUpdateInterval time.Duration
+
+	// AgentID and ClusterName identify the agent running the controller. They
+	// are exposed to the loaded configuration and its modules as read-only
+	// values of the agent variable, together with the hostname of the agent
+	// and the ID of the module being evaluated.
+	AgentID     string
+	ClusterName string
+
+	// ComponentRegistry is used to look up the components referenced in the
+	// loaded configuration. Modules created by the controller use the same
+	// registry. If nil, [DefaultComponentRegistry] is used.
+	ComponentRegistry ComponentRegistry
 }
 
 // Flow is the Flow system.
@@ -118,15 +187,25 @@ type Flow struct {
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
+	loadedHash [sha256.Size]byte // Hash of the most recently loaded source.
+	lastDiff   LoadDiff          // Changes made by the most recent load.
+	loadStatus LoadStatus        // Most recent successful and failed loads.
+
+	persistedHash [sha256.Size]byte // Hash of the module content persisted in the data path.
 }
 
 // New creates a new, unstarted Flow controller. Call Run to run the controller.
 func New(o Options) *Flow {
+	workerPool := worker.NewDefaultWorkerPool()
+	if o.MaxConcurrentEvaluations > 0 {
+		workerPool = worker.NewFixedWorkerPool(o.MaxConcurrentEvaluations, worker.DefaultMaxQueueSize)
+	}
+
 	return newController(controllerOptions{
 		Options:        o,
 		ModuleRegistry: newModuleRegistry(),
 		IsModule:       false, // We are creating a new root controller.
-		WorkerPool:     worker.NewDefaultWorkerPool(),
+		WorkerPool:     workerPool,
 	})
 }
 
@@ -135,11 +214,19 @@ func New(o Options) *Flow {
 type controllerOptions struct {
 	Options
 
-	ComponentRegistry controller.ComponentRegistry // Custom component registry used in tests.
-	ModuleRegistry    *moduleRegistry              // Where to register created modules.
-	IsModule          bool                         // Whether this controller is for a module.
+	ModuleRegistry *moduleRegistry // Where to register created modules.
+	IsModule       bool            // Whether this controller is for a module.
+	Module         *module         // The module this controller runs, if IsModule is true.
 	// A worker pool to evaluate components asynchronously. A default one will be created if this is nil.
 	WorkerPool worker.Pool
+	// Implements the component.exports function. A new one which looks up the
+	// components of this controller and its modules is created if this is nil.
+	ComponentLookup *controller.ComponentLookup
+	// Bus to publish events of components to. A new one is created if this is
+	// nil.
+	Events *controller.EventBus
+	// Whether components are only validated instead of being built.
+	ValidateOnly bool
 }
 
 // newController creates a new, unstarted Flow controller with a specific
@@ -166,12 +253,17 @@ func newController(o controllerOptions) *Flow {
 		workerPool = worker.NewDefaultWorkerPool()
 	}
 
+	componentRegistry := o.ComponentRegistry
+	if componentRegistry == nil {
+		componentRegistry = DefaultComponentRegistry()
+	}
+
 	f := &Flow{
 		log:    log,
 		tracer: tracer,
 		opts:   o,
 
-		updateQueue: controller.NewQueue(),
+		updateQueue: controller.NewRateLimitedQueue(o.MinUpdateInterval),
 		sched:       controller.NewScheduler(),
 
 		modules: o.ModuleRegistry,
@@ -181,6 +273,16 @@ func newController(o controllerOptions) *Flow {
 
 	serviceMap := controller.NewServiceMap(o.Services)
 
+	componentLookup := o.ComponentLookup
+	if componentLookup == nil {
+		componentLookup = controller.NewComponentLookup(f.resolveComponent)
+	}
+
+	if o.Events == nil {
+		o.Events = &controller.EventBus{}
+		f.opts.Events = o.Events
+	}
+
 	f.loader = controller.NewLoader(controller.LoaderOptions{
 		ComponentGlobals: controller.ComponentGlobals{
 			Logger:        log,
@@ -190,12 +292,36 @@ func newController(o controllerOptions) *Flow {
 				// Changed node should be queued for reevaluation.
 				f.updateQueue.Enqueue(&controller.QueuedNode{Node: cn, LastUpdatedTime: time.Now()})
 			},
-			OnExportsChange: o.OnExportsChange,
-			Registerer:      o.Reg,
-			ControllerID:    o.ControllerID,
+			OnComponentBuilt: func(cn controller.ComponentNode) {
+				// Components built outside of a load, such as when a failed
+				// evaluation is retried, aren't running yet. Components aren't run
+				// until a load succeeded once.
+				if !f.loadedOnce.Load() {
+					return
+				}
+				select {
+				case f.loadFinished <- struct{}{}:
+				default:
+					// A refresh is already scheduled
+				}
+			},
+			OnExportsChange:    o.OnExportsChange,
+			Registerer:         o.Reg,
+			ControllerID:       o.ControllerID,
+			MaxComponentSeries: o.MaxComponentSeries,
+			MinStability:       o.MinStability,
+			EvaluationTimeout:  o.EvaluationTimeout,
+			AgentID:            o.AgentID,
+			ClusterName:        o.ClusterName,
+			ComponentLookup:    componentLookup,
+			Events:             o.Events,
+			ValidateOnly:       o.ValidateOnly,
+
+			MaxConcurrentEvaluations: o.MaxConcurrentEvaluations,
+
 			NewModuleController: func(id string) controller.ModuleController {
 				return newModuleController(&moduleControllerOptions{
-					ComponentRegistry: o.ComponentRegistry,
+					ComponentRegistry: componentRegistry,
 					ModuleRegistry:    o.ModuleRegistry,
 					Logger:            log,
 					Tracer:            tracer,
@@ -204,6 +330,23 @@ func newController(o controllerOptions) *Flow {
 					ID:                id,
 					ServiceMap:        serviceMap,
 					WorkerPool:        workerPool,
+					Owner:             o.Module,
+
+					MaxComponentSeries: o.MaxComponentSeries,
+					MinStability:       o.MinStability,
+					EvaluationTimeout:  o.EvaluationTimeout,
+					MaxModuleDepth:     o.MaxModuleDepth,
+					MaxModules:         o.MaxModules,
+					AuditLogger:        o.AuditLogger,
+					AgentID:            o.AgentID,
+					ClusterName:        o.ClusterName,
+					ComponentLookup:    componentLookup,
+					Events:             o.Events,
+					ValidateOnly:       o.ValidateOnly,
+
+					MaxConcurrentEvaluations: o.MaxConcurrentEvaluations,
+					MinUpdateInterval:        o.MinUpdateInterval,
+					RollbackOnFailure:        o.RollbackOnFailure,
 				})
 			},
 			GetServiceData: func(name string) (interface{}, error) {
@@ -217,8 +360,9 @@ func newController(o controllerOptions) *Flow {
 
 		Services:          o.Services,
 		Host:              f,
-		ComponentRegistry: o.ComponentRegistry,
+		ComponentRegistry: componentRegistry,
 		WorkerPool:        workerPool,
+		UpdateQueue:       f.updateQueue,
 	})
 
 	return f
@@ -278,10 +422,36 @@ func (f *Flow) Run(ctx context.Context) {
 // The controller will only start running components after Load is called once
 // without any configuration errors.
 func (f *Flow) LoadSource(source *Source, args map[string]any) error {
+	return f.loadSource(context.Background(), source, args)
+}
+
+// loadSource implements LoadSource. The span in ctx, if any, is the parent
+// of the spans created while source is loaded.
+func (f *Flow) loadSource(ctx context.Context, source *Source, args map[string]any) error {
 	f.loadMut.Lock()
 	defer f.loadMut.Unlock()
 
-	diags := f.loader.Apply(args, source.components, source.configBlocks)
+	if err := f.rejectLoad(source, args); err != nil {
+		f.auditLoad(source, LoadDiff{}, err)
+		return err
+	}
+
+	prevComponents := snapshotComponents(f.loader.Components())
+	diags := f.loader.Apply(ctx, args, source.components, source.configBlocks)
+	f.recordLoad(source, diags.ErrorOrNil(), false)
+
+	diff := diffComponents(prevComponents, f.loader.Components())
+	diff.Time = time.Now()
+	f.lastDiff = diff
+	f.logLoadDiff(diff)
+	f.auditLoad(source, diff, diags.ErrorOrNil())
+	f.loadedHash = source.SHA256()
+	if f.opts.IsModule && !diags.HasErrors() {
+		// Only content which loaded without errors is persisted, so that the
+		// persisted content is always content the module ran successfully.
+		f.persistModuleContent(source)
+	}
+
 	if !f.loadedOnce.Load() && diags.HasErrors() {
 		// The first call to Load should not run any components if there were
 		// errors in the configuration file.
@@ -0,0 +1,193 @@
+package flow
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+	"github.com/grafana/river/printer"
+)
+
+// LoadDiff describes the changes a configuration load made to the components
+// of a controller.
+type LoadDiff struct {
+	// Time is when the load finished.
+	Time time.Time `json:"time"`
+
+	Added   []string `json:"added"`   // IDs of components added by the load.
+	Removed []string `json:"removed"` // IDs of components removed by the load.
+	Changed []string `json:"changed"` // IDs of components whose block changed.
+
+	// Modules holds the IDs of the added, removed, or changed module loaders
+	// and other components which run modules.
+	Modules []string `json:"modules"`
+}
+
+// Empty returns true if the load didn't add, remove, or change components.
+func (d LoadDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// LastLoadDiff returns the changes made to the components of the controller
+// by the most recent call to LoadSource. Changes to the components inside of
+// modules aren't included.
+func (f *Flow) LastLoadDiff() LoadDiff {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+	return f.lastDiff
+}
+
+// componentSnapshot is the state of a component before a load, used to
+// compute the diff of the load.
+type componentSnapshot struct {
+	block      string // River text of the component's block.
+	runsModule bool
+}
+
+// snapshotComponents returns the state of components by ID.
+func snapshotComponents(components []controller.ComponentNode) map[string]componentSnapshot {
+	snapshots := make(map[string]componentSnapshot, len(components))
+	for _, cn := range components {
+		snapshots[cn.NodeID()] = componentSnapshot{
+			block:      blockText(cn),
+			runsModule: runsModule(cn),
+		}
+	}
+	return snapshots
+}
+
+// diffComponents returns the diff between the components described by prev
+// and next. The IDs in the diff are sorted.
+func diffComponents(prev map[string]componentSnapshot, next []controller.ComponentNode) LoadDiff {
+	var (
+		diff = LoadDiff{
+			Added:   []string{},
+			Removed: []string{},
+			Changed: []string{},
+			Modules: []string{},
+		}
+		visited = make(map[string]struct{}, len(next))
+	)
+
+	for _, cn := range next {
+		id := cn.NodeID()
+		visited[id] = struct{}{}
+
+		snapshot, ok := prev[id]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, id)
+		case snapshot.block != blockText(cn):
+			diff.Changed = append(diff.Changed, id)
+		default:
+			continue
+		}
+		if runsModule(cn) || snapshot.runsModule {
+			diff.Modules = append(diff.Modules, id)
+		}
+	}
+	for id, snapshot := range prev {
+		if _, ok := visited[id]; ok {
+			continue
+		}
+		diff.Removed = append(diff.Removed, id)
+		if snapshot.runsModule {
+			diff.Modules = append(diff.Modules, id)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Modules)
+	return diff
+}
+
+// blockText returns the River text of the block of cn, ignoring its
+// position in the source.
+func blockText(cn controller.ComponentNode) string {
+	block := cn.Block()
+	if block == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if err := printer.Fprint(&sb, block); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// runsModule returns true if cn is a module loader or currently runs at
+// least one module. Module loaders are checked by name since the modules they
+// create only run after the load.
+func runsModule(cn controller.ComponentNode) bool {
+	if strings.HasPrefix(cn.ComponentName(), "module.") {
+		return true
+	}
+	builtin, ok := cn.(*controller.BuiltinComponentNode)
+	return ok && len(builtin.ModuleIDs()) > 0
+}
+
+// logLoadDiff logs the changes made by a configuration load, if any.
+func (f *Flow) logLoadDiff(diff LoadDiff) {
+	if diff.Empty() {
+		return
+	}
+	level.Info(f.log).Log(
+		"msg", "configuration changed",
+		"components_added", strings.Join(diff.Added, ","),
+		"components_removed", strings.Join(diff.Removed, ","),
+		"components_changed", strings.Join(diff.Changed, ","),
+		"modules_touched", strings.Join(diff.Modules, ","),
+	)
+}
+
+// auditLoad writes an audit log entry describing a configuration load. The
+// entry contains the hashes of the previous and new source and the diff of
+// the components changed by the load. f.loadMut must be held when calling
+// auditLoad.
+func (f *Flow) auditLoad(source *Source, diff LoadDiff, loadErr error) {
+	logger := f.opts.AuditLogger
+	if logger == nil {
+		return
+	}
+
+	var (
+		prevHash = f.loadedHash
+		newHash  = source.SHA256()
+	)
+
+	status := "success"
+	if loadErr != nil {
+		status = "error"
+	}
+
+	keyvals := []interface{}{
+		"event", "config_load",
+		"controller_id", f.opts.ControllerID,
+		"status", status,
+		"changed", prevHash != newHash,
+		"old_sha256", hashString(prevHash),
+		"new_sha256", hex.EncodeToString(newHash[:]),
+		"components_added", strings.Join(diff.Added, ","),
+		"components_removed", strings.Join(diff.Removed, ","),
+		"components_changed", strings.Join(diff.Changed, ","),
+		"modules_touched", strings.Join(diff.Modules, ","),
+	}
+	if loadErr != nil {
+		keyvals = append(keyvals, "err", loadErr)
+	}
+	_ = logger.Log(keyvals...)
+}
+
+// hashString returns the hex representation of hash, or an empty string if
+// hash is unset.
+func hashString(hash [32]byte) string {
+	if hash == [32]byte{} {
+		return ""
+	}
+	return hex.EncodeToString(hash[:])
+}
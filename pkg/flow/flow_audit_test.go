@@ -0,0 +1,137 @@
+package flow_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/grafana/agent/pkg/flow/internal/testcomponents" // Include test components
+)
+
+func TestAuditLog(t *testing.T) {
+	logger, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	ctrl := flow.New(flow.Options{
+		Logger:      logger,
+		DataPath:    t.TempDir(),
+		AuditLogger: log.NewJSONLogger(log.NewSyncWriter(&buf)),
+	})
+
+	// Run the controller so its resources are released when the test exits.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	load := func(content string) {
+		f, err := flow.ParseSource(t.Name(), []byte(content))
+		require.NoError(t, err)
+		_ = ctrl.LoadSource(f, nil)
+	}
+
+	load(`testcomponents.passthrough "a" { input = "a" }`)
+	load(`testcomponents.passthrough "b" { input = "b" }`)
+	load(`testcomponents.passthrough "b" { input = "b" }`)
+	load(`testcomponents.passthrough "b" { input = testcomponents.passthrough.missing.output }`)
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 4)
+
+	require.Equal(t, "config_load", entries[0]["event"])
+	require.Equal(t, "success", entries[0]["status"])
+	require.Equal(t, "", entries[0]["old_sha256"])
+	require.Equal(t, "testcomponents.passthrough.a", entries[0]["components_added"])
+
+	require.Equal(t, entries[0]["new_sha256"], entries[1]["old_sha256"])
+	require.Equal(t, true, entries[1]["changed"])
+	require.Equal(t, "testcomponents.passthrough.b", entries[1]["components_added"])
+	require.Equal(t, "testcomponents.passthrough.a", entries[1]["components_removed"])
+
+	require.Equal(t, false, entries[2]["changed"])
+	require.Equal(t, "", entries[2]["components_added"])
+
+	require.Equal(t, "error", entries[3]["status"])
+	require.Contains(t, entries[3]["err"], "testcomponents.passthrough.missing")
+	require.Equal(t, "testcomponents.passthrough.b", entries[3]["components_changed"])
+}
+
+func TestLastLoadDiff(t *testing.T) {
+	logger, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+
+	ctrl := flow.New(flow.Options{
+		Logger:   logger,
+		DataPath: t.TempDir(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	load := func(content string) {
+		f, err := flow.ParseSource(t.Name(), []byte(content))
+		require.NoError(t, err)
+		require.NoError(t, ctrl.LoadSource(f, nil))
+	}
+
+	load(`
+		testcomponents.passthrough "a" { input = "a" }
+		testcomponents.passthrough "b" { input = "b" }
+		module.string "m" { content = "" }
+	`)
+	diff := ctrl.LastLoadDiff()
+	require.Equal(t, []string{"module.string.m", "testcomponents.passthrough.a", "testcomponents.passthrough.b"}, diff.Added)
+	require.Equal(t, []string{"module.string.m"}, diff.Modules)
+
+	// Moving blocks around or changing whitespace doesn't change them.
+	load(`
+		module.string "m" {
+			content = "testcomponents.passthrough \"inner\" { input = \"x\" }"
+		}
+		testcomponents.passthrough "b" { input   =   "b" }
+		testcomponents.passthrough "c" { input = "c" }
+	`)
+	diff = ctrl.LastLoadDiff()
+	require.Equal(t, []string{"testcomponents.passthrough.c"}, diff.Added)
+	require.Equal(t, []string{"testcomponents.passthrough.a"}, diff.Removed)
+	require.Equal(t, []string{"module.string.m"}, diff.Changed)
+	require.Equal(t, []string{"module.string.m"}, diff.Modules)
+	require.False(t, diff.Time.IsZero())
+
+	load(`
+		module.string "m" {
+			content = "testcomponents.passthrough \"inner\" { input = \"x\" }"
+		}
+		testcomponents.passthrough "b" { input = "b" }
+		testcomponents.passthrough "c" { input = "c" }
+	`)
+	require.True(t, ctrl.LastLoadDiff().Empty())
+}
@@ -2,6 +2,8 @@ package flow
 
 import (
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
@@ -37,6 +39,97 @@ func (f *Flow) GetComponent(id component.ID, opts component.InfoOptions) (*compo
 	return f.getComponentDetail(cn, graph, opts), nil
 }
 
+// resolveComponent returns the loader which manages the component with the
+// given global ID, such as "module.file.a/prometheus.scrape.default", along
+// with the global ID normalized to include the ID of the controller. The
+// returned loader is nil if the module of the component isn't running.
+//
+// resolveComponent doesn't lock the controller, since it's called while nodes
+// are being evaluated.
+func (f *Flow) resolveComponent(id string) (*controller.Loader, string) {
+	moduleID, localID := path.Split(id)
+	moduleID = strings.TrimSuffix(moduleID, "/")
+
+	if moduleID == "" || moduleID == f.opts.ControllerID {
+		return f.loader, path.Join(f.opts.ControllerID, localID)
+	}
+
+	if f.opts.ControllerID != "" && !strings.HasPrefix(moduleID, f.opts.ControllerID+"/") {
+		moduleID = path.Join(f.opts.ControllerID, moduleID)
+	}
+	globalID := path.Join(moduleID, localID)
+
+	mod, ok := f.modules.Get(moduleID)
+	if !ok {
+		return nil, globalID
+	}
+	return mod.f.loader, globalID
+}
+
+// RefreshComponents requests every component which loads content from an
+// external source, including components running inside of modules, to reload
+// its content immediately. It returns the number of components which were
+// asked to refresh.
+func (f *Flow) RefreshComponents() int {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	refreshed := refreshComponents(f.loader.Components())
+	for _, mod := range f.modules.List() {
+		refreshed += refreshComponents(mod.f.loader.Components())
+	}
+	return refreshed
+}
+
+// RefreshComponent requests the component with the given ID, which may be
+// running inside of a module, to reload its content from its external source
+// immediately. component.ErrComponentNotFound is returned if the component
+// doesn't exist.
+func (f *Flow) RefreshComponent(id component.ID) error {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	if id.ModuleID != "" {
+		mod, ok := f.modules.Get(id.ModuleID)
+		if !ok {
+			return component.ErrComponentNotFound
+		}
+		return mod.f.RefreshComponent(component.ID{LocalID: id.LocalID})
+	}
+
+	for _, cn := range f.loader.Components() {
+		if cn.NodeID() != id.LocalID {
+			continue
+		}
+		builtin, ok := cn.(*controller.BuiltinComponentNode)
+		if !ok {
+			break
+		}
+		rc, ok := builtin.Component().(component.RefreshableComponent)
+		if !ok {
+			return fmt.Errorf("component %q doesn't load content from an external source", id.LocalID)
+		}
+		rc.Refresh()
+		return nil
+	}
+	return component.ErrComponentNotFound
+}
+
+func refreshComponents(components []controller.ComponentNode) int {
+	var refreshed int
+	for _, cn := range components {
+		builtin, ok := cn.(*controller.BuiltinComponentNode)
+		if !ok {
+			continue
+		}
+		if rc, ok := builtin.Component().(component.RefreshableComponent); ok {
+			rc.Refresh()
+			refreshed++
+		}
+	}
+	return refreshed
+}
+
 // ListComponents implements [component.Provider].
 func (f *Flow) ListComponents(moduleID string, opts component.InfoOptions) ([]*component.Info, error) {
 	f.loadMut.RLock()
@@ -125,6 +218,15 @@ func (f *Flow) getComponentDetail(cn controller.ComponentNode, graph *dag.Graph,
 		if opts.GetDebugInfo {
 			componentInfo.DebugInfo = builtinComponent.DebugInfo()
 		}
+		if opts.GetHealth {
+			componentInfo.EvaluationErrors = component.ErrorDetails(builtinComponent.EvaluationError())
+			for i := range componentInfo.EvaluationErrors {
+				componentInfo.EvaluationErrors[i].NodeID = componentInfo.ID.String()
+			}
+		}
+		if opts.GetHealthHistory {
+			componentInfo.HealthHistory = builtinComponent.HealthHistory()
+		}
 	}
 	return componentInfo
 }
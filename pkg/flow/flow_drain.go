@@ -0,0 +1,131 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+)
+
+// Drain asks every component which implements [component.DrainableComponent],
+// including components running inside of modules, to stop accepting new data.
+// Drain blocks until all components are
+// drained or ctx is canceled, returning the errors of all components which
+// failed to drain.
+//
+// Components are drained in the order data flows through them: a component
+// is only drained after all components which reference it, so sources stop
+// before the components they send data to. Modules are
+// drained concurrently with the root controller.
+//
+// Drain doesn't stop the controller; components keep running until the
+// context passed to Run is canceled.
+func (f *Flow) Drain(ctx context.Context) error {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	graphs := []*dag.Graph{f.loader.OriginalGraph()}
+	for _, mod := range f.modules.List() {
+		graphs = append(graphs, mod.f.loader.OriginalGraph())
+	}
+
+	var (
+		wg     sync.WaitGroup
+		errMut sync.Mutex
+		errs   []error
+	)
+	for _, g := range graphs {
+		wg.Add(1)
+		go func(g *dag.Graph) {
+			defer wg.Done()
+			if err := f.drainGraph(ctx, g); err != nil {
+				errMut.Lock()
+				errs = append(errs, err)
+				errMut.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (f *Flow) drainGraph(ctx context.Context, g *dag.Graph) error {
+	var errs []error
+
+	for _, step := range drainOrder(g) {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		var (
+			wg     sync.WaitGroup
+			errMut sync.Mutex
+		)
+		for _, n := range step {
+			builtin, ok := n.(*controller.BuiltinComponentNode)
+			if !ok {
+				continue
+			}
+			dc, ok := builtin.Component().(component.DrainableComponent)
+			if !ok {
+				continue
+			}
+
+			wg.Add(1)
+			go func(id string, dc component.DrainableComponent) {
+				defer wg.Done()
+
+				level.Info(f.log).Log("msg", "draining component", "id", id)
+				if err := dc.Drain(ctx); err != nil {
+					errMut.Lock()
+					errs = append(errs, fmt.Errorf("draining %s: %w", id, err))
+					errMut.Unlock()
+				}
+			}(builtin.NodeID(), dc)
+		}
+		wg.Wait()
+	}
+
+	return errors.Join(errs...)
+}
+
+// drainOrder groups the nodes of g into steps. Every node is placed in the
+// step after the last of the nodes which reference it, so nodes which aren't
+// referenced by any other node, such as scrapers and receivers, are drained
+// first.
+func drainOrder(g *dag.Graph) [][]dag.Node {
+	var (
+		steps  [][]dag.Node
+		stepOf = make(map[dag.Node]int)
+	)
+
+	var visit func(n dag.Node) int
+	visit = func(n dag.Node) int {
+		if step, ok := stepOf[n]; ok {
+			return step
+		}
+		step := 0
+		for _, dep := range g.Dependants(n) {
+			if depStep := visit(dep) + 1; depStep > step {
+				step = depStep
+			}
+		}
+		stepOf[n] = step
+		return step
+	}
+
+	for _, n := range g.Nodes() {
+		step := visit(n)
+		for len(steps) <= step {
+			steps = append(steps, nil)
+		}
+		steps[step] = append(steps[step], n)
+	}
+	return steps
+}
@@ -0,0 +1,50 @@
+package flow
+
+import (
+	"sort"
+	"testing"
+
+	_ "github.com/grafana/agent/pkg/flow/internal/testcomponents" // Include test components
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainOrder(t *testing.T) {
+	source, err := ParseSource(t.Name(), []byte(`
+		testcomponents.passthrough "a" {
+			input = "a"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "d" {
+			input = testcomponents.passthrough.b.output
+		}
+	`))
+	require.NoError(t, err)
+
+	plan, diags := PlanEvaluation(source, PlanOptions{})
+	require.NoError(t, diags.ErrorOrNil())
+
+	var steps [][]string
+	for _, step := range drainOrder(plan.graph) {
+		ids := make([]string, 0, len(step))
+		for _, n := range step {
+			ids = append(ids, n.NodeID())
+		}
+		sort.Strings(ids)
+		steps = append(steps, ids)
+	}
+
+	// Nodes are drained after every node which references them.
+	require.Equal(t, [][]string{
+		{"logging", "testcomponents.passthrough.c", "testcomponents.passthrough.d", "tracing"},
+		{"testcomponents.passthrough.b"},
+		{"testcomponents.passthrough.a"},
+	}, steps)
+}
@@ -0,0 +1,19 @@
+package flow
+
+import "github.com/grafana/agent/pkg/flow/internal/controller"
+
+// Event describes a change of the state of a component, such as a component
+// starting, stopping, being reloaded with new arguments, or becoming
+// unhealthy.
+type Event = controller.Event
+
+// EventType is the kind of change described by an Event.
+type EventType = controller.EventType
+
+// SubscribeEvents returns a channel receiving the events of the components
+// of the controller and its modules, buffering up to buffer events. Events
+// are dropped instead of blocking the controller if the buffer is full. The
+// returned func unsubscribes and must be called once the subscriber is done.
+func (f *Flow) SubscribeEvents(buffer int) (<-chan Event, func()) {
+	return f.opts.Events.Subscribe(buffer)
+}
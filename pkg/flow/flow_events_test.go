@@ -0,0 +1,62 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	load := func(t *testing.T, ctrl *Flow, input string) {
+		t.Helper()
+		f, err := ParseSource(t.Name(), []byte(`
+			testcomponents.passthrough "a" {
+				input = "`+input+`"
+			}
+		`))
+		require.NoError(t, err)
+		require.NoError(t, ctrl.LoadSource(f, nil))
+	}
+	next := func(t *testing.T, events <-chan Event) Event {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for event")
+			return Event{}
+		}
+	}
+
+	ctrl := New(testOptions(t))
+	events, unsubscribe := ctrl.SubscribeEvents(10)
+	defer unsubscribe()
+
+	load(t, ctrl, "a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+
+	e := next(t, events)
+	require.Equal(t, controller.EventStarted, e.Type)
+	require.Equal(t, "testcomponents.passthrough.a", e.ComponentID)
+
+	load(t, ctrl, "b")
+	require.Equal(t, controller.EventReloaded, next(t, events).Type)
+
+	// Loading unchanged arguments doesn't reload the component.
+	load(t, ctrl, "b")
+
+	cancel()
+	<-done
+	require.Equal(t, controller.EventStopped, next(t, events).Type)
+}
@@ -0,0 +1,175 @@
+package flow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/service"
+	"github.com/grafana/river/diag"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// PlanOptions configures PlanEvaluation.
+type PlanOptions struct {
+	// Services which may be configured by blocks in the source. Services are
+	// only used to recognize their blocks; they are never run.
+	Services []service.Service
+
+	// MinStability is the minimum stability level of components which may be
+	// used in the source.
+	MinStability featuregate.Stability
+}
+
+// EvaluationPlan describes the order in which the Flow controller evaluates
+// the nodes of a configuration.
+type EvaluationPlan struct {
+	graph *dag.Graph
+	steps [][]string
+	step  map[string]int
+}
+
+// PlanEvaluation builds the graph of nodes described by source and returns
+// the order in which they are evaluated. Components are neither built nor
+// run, and modules are not loaded, so PlanEvaluation can be used without a
+// running Flow controller.
+func PlanEvaluation(source *Source, opts PlanOptions) (*EvaluationPlan, diag.Diagnostics) {
+	logger, err := logging.New(io.Discard, logging.DefaultOptions)
+	if err != nil {
+		return nil, diag.Diagnostics{{Severity: diag.SeverityLevelError, Message: err.Error()}}
+	}
+
+	l := controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:            logger,
+			TraceProvider:     noop.NewTracerProvider(),
+			OnBlockNodeUpdate: func(controller.BlockNode) {},
+			MinStability:      opts.MinStability,
+			NewModuleController: func(string) controller.ModuleController {
+				return nil
+			},
+			GetServiceData: func(name string) (interface{}, error) {
+				return nil, fmt.Errorf("service %q is not available when planning an evaluation", name)
+			},
+		},
+		Services: opts.Services,
+	})
+
+	g, diags := l.BuildGraph(nil, source.components, source.configBlocks)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return newEvaluationPlan(g), diags
+}
+
+func newEvaluationPlan(g *dag.Graph) *EvaluationPlan {
+	p := &EvaluationPlan{
+		graph: g,
+		step:  make(map[string]int),
+	}
+
+	// A node is evaluated in the step after the last of its dependencies.
+	var stepOf func(n dag.Node) int
+	stepOf = func(n dag.Node) int {
+		if step, ok := p.step[n.NodeID()]; ok {
+			return step
+		}
+		step := 0
+		for _, dep := range g.Dependencies(n) {
+			if depStep := stepOf(dep) + 1; depStep > step {
+				step = depStep
+			}
+		}
+		p.step[n.NodeID()] = step
+		return step
+	}
+
+	for _, n := range g.Nodes() {
+		step := stepOf(n)
+		for len(p.steps) <= step {
+			p.steps = append(p.steps, nil)
+		}
+		p.steps[step] = append(p.steps[step], n.NodeID())
+	}
+	for _, ids := range p.steps {
+		sort.Strings(ids)
+	}
+
+	return p
+}
+
+// Steps returns the IDs of all nodes grouped by the step in which they are
+// evaluated. All nodes of a step are evaluated after the nodes of the
+// previous steps. Nodes within the same step don't depend on each other, so
+// their relative order isn't defined and they may be evaluated concurrently.
+func (p *EvaluationPlan) Steps() [][]string {
+	return p.steps
+}
+
+// Dependencies returns the IDs of the nodes which the node with the given ID
+// references.
+func (p *EvaluationPlan) Dependencies(id string) ([]string, error) {
+	n := p.graph.GetByID(id)
+	if n == nil {
+		return nil, fmt.Errorf("node %q does not exist", id)
+	}
+	return p.sortedIDs(p.graph.Dependencies(n)), nil
+}
+
+// Dependants returns the nodes which are evaluated when the node with the
+// given ID changes its exports. Direct dependants are always evaluated.
+// Indirect dependants are only evaluated if a node between them and the
+// changed node changes its exports as well. Both lists are ordered by the
+// step in which the nodes are evaluated.
+func (p *EvaluationPlan) Dependants(id string) (direct, indirect []string, err error) {
+	n := p.graph.GetByID(id)
+	if n == nil {
+		return nil, nil, fmt.Errorf("node %q does not exist", id)
+	}
+
+	directNodes := p.graph.Dependants(n)
+	visited := map[dag.Node]struct{}{n: {}}
+	for _, dep := range directNodes {
+		visited[dep] = struct{}{}
+	}
+
+	var (
+		indirectNodes []dag.Node
+		queue         = append([]dag.Node(nil), directNodes...)
+	)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range p.graph.Dependants(next) {
+			if _, ok := visited[dep]; ok {
+				continue
+			}
+			visited[dep] = struct{}{}
+			indirectNodes = append(indirectNodes, dep)
+			queue = append(queue, dep)
+		}
+	}
+
+	return p.sortedIDs(directNodes), p.sortedIDs(indirectNodes), nil
+}
+
+// sortedIDs returns the IDs of nodes sorted by evaluation step and then by
+// ID.
+func (p *EvaluationPlan) sortedIDs(nodes []dag.Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.NodeID())
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if p.step[ids[i]] != p.step[ids[j]] {
+			return p.step[ids[i]] < p.step[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
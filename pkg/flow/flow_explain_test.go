@@ -0,0 +1,62 @@
+package flow
+
+import (
+	"testing"
+
+	_ "github.com/grafana/agent/pkg/flow/internal/testcomponents" // Include test components
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanEvaluation(t *testing.T) {
+	source, err := ParseSource(t.Name(), []byte(`
+		testcomponents.passthrough "a" {
+			input = "a"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "d" {
+			input = testcomponents.passthrough.b.output + testcomponents.passthrough.c.output
+		}
+	`))
+	require.NoError(t, err)
+
+	plan, diags := PlanEvaluation(source, PlanOptions{})
+	require.NoError(t, diags.ErrorOrNil())
+
+	require.Equal(t, [][]string{
+		{"logging", "testcomponents.passthrough.a", "tracing"},
+		{"testcomponents.passthrough.b", "testcomponents.passthrough.c"},
+		{"testcomponents.passthrough.d"},
+	}, plan.Steps())
+
+	direct, indirect, err := plan.Dependants("testcomponents.passthrough.a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"testcomponents.passthrough.b", "testcomponents.passthrough.c"}, direct)
+	require.Equal(t, []string{"testcomponents.passthrough.d"}, indirect)
+
+	deps, err := plan.Dependencies("testcomponents.passthrough.d")
+	require.NoError(t, err)
+	require.Equal(t, []string{"testcomponents.passthrough.b", "testcomponents.passthrough.c"}, deps)
+
+	_, _, err = plan.Dependants("testcomponents.passthrough.missing")
+	require.EqualError(t, err, `node "testcomponents.passthrough.missing" does not exist`)
+}
+
+func TestPlanEvaluation_Errors(t *testing.T) {
+	source, err := ParseSource(t.Name(), []byte(`
+		testcomponents.passthrough "a" {
+			input = testcomponents.passthrough.missing.output
+		}
+	`))
+	require.NoError(t, err)
+
+	_, diags := PlanEvaluation(source, PlanOptions{})
+	require.ErrorContains(t, diags.ErrorOrNil(), `component "testcomponents.passthrough.missing.output" does not exist`)
+}
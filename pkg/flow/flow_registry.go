@@ -0,0 +1,37 @@
+package flow
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+)
+
+// ComponentRegistry is a collection of components which can be used by a
+// Flow controller. Programs embedding a Flow controller can provide a custom
+// ComponentRegistry to control which components may be used in the loaded
+// configuration.
+type ComponentRegistry interface {
+	// Get looks up a component by name.
+	Get(name string) (component.Registration, bool)
+}
+
+// DefaultComponentRegistry returns the ComponentRegistry of all components
+// registered through [component.Register].
+func DefaultComponentRegistry() ComponentRegistry {
+	return controller.DefaultComponentRegistry{}
+}
+
+// NewComponentRegistry returns a ComponentRegistry containing only the
+// provided registrations. An error is returned if two registrations use the
+// same name.
+func NewComponentRegistry(regs ...component.Registration) (ComponentRegistry, error) {
+	m := make(controller.RegistryMap, len(regs))
+	for _, reg := range regs {
+		if _, exist := m[reg.Name]; exist {
+			return nil, fmt.Errorf("component name %q already registered", reg.Name)
+		}
+		m[reg.Name] = reg
+	}
+	return m, nil
+}
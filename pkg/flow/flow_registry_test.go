@@ -0,0 +1,63 @@
+package flow_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomComponentRegistry(t *testing.T) {
+	registry, err := flow.NewComponentRegistry(component.Registration{
+		Name: "custom.fake",
+		Args: struct{}{},
+		Build: func(component.Options, component.Arguments) (component.Component, error) {
+			return &testcomponents.Fake{}, nil
+		},
+	})
+	require.NoError(t, err)
+
+	logger, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+
+	ctrl := flow.New(flow.Options{
+		Logger:            logger,
+		DataPath:          t.TempDir(),
+		ComponentRegistry: registry,
+	})
+
+	// Run the controller so its resources are released when the test exits.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Components in the custom registry can be used.
+	f, err := flow.ParseSource(t.Name(), []byte(`custom.fake "example" {}`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	// Globally registered components are not available.
+	f, err = flow.ParseSource(t.Name(), []byte(`testcomponents.tick "example" {
+		frequency = "1s"
+	}`))
+	require.NoError(t, err)
+	require.ErrorContains(t, ctrl.LoadSource(f, nil), `Unrecognized component name "testcomponents.tick"`)
+}
+
+func TestNewComponentRegistry_Duplicate(t *testing.T) {
+	reg := component.Registration{Name: "custom.fake", Args: struct{}{}}
+	_, err := flow.NewComponentRegistry(reg, reg)
+	require.EqualError(t, err, `component name "custom.fake" already registered`)
+}
@@ -0,0 +1,90 @@
+package flow
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+)
+
+// LoadStatus describes the most recent successful and failed configuration
+// loads of a controller.
+type LoadStatus struct {
+	// LastGood is the most recent load which succeeded, or nil if no load
+	// succeeded yet. The controller runs the configuration of LastGood unless
+	// a failed load was applied after it.
+	LastGood *LoadAttempt `json:"lastGood"`
+
+	// LastFailure is the most recent load which failed, or nil if no load
+	// failed since the last successful one.
+	LastFailure *LoadAttempt `json:"lastFailure"`
+}
+
+// LoadAttempt describes a single configuration load.
+type LoadAttempt struct {
+	Time   time.Time `json:"time"`
+	SHA256 string    `json:"sha256"`
+
+	// RolledBack is true if the source failed validation and wasn't applied,
+	// so that the configuration of the previous successful load kept running.
+	RolledBack bool `json:"rolledBack"`
+
+	// Errors holds the errors of a failed load.
+	Errors []component.ErrorDetail `json:"errors,omitempty"`
+}
+
+// LoadStatus returns the most recent successful and failed configuration
+// loads of the controller. Loads of modules aren't included.
+func (f *Flow) LoadStatus() LoadStatus {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+	return f.loadStatus
+}
+
+// rejectLoad validates source before it's applied, if the controller is
+// configured to roll back failed loads and has a successful load to roll
+// back to. rejectLoad returns the validation error if source must not be
+// applied. f.loadMut must be held when calling rejectLoad.
+func (f *Flow) rejectLoad(source *Source, args map[string]any) error {
+	if !f.opts.RollbackOnFailure || f.loadStatus.LastGood == nil {
+		return nil
+	}
+
+	err := f.ValidateSource(source, args)
+	if err == nil {
+		return nil
+	}
+
+	level.Warn(f.log).Log(
+		"msg", "configuration failed validation; keeping the previous configuration running",
+		"sha256", sourceHash(source),
+		"last_good_sha256", f.loadStatus.LastGood.SHA256,
+		"err", err,
+	)
+	f.recordLoad(source, err, true)
+	return err
+}
+
+// recordLoad records the outcome of loading source. f.loadMut must be held
+// when calling recordLoad.
+func (f *Flow) recordLoad(source *Source, err error, rolledBack bool) {
+	attempt := &LoadAttempt{
+		Time:       time.Now(),
+		SHA256:     sourceHash(source),
+		RolledBack: rolledBack,
+		Errors:     component.ErrorDetails(err),
+	}
+
+	if err != nil {
+		f.loadStatus.LastFailure = attempt
+		return
+	}
+	f.loadStatus.LastGood = attempt
+	f.loadStatus.LastFailure = nil
+}
+
+func sourceHash(source *Source) string {
+	hash := source.SHA256()
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,92 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackOnFailure(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	const (
+		good = `testcomponents.passthrough "a" { input = "a" }`
+		bad  = `
+			testcomponents.passthrough "b" { input = "b" }
+			testcomponents.tick "t" { frequency = "not a duration" }
+		`
+	)
+
+	load := func(ctrl *Flow, content string) error {
+		source, err := ParseSource(t.Name(), []byte(content))
+		require.NoError(t, err)
+		return ctrl.LoadSource(source, nil)
+	}
+	componentIDs := func(ctrl *Flow) []string {
+		var ids []string
+		for _, cn := range ctrl.loader.Components() {
+			ids = append(ids, cn.NodeID())
+		}
+		return ids
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		opts := testOptions(t)
+		opts.RollbackOnFailure = true
+		ctrl := New(opts)
+		defer cleanUpController(ctrl)
+
+		require.NoError(t, load(ctrl, good))
+		status := ctrl.LoadStatus()
+		require.NotNil(t, status.LastGood)
+		require.Nil(t, status.LastFailure)
+
+		err := load(ctrl, bad)
+		require.ErrorContains(t, err, `invalid duration "not a duration"`)
+		require.Equal(t, []string{"testcomponents.passthrough.a"}, componentIDs(ctrl))
+
+		status = ctrl.LoadStatus()
+		require.NotNil(t, status.LastFailure)
+		require.True(t, status.LastFailure.RolledBack)
+		require.NotEmpty(t, status.LastFailure.Errors)
+		require.NotEqual(t, status.LastGood.SHA256, status.LastFailure.SHA256)
+
+		// A successful load clears the failure.
+		require.NoError(t, load(ctrl, good))
+		require.Nil(t, ctrl.LoadStatus().LastFailure)
+	})
+
+	t.Run("Exports", func(t *testing.T) {
+		opts := testOptions(t)
+		opts.RollbackOnFailure = true
+		ctrl := New(opts)
+		defer cleanUpController(ctrl)
+
+		require.NoError(t, load(ctrl, good))
+
+		// Arguments which reference the exports of other components, directly
+		// or in a module, are applied.
+		const inner = `
+			testcomponents.passthrough "p" { input = "1s" }
+			testcomponents.tick "t" { frequency = testcomponents.passthrough.p.output }
+		`
+		require.NoError(t, load(ctrl, inner+`
+			module.string "m" { content = `+"`"+inner+"`"+` }
+		`))
+		require.ElementsMatch(t, []string{"testcomponents.passthrough.p", "testcomponents.tick.t", "module.string.m"}, componentIDs(ctrl))
+		require.Nil(t, ctrl.LoadStatus().LastFailure)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := New(testOptions(t))
+		defer cleanUpController(ctrl)
+
+		require.NoError(t, load(ctrl, good))
+		require.Error(t, load(ctrl, bad))
+		require.ElementsMatch(t, []string{"testcomponents.passthrough.b", "testcomponents.tick.t"}, componentIDs(ctrl))
+
+		status := ctrl.LoadStatus()
+		require.NotNil(t, status.LastFailure)
+		require.False(t, status.LastFailure.RolledBack)
+	})
+}
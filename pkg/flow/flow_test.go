@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
@@ -53,6 +54,104 @@ func TestController_LoadSource_Evaluation(t *testing.T) {
 	require.Equal(t, "hello, world!", out.(testcomponents.PassthroughExports).Output)
 }
 
+func TestController_EvaluationErrors(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+	defer cleanUpController(ctrl)
+
+	f, err := ParseSource(t.Name(), []byte(`
+		testcomponents.tick "ticker" {
+			frequency = "0s"
+		}
+	`))
+	require.NoError(t, err)
+	require.Error(t, ctrl.LoadSource(f, nil))
+
+	info, err := ctrl.GetComponent(component.ID{LocalID: "testcomponents.tick.ticker"}, component.InfoOptions{GetHealth: true})
+	require.NoError(t, err)
+	require.Len(t, info.EvaluationErrors, 1)
+	require.Equal(t, "testcomponents.tick.ticker", info.EvaluationErrors[0].NodeID)
+	require.Contains(t, info.EvaluationErrors[0].Message, "frequency must not be 0")
+}
+
+func TestController_RefreshComponent(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+	defer cleanUpController(ctrl)
+
+	f, err := ParseSource(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	err = ctrl.RefreshComponent(component.ID{LocalID: "testcomponents.passthrough.missing"})
+	require.ErrorIs(t, err, component.ErrComponentNotFound)
+
+	err = ctrl.RefreshComponent(component.ID{ModuleID: "module.file.missing", LocalID: "testcomponents.passthrough.static"})
+	require.ErrorIs(t, err, component.ErrComponentNotFound)
+
+	err = ctrl.RefreshComponent(component.ID{LocalID: "testcomponents.passthrough.static"})
+	require.ErrorContains(t, err, "doesn't load content from an external source")
+}
+
+func TestController_DisabledComponent(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	ctrl := New(testOptions(t))
+
+	load := func(enabled string) {
+		f, err := ParseSource(t.Name(), []byte(`
+			testcomponents.passthrough "flag" {
+				input = "`+enabled+`"
+			}
+
+			testcomponents.tick "ticker" {
+				frequency = "10ms"
+				enabled   = testcomponents.passthrough.flag.output == "true"
+			}
+		`))
+		require.NoError(t, err)
+		require.NoError(t, ctrl.LoadSource(f, nil))
+	}
+	tickerHealth := func() (component.Health, time.Time) {
+		info, err := ctrl.GetComponent(component.ID{LocalID: "testcomponents.tick.ticker"}, component.InfoOptions{
+			GetHealth:  true,
+			GetExports: true,
+		})
+		require.NoError(t, err)
+		return info.Health, info.Exports.(testcomponents.TickExports).Time
+	}
+
+	load("false")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		health, tickTime := tickerHealth()
+		return health.Health == component.HealthTypeHealthy && health.Message == "component disabled" && tickTime.IsZero()
+	}, 3*time.Second, 10*time.Millisecond)
+
+	load("true")
+	require.Eventually(t, func() bool {
+		health, tickTime := tickerHealth()
+		return health.Health == component.HealthTypeHealthy && !tickTime.IsZero()
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Disabling the component again stops it and resets its exports.
+	load("false")
+	require.Eventually(t, func() bool {
+		health, tickTime := tickerHealth()
+		return health.Health == component.HealthTypeHealthy && health.Message == "component disabled" && tickTime.IsZero()
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
 func getFields(t *testing.T, g *dag.Graph, nodeID string) (component.Arguments, component.Exports) {
 	t.Helper()
 
@@ -0,0 +1,76 @@
+package flow
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/grafana/agent/pkg/flow/internal/worker"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/service"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ValidateSource checks source the same way LoadSource does without applying
+// it to the controller. The arguments of every component are decoded and
+// validated, but components aren't built, since building them may have side
+// effects such as opening listeners. Module loaders are the exception: they're
+// built so that the content of their modules is validated the same way.
+//
+// Exports of components which aren't built are unknown, so errors decoding
+// arguments which reference them are ignored, and module loaders whose
+// arguments reference them aren't built. The running components and services
+// are left untouched.
+//
+// The controller used for validation has its own data directory and metrics
+// registry, both of which are discarded once ValidateSource returns.
+func (f *Flow) ValidateSource(source *Source, args map[string]any) error {
+	dataPath, err := os.MkdirTemp("", "agent-validate-*")
+	if err != nil {
+		return fmt.Errorf("creating data directory for validation: %w", err)
+	}
+	defer os.RemoveAll(dataPath)
+
+	logger, err := logging.New(io.Discard, logging.DefaultOptions)
+	if err != nil {
+		return err
+	}
+
+	services := make([]service.Service, 0, len(f.opts.Services))
+	for _, svc := range f.opts.Services {
+		services = append(services, validationService{Service: svc})
+	}
+
+	o := f.opts.Options
+	o.Logger = logger
+	o.Tracer = f.tracer
+	o.Reg = prometheus.NewRegistry()
+	o.DataPath = dataPath
+	o.Services = services
+	o.AuditLogger = nil
+	if o.OnExportsChange != nil {
+		// Modules are recognized by having an export handler, which must be
+		// kept so export blocks are accepted.
+		o.OnExportsChange = func(map[string]any) {}
+	}
+
+	ctrl := newController(controllerOptions{
+		Options:        o,
+		IsModule:       f.opts.IsModule,
+		ModuleRegistry: newModuleRegistry(),
+		WorkerPool:     worker.NewFixedWorkerPool(1, worker.DefaultMaxQueueSize),
+		ValidateOnly:   true,
+	})
+	defer ctrl.loader.Cleanup(true)
+
+	return ctrl.LoadSource(source, args)
+}
+
+// validationService wraps a running service for ValidateSource. Blocks
+// configuring the service are still decoded, but the service isn't updated.
+type validationService struct {
+	service.Service
+}
+
+// Update implements [service.Service]. It discards newConfig.
+func (validationService) Update(newConfig any) error { return nil }
@@ -0,0 +1,112 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/internal/testservices"
+	"github.com/grafana/agent/service"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestValidateSource(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	type ServiceOptions struct {
+		Name string `river:"name,attr,optional"`
+	}
+
+	var (
+		updates atomic.Int32
+		svc     = &testservices.Fake{
+			DefinitionFunc: func() service.Definition {
+				return service.Definition{
+					Name:       "fake",
+					ConfigType: ServiceOptions{},
+				}
+			},
+			UpdateFunc: func(newConfig any) error {
+				updates.Inc()
+				return nil
+			},
+		}
+	)
+
+	opts := testOptions(t)
+	opts.Services = append(opts.Services, svc)
+	ctrl := New(opts)
+	defer cleanUpController(ctrl)
+
+	running, err := ParseSource(t.Name(), []byte(`
+		testcomponents.passthrough "a" { input = "a" }
+		fake { name = "running" }
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(running, nil))
+	require.Equal(t, int32(1), updates.Load())
+
+	validate := func(content string) error {
+		source, err := ParseSource(t.Name(), []byte(content))
+		require.NoError(t, err)
+		return ctrl.ValidateSource(source, nil)
+	}
+
+	require.NoError(t, validate(`
+		testcomponents.passthrough "b" { input = "b" }
+		module.string "m" {
+			content = "testcomponents.passthrough \"inner\" { input = \"x\" }"
+		}
+		fake { name = "validated" }
+	`))
+
+	// Arguments are decoded and validated.
+	err = validate(`
+		testcomponents.passthrough "b" { input = testcomponents.passthrough.missing.output }
+	`)
+	require.ErrorContains(t, err, "testcomponents.passthrough.missing")
+	err = validate(`
+		testcomponents.tick "t" { frequency = "not a duration" }
+	`)
+	require.Error(t, err)
+
+	// Components aren't built, so errors which only occur when building
+	// them, such as the zero frequency of testcomponents.tick, aren't
+	// reported.
+	require.NoError(t, validate(`
+		testcomponents.tick "t" { frequency = "0s" }
+	`))
+
+	// Exports of components which aren't built are zero values, so errors
+	// decoding them aren't reported.
+	require.NoError(t, validate(`
+		testcomponents.passthrough "p" { input = "1s" }
+		testcomponents.tick "t" { frequency = testcomponents.passthrough.p.output }
+	`))
+	err = validate(`
+		testcomponents.passthrough "p" { input = "1s" }
+		testcomponents.tick "t" {
+			frequency = "not a duration"
+			enabled   = testcomponents.passthrough.p.output == "1s"
+		}
+	`)
+	require.ErrorContains(t, err, `invalid duration "not a duration"`)
+
+	// Module loaders are built, so the content of modules is validated.
+	err = validate(`
+		module.string "m" {
+			content = "testcomponents.tick \"inner\" { frequency = \"not a duration\" }"
+		}
+	`)
+	require.ErrorContains(t, err, `invalid duration "not a duration"`)
+	require.NoError(t, validate(`
+		module.string "m" {
+			content = "testcomponents.passthrough \"p\" { input = \"1s\" }\ntestcomponents.tick \"t\" { frequency = testcomponents.passthrough.p.output }"
+		}
+	`))
+
+	// The running components and services are untouched.
+	require.Equal(t, int32(1), updates.Load())
+	components := ctrl.loader.Components()
+	require.Len(t, components, 1)
+	require.Equal(t, "testcomponents.passthrough.a", components[0].NodeID())
+}
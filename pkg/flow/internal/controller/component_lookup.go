@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow/logging/level"
+	"github.com/grafana/river/vm"
+)
+
+// ComponentLookup implements the component.exports function, which returns
+// the exports of a component by its global ID. A single ComponentLookup is
+// shared by a root controller and all of its modules, so that components can
+// be looked up across modules.
+//
+// Lookups don't add edges to the graph. Instead, ComponentLookup remembers
+// which nodes looked up which components, and evaluates those nodes again
+// when the exports of the components they looked up change. Lookups which
+// would form a cycle of lookups fail.
+type ComponentLookup struct {
+	resolve func(id string) (*Loader, string)
+
+	mut      sync.Mutex
+	watchers map[string]map[string]lookupWatcher // Target global ID -> watcher global ID -> watcher.
+	targets  map[string]map[string]struct{}      // Watcher global ID -> target global IDs.
+}
+
+// lookupWatcher is a node which looked up the exports of a component.
+type lookupWatcher struct {
+	loader *Loader
+	node   BlockNode
+}
+
+// NewComponentLookup creates a new ComponentLookup. resolve is called with
+// the global ID of a component, and returns the loader which manages the
+// component along with the normalized global ID of the component. resolve
+// returns a nil loader if the component's module isn't running.
+func NewComponentLookup(resolve func(id string) (*Loader, string)) *ComponentLookup {
+	return &ComponentLookup{
+		resolve:  resolve,
+		watchers: make(map[string]map[string]lookupWatcher),
+		targets:  make(map[string]map[string]struct{}),
+	}
+}
+
+// nodeScope returns the scope to evaluate n with, which provides the
+// component.exports function on top of scope.
+func (l *Loader) nodeScope(n BlockNode, scope *vm.Scope) *vm.Scope {
+	c := l.globals.ComponentLookup
+	if c == nil {
+		return scope
+	}
+
+	// Forget the lookups of the previous evaluation of n; the lookups of this
+	// evaluation are recorded while it's evaluated.
+	callerID := path.Join(l.globals.ControllerID, n.NodeID())
+	c.unwatch(callerID)
+
+	exports := func(id string) (any, error) {
+		err := featuregate.CheckAllowed(featuregate.StabilityExperimental, l.globals.MinStability, "function component.exports")
+		if err != nil {
+			return nil, err
+		}
+		return c.exports(lookupWatcher{loader: l, node: n}, callerID, id)
+	}
+
+	return &vm.Scope{
+		Parent: scope,
+		Variables: map[string]any{
+			componentVariable: map[string]any{"exports": exports},
+		},
+	}
+}
+
+// exports returns the exports of the component with the given global ID on
+// behalf of w, or nil if the component doesn't exist or hasn't been evaluated
+// yet.
+func (c *ComponentLookup) exports(w lookupWatcher, callerID string, id string) (any, error) {
+	loader, targetID := c.resolve(id)
+
+	c.mut.Lock()
+	if c.reaches(targetID, callerID) {
+		c.mut.Unlock()
+		return nil, fmt.Errorf("looking up %q from %q would form a cycle", targetID, callerID)
+	}
+	if c.watchers[targetID] == nil {
+		c.watchers[targetID] = make(map[string]lookupWatcher)
+	}
+	c.watchers[targetID][callerID] = w
+	if c.targets[callerID] == nil {
+		c.targets[callerID] = make(map[string]struct{})
+	}
+	c.targets[callerID][targetID] = struct{}{}
+	c.mut.Unlock()
+
+	if loader == nil {
+		return nil, nil
+	}
+	exports, _ := loader.CachedExports(path.Base(targetID))
+	return exports, nil
+}
+
+// reaches returns true if to is from or is looked up by from, directly or
+// through other lookups. mut must be held when calling reaches.
+func (c *ComponentLookup) reaches(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for target := range c.targets[from] {
+		if c.reaches(target, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// unwatch forgets all lookups made by the node with the given global ID.
+func (c *ComponentLookup) unwatch(callerID string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.unwatchLocked(callerID)
+}
+
+func (c *ComponentLookup) unwatchLocked(callerID string) {
+	for target := range c.targets[callerID] {
+		delete(c.watchers[target], callerID)
+		if len(c.watchers[target]) == 0 {
+			delete(c.watchers, target)
+		}
+	}
+	delete(c.targets, callerID)
+}
+
+// unwatchNode forgets all lookups made by the node with the given global ID,
+// provided they were made by n rather than by a node which replaced n.
+func (c *ComponentLookup) unwatchNode(callerID string, n BlockNode) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for target := range c.targets[callerID] {
+		if c.watchers[target][callerID].node != n {
+			return
+		}
+	}
+	c.unwatchLocked(callerID)
+}
+
+// removeLoader forgets all lookups made by the nodes of l.
+func (c *ComponentLookup) removeLoader(l *Loader) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for callerID, targets := range c.targets {
+		for target := range targets {
+			if c.watchers[target][callerID].loader == l {
+				c.unwatchLocked(callerID)
+				break
+			}
+		}
+	}
+}
+
+// notify submits the nodes which looked up the component with the given
+// global ID for evaluation.
+func (c *ComponentLookup) notify(targetID string) {
+	c.mut.Lock()
+	watchers := make([]lookupWatcher, 0, len(c.watchers[targetID]))
+	for _, w := range c.watchers[targetID] {
+		watchers = append(watchers, w)
+	}
+	c.mut.Unlock()
+
+	for _, w := range watchers {
+		w.loader.reevaluateWatcher(w.node)
+	}
+}
+
+// ModuleStarted submits the nodes which looked up components of the module
+// with the given ID for evaluation. Lookups of components in a module return
+// null until the module runs.
+func (c *ComponentLookup) ModuleStarted(moduleID string) {
+	prefix := moduleID + "/"
+
+	c.mut.Lock()
+	var watchers []lookupWatcher
+	for target, targetWatchers := range c.watchers {
+		if !strings.HasPrefix(target, prefix) || strings.Contains(target[len(prefix):], "/") {
+			continue
+		}
+		for _, w := range targetWatchers {
+			watchers = append(watchers, w)
+		}
+	}
+	c.mut.Unlock()
+
+	for _, w := range watchers {
+		w.loader.reevaluateWatcher(w.node)
+	}
+}
+
+// onExportsChanged notifies the nodes which looked up the exports of cn that
+// they changed.
+func (l *Loader) onExportsChanged(cn ComponentNode) {
+	if c := l.globals.ComponentLookup; c != nil {
+		c.notify(path.Join(l.globals.ControllerID, cn.NodeID()))
+	}
+}
+
+// reevaluateWatcher submits n for evaluation, provided it's still part of the
+// graph. reevaluateWatcher doesn't block, since it may be called while the
+// loader of n is locked.
+func (l *Loader) reevaluateWatcher(n BlockNode) {
+	if l.workerPool == nil {
+		return
+	}
+
+	tracer := l.tracer.Tracer("")
+	queued := &QueuedNode{Node: n, LastUpdatedTime: time.Now()}
+	globalUniqueKey := path.Join(l.globals.ControllerID, n.NodeID())
+	err := l.workerPool.SubmitWithKey(globalUniqueKey, func() {
+		l.mut.RLock()
+		current := l.graph.GetByID(n.NodeID())
+		l.mut.RUnlock()
+
+		if current != n {
+			// The node was removed or replaced by a reload.
+			l.globals.ComponentLookup.unwatchNode(globalUniqueKey, n)
+			return
+		}
+		l.concurrentEvalFn(n, context.Background(), tracer, queued)
+	})
+	if err != nil {
+		level.Error(l.log).Log("msg", "failed to submit node for evaluation after looked up exports changed", "node_id", n.NodeID(), "err", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/river/ast"
 	"github.com/grafana/river/diag"
+	"github.com/grafana/river/token"
 	"github.com/grafana/river/vm"
 )
 
@@ -22,6 +23,9 @@ type Reference struct {
 	// Traversal describes which nested field relative to Target is being
 	// accessed.
 	Traversal Traversal
+
+	// Pos is the position of the expression making the reference.
+	Pos token.Pos
 }
 
 // ComponentReferences returns the list of references a component is making to
@@ -52,6 +56,12 @@ func ComponentReferences(cn dag.Node, g *dag.Graph) ([]Reference, diag.Diagnosti
 			continue
 		}
 
+		// Variables provided by the controller aren't nodes, so references to
+		// them don't create edges in the graph.
+		if isControllerVariable(t[0].Name) {
+			continue
+		}
+
 		ref, resolveDiags := resolveTraversal(t, g)
 		diags = append(diags, resolveDiags...)
 		if resolveDiags.HasErrors() {
@@ -142,6 +152,7 @@ func resolveTraversal(t Traversal, g *dag.Graph) (Reference, diag.Diagnostics) {
 			return Reference{
 				Target:    n.(BlockNode),
 				Traversal: rem,
+				Pos:       t[0].NamePos,
 			}, nil
 		}
 
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow/logging/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/river/diag"
+)
+
+// evalRetrier schedules new evaluations of component nodes whose evaluation
+// failed, using an exponential backoff per node. Without retries, a component
+// which failed to evaluate because of a transient condition (e.g., a file
+// which was briefly missing) would stay unhealthy until one of its
+// dependencies happened to change.
+type evalRetrier struct {
+	cfg backoff.Config
+
+	mut     sync.Mutex
+	closed  bool
+	pending map[string]*evalRetry
+}
+
+// evalRetry is the retry state of a single node.
+type evalRetry struct {
+	attempts int
+	timer    *time.Timer // Non-nil while a retry is scheduled.
+}
+
+func newEvalRetrier(cfg backoff.Config) *evalRetrier {
+	return &evalRetrier{
+		cfg:     cfg,
+		pending: make(map[string]*evalRetry),
+	}
+}
+
+// isRetryableEvalError reports whether err is an evaluation error which may
+// go away by itself. Errors in the River block of a node won't be fixed
+// without a config change, and evaluations which timed out are still
+// running, so neither are retried.
+func isRetryableEvalError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) {
+		return false
+	}
+	return !errors.As(err, new(*evaluationTimeoutError))
+}
+
+// schedule calls retry after the backoff delay of the node with the given ID.
+// schedule is a no-op if a retry is already scheduled for the node.
+func (r *evalRetrier) schedule(nodeID string, retry func()) time.Duration {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.closed {
+		return 0
+	}
+
+	state, ok := r.pending[nodeID]
+	if !ok {
+		state = &evalRetry{}
+		r.pending[nodeID] = state
+	}
+	if state.timer != nil {
+		return 0
+	}
+
+	delay := r.delay(state.attempts)
+	state.attempts++
+	state.timer = time.AfterFunc(delay, func() {
+		r.mut.Lock()
+		state.timer = nil
+		r.mut.Unlock()
+
+		retry()
+	})
+	return delay
+}
+
+// delay returns the backoff delay after the given number of attempts.
+func (r *evalRetrier) delay(attempts int) time.Duration {
+	delay := r.cfg.MinBackoff
+	for i := 0; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// reset stops any scheduled retry of the node with the given ID and resets
+// its backoff.
+func (r *evalRetrier) reset(nodeID string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if state, ok := r.pending[nodeID]; ok {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+		delete(r.pending, nodeID)
+	}
+}
+
+// Close stops all scheduled retries. No retries are scheduled after Close.
+func (r *evalRetrier) Close() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.closed = true
+	for nodeID, state := range r.pending {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+		delete(r.pending, nodeID)
+	}
+}
+
+// onNodeEvaluated schedules a retry of a failed component evaluation, or
+// resets the retry state of the node after a successful one.
+func (l *Loader) onNodeEvaluated(n BlockNode, err error) {
+	if _, ok := n.(ComponentNode); !ok || l.workerPool == nil {
+		return
+	}
+	if !isRetryableEvalError(err) {
+		l.retrier.reset(n.NodeID())
+		return
+	}
+
+	delay := l.retrier.schedule(n.NodeID(), func() { l.retryEvaluation(n) })
+	if delay > 0 {
+		level.Warn(l.log).Log("msg", "component evaluation failed, will retry", "node_id", n.NodeID(), "retry_in", delay, "err", err)
+	}
+}
+
+// retryEvaluation submits n for evaluation, provided it's still part of the
+// graph.
+func (l *Loader) retryEvaluation(n BlockNode) {
+	l.mut.RLock()
+	current := l.graph.GetByID(n.NodeID())
+	l.mut.RUnlock()
+
+	if current != n {
+		// The node was removed or replaced by a reload.
+		l.retrier.reset(n.NodeID())
+		return
+	}
+
+	l.cm.evaluationRetries.Inc()
+
+	tracer := l.tracer.Tracer("")
+	queued := &QueuedNode{Node: n, LastUpdatedTime: time.Now()}
+	globalUniqueKey := path.Join(l.globals.ControllerID, n.NodeID())
+	err := l.workerPool.SubmitWithKey(globalUniqueKey, func() {
+		l.concurrentEvalFn(n, context.Background(), tracer, queued)
+	})
+	if err != nil {
+		// The worker pool is full; try again later.
+		l.onNodeEvaluated(n, err)
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
+	"github.com/grafana/agent/pkg/flow/internal/worker"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/diag"
+	"github.com/grafana/river/parser"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/atomic"
+)
+
+func TestEvalRetrierDelay(t *testing.T) {
+	r := newEvalRetrier(backoff.Config{
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	})
+
+	require.Equal(t, 1*time.Second, r.delay(0))
+	require.Equal(t, 2*time.Second, r.delay(1))
+	require.Equal(t, 4*time.Second, r.delay(2))
+	require.Equal(t, 8*time.Second, r.delay(3))
+	require.Equal(t, 10*time.Second, r.delay(4))
+	require.Equal(t, 10*time.Second, r.delay(100))
+}
+
+func TestIsRetryableEvalError(t *testing.T) {
+	require.False(t, isRetryableEvalError(nil))
+	require.True(t, isRetryableEvalError(errors.New("file not found")))
+	require.False(t, isRetryableEvalError(fmt.Errorf("decoding River: %w", diag.Diagnostics{{Message: "bad attribute"}})))
+	require.False(t, isRetryableEvalError(&evaluationTimeoutError{nodeID: "a", timeout: time.Second}))
+}
+
+type flakyArgs struct {
+	Value string `river:"value,attr"`
+}
+
+type flakyComponent struct {
+	running chan struct{}
+}
+
+func (c flakyComponent) Run(ctx context.Context) error {
+	close(c.running)
+	<-ctx.Done()
+	return nil
+}
+
+func (flakyComponent) Update(component.Arguments) error { return nil }
+
+func TestLoader_RetriesFailedEvaluations(t *testing.T) {
+	var (
+		builds  atomic.Int32
+		running = make(chan struct{})
+		built   = make(chan ComponentNode, 1)
+	)
+
+	registry := RegistryMap{
+		"flaky": component.Registration{
+			Name:      "flaky",
+			Stability: featuregate.StabilityExperimental,
+			Args:      flakyArgs{},
+			Build: func(component.Options, component.Arguments) (component.Component, error) {
+				// Fail the first two builds.
+				if builds.Inc() <= 2 {
+					return nil, errors.New("source not ready")
+				}
+				return flakyComponent{running: running}, nil
+			},
+		},
+	}
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	pool := worker.NewFixedWorkerPool(1, 10)
+	defer pool.Stop()
+
+	l := NewLoader(LoaderOptions{
+		ComponentGlobals: ComponentGlobals{
+			Logger:            logger,
+			TraceProvider:     noop.NewTracerProvider(),
+			DataPath:          t.TempDir(),
+			OnBlockNodeUpdate: func(cn BlockNode) { /* no-op */ },
+			OnComponentBuilt:  func(cn ComponentNode) { built <- cn },
+			NewModuleController: func(id string) ModuleController {
+				return nil
+			},
+		},
+		ComponentRegistry: registry,
+		WorkerPool:        pool,
+	})
+	l.retrier = newEvalRetrier(backoff.Config{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 50 * time.Millisecond,
+	})
+	defer l.Cleanup(false)
+
+	file, err := parser.ParseFile(t.Name(), []byte(`flaky "test" { value = "a" }`))
+	require.NoError(t, err)
+	diags := l.Apply(context.Background(), nil, []*ast.BlockStmt{file.Body[0].(*ast.BlockStmt)}, nil)
+	require.ErrorContains(t, diags.ErrorOrNil(), "source not ready")
+
+	// The component isn't built yet, so it exits immediately when scheduled.
+	sched := NewScheduler()
+	defer sched.Close()
+	synchronize := func() {
+		var runnables []RunnableNode
+		for _, cn := range l.Components() {
+			runnables = append(runnables, cn)
+		}
+		require.NoError(t, sched.Synchronize(runnables))
+	}
+	synchronize()
+
+	// Once a retry builds the component, the controller is informed so that
+	// it schedules the component again.
+	node := l.Graph().GetByID("flaky.test").(*BuiltinComponentNode)
+	select {
+	case cn := <-built:
+		require.Equal(t, node, cn)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "component was never built")
+	}
+	require.Equal(t, int32(3), builds.Load())
+	require.NotEqual(t, component.HealthTypeUnhealthy, node.CurrentHealth().Health)
+
+	synchronize()
+	select {
+	case <-running:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "component never ran")
+	}
+}
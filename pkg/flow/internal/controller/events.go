@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+)
+
+// EventType is the kind of change described by an Event.
+type EventType string
+
+// Types of events published by component nodes.
+const (
+	EventStarted   EventType = "started"   // The managed component started running.
+	EventStopped   EventType = "stopped"   // The managed component stopped running.
+	EventReloaded  EventType = "reloaded"  // The managed component was updated with new arguments.
+	EventUnhealthy EventType = "unhealthy" // The component became unhealthy.
+	EventRecovered EventType = "recovered" // The component is no longer unhealthy.
+)
+
+// Event describes a change of the state of a component.
+type Event struct {
+	Time        time.Time            `json:"time"`
+	Type        EventType            `json:"type"`
+	ComponentID string               `json:"component_id"` // Globally unique ID of the component.
+	Health      component.HealthType `json:"health"`       // Health of the component after the change.
+	Message     string               `json:"message"`      // Message of the health of the component.
+}
+
+// EventBus distributes events to subscribers. Events are never blocked on
+// slow subscribers: an event is dropped for a subscriber whose buffer is
+// full. The zero value is ready for use.
+type EventBus struct {
+	mut  sync.Mutex
+	subs map[*eventSubscription]struct{}
+}
+
+type eventSubscription struct {
+	ch chan Event
+}
+
+// Subscribe returns a channel receiving events published after Subscribe
+// returns, buffering up to buffer events. The returned func unsubscribes and
+// closes the channel; it must be called once the subscriber is done.
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	sub := &eventSubscription{ch: make(chan Event, buffer)}
+
+	b.mut.Lock()
+	if b.subs == nil {
+		b.subs = make(map[*eventSubscription]struct{})
+	}
+	b.subs[sub] = struct{}{}
+	b.mut.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mut.Lock()
+			delete(b.subs, sub)
+			b.mut.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Publish sends e to all subscribers. Publish is a no-op on a nil EventBus.
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	// The lock is held so that the channel of a subscriber can't be
+	// closed while sending to it.
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus(t *testing.T) {
+	var bus EventBus
+
+	first, unsubscribeFirst := bus.Subscribe(1)
+	second, unsubscribeSecond := bus.Subscribe(2)
+	defer unsubscribeSecond()
+
+	bus.Publish(Event{Type: EventStarted})
+	bus.Publish(Event{Type: EventStopped}) // Dropped for the first subscriber.
+
+	require.Equal(t, EventStarted, (<-first).Type)
+	require.Equal(t, EventStarted, (<-second).Type)
+	require.Equal(t, EventStopped, (<-second).Type)
+
+	unsubscribeFirst()
+	unsubscribeFirst() // Unsubscribing again must not panic.
+	_, ok := <-first
+	require.False(t, ok, "channel must be closed after unsubscribing")
+
+	bus.Publish(Event{Type: EventReloaded})
+	require.Equal(t, EventReloaded, (<-second).Type)
+
+	var nilBus *EventBus
+	require.NotPanics(t, func() { nilBus.Publish(Event{}) })
+}
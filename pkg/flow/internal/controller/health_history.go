@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/grafana/agent/component"
+)
+
+// healthHistorySize is the number of health transitions retained for each
+// node.
+const healthHistorySize = 20
+
+// healthHistory is a bounded ring buffer of health transitions. Only
+// transitions are recorded: health values which have the same state and
+// message as the most recently recorded value are ignored.
+type healthHistory struct {
+	mut     sync.Mutex
+	entries []component.Health
+	next    int // Index to write the next entry to once entries is full.
+}
+
+// Record adds h to the history if it differs from the most recently recorded
+// health. Record returns the previously recorded health and whether h was
+// recorded as a transition.
+func (hh *healthHistory) Record(h component.Health) (component.Health, bool) {
+	hh.mut.Lock()
+	defer hh.mut.Unlock()
+
+	last, ok := hh.latest()
+	if ok && last.Health == h.Health && last.Message == h.Message {
+		return last, false
+	}
+
+	if len(hh.entries) < healthHistorySize {
+		hh.entries = append(hh.entries, h)
+		return last, true
+	}
+	hh.entries[hh.next] = h
+	hh.next = (hh.next + 1) % healthHistorySize
+	return last, true
+}
+
+// latest returns the most recently recorded health. mut must be held when
+// calling latest.
+func (hh *healthHistory) latest() (component.Health, bool) {
+	if len(hh.entries) == 0 {
+		return component.Health{}, false
+	}
+	if len(hh.entries) < healthHistorySize {
+		return hh.entries[len(hh.entries)-1], true
+	}
+	return hh.entries[(hh.next+healthHistorySize-1)%healthHistorySize], true
+}
+
+// List returns the recorded health transitions, ordered from oldest to
+// newest.
+func (hh *healthHistory) List() []component.Health {
+	hh.mut.Lock()
+	defer hh.mut.Unlock()
+
+	res := make([]component.Health, 0, len(hh.entries))
+	res = append(res, hh.entries[hh.next:]...)
+	res = append(res, hh.entries[:hh.next]...)
+	return res
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHistory(t *testing.T) {
+	var hh healthHistory
+
+	healthy := component.Health{Health: component.HealthTypeHealthy, Message: "ok"}
+	unhealthy := component.Health{Health: component.HealthTypeUnhealthy, Message: "broken"}
+
+	hh.Record(healthy)
+	hh.Record(healthy) // Not a transition; should be ignored.
+	hh.Record(unhealthy)
+	require.Equal(t, []component.Health{healthy, unhealthy}, hh.List())
+}
+
+func TestHealthHistory_Bounded(t *testing.T) {
+	var hh healthHistory
+
+	var expect []component.Health
+	for i := 0; i < healthHistorySize+5; i++ {
+		h := component.Health{Health: component.HealthTypeUnhealthy, Message: fmt.Sprintf("failure %d", i)}
+		hh.Record(h)
+		expect = append(expect, h)
+	}
+
+	require.Equal(t, expect[5:], hh.List())
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/worker"
 	"github.com/grafana/agent/pkg/flow/logging/level"
@@ -17,6 +20,7 @@ import (
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/river/ast"
 	"github.com/grafana/river/diag"
+	"github.com/grafana/river/vm"
 	"github.com/hashicorp/go-multierror"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -32,11 +36,14 @@ type Loader struct {
 	host         service.Host
 	componentReg ComponentRegistry
 	workerPool   worker.Pool
+	updateQueue  *Queue
 	// backoffConfig is used to backoff when an updated component's dependencies cannot be submitted to worker
 	// pool for evaluation in EvaluateDependants, because the queue is full. This is an unlikely scenario, but when
 	// it happens we should avoid retrying too often to give other goroutines a chance to progress. Having a backoff
 	// also prevents log spamming with errors.
 	backoffConfig backoff.Config
+	// retrier schedules new evaluations of components whose evaluation failed.
+	retrier *evalRetrier
 
 	mut               sync.RWMutex
 	graph             *dag.Graph
@@ -48,6 +55,13 @@ type Loader struct {
 	cm                *controllerMetrics
 	cc                *controllerCollector
 	moduleExportIndex int
+
+	// pendingEvals tracks nodes whose evaluation exceeded the evaluation
+	// timeout and hasn't finished yet.
+	pendingEvalsMut sync.Mutex
+	pendingEvals    map[string]struct{}
+
+	settle settleTracker // Measures how long the graph takes to settle after a change.
 }
 
 // LoaderOptions holds options for creating a Loader.
@@ -59,6 +73,7 @@ type LoaderOptions struct {
 	Host              service.Host      // Service host (when running services).
 	ComponentRegistry ComponentRegistry // Registry to search for components.
 	WorkerPool        worker.Pool       // Worker pool to use for async tasks.
+	UpdateQueue       *Queue            // Queue of updated nodes (optional, used for metrics).
 }
 
 // NewLoader creates a new Loader. Components built by the Loader will be built
@@ -83,6 +98,7 @@ func NewLoader(opts LoaderOptions) *Loader {
 		host:         host,
 		componentReg: reg,
 		workerPool:   opts.WorkerPool,
+		updateQueue:  opts.UpdateQueue,
 
 		// This is a reasonable default which should work for most cases. If a component is completely stuck, we would
 		// retry and log an error every 10 seconds, at most.
@@ -91,12 +107,28 @@ func NewLoader(opts LoaderOptions) *Loader {
 			MaxBackoff: 10 * time.Second,
 		},
 
+		// Retry failed evaluations quickly at first, but don't retry components
+		// which keep failing more often than once a minute.
+		retrier: newEvalRetrier(backoff.Config{
+			MinBackoff: 1 * time.Second,
+			MaxBackoff: 1 * time.Minute,
+		}),
+
 		graph:         &dag.Graph{},
 		originalGraph: &dag.Graph{},
 		cache:         newValueCache(),
 		cm:            newControllerMetrics(globals.ControllerID),
+		pendingEvals:  make(map[string]struct{}),
 	}
-	l.cc = newControllerCollector(l, globals.ControllerID)
+	l.cc = newControllerCollector(l, globals.ControllerID, globals.MaxComponentSeries)
+
+	hostname, _ := os.Hostname()
+	l.cache.SetVariable(agentVariable, map[string]any{
+		"id":           globals.AgentID,
+		"hostname":     hostname,
+		"cluster_name": globals.ClusterName,
+		"module_id":    globals.ControllerID,
+	})
 
 	if globals.Registerer != nil {
 		globals.Registerer.MustRegister(l.cc)
@@ -106,6 +138,13 @@ func NewLoader(opts LoaderOptions) *Loader {
 	return l
 }
 
+// CachedExports returns the most recently evaluated exports of the component
+// with the given node ID. CachedExports doesn't lock the Loader, so it may be
+// called while nodes are being evaluated.
+func (l *Loader) CachedExports(nodeID string) (any, bool) {
+	return l.cache.GetExports(nodeID)
+}
+
 // Apply loads a new set of components into the Loader. Apply will drop any
 // previously loaded component which is not described in the set of River
 // blocks.
@@ -118,8 +157,13 @@ func NewLoader(opts LoaderOptions) *Loader {
 // The provided parentContext can be used to provide global variables and
 // functions to components. A child context will be constructed from the parent
 // to expose values of other components.
-func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) diag.Diagnostics {
+//
+// The span in ctx, if any, is the parent of the spans created by Apply.
+func (l *Loader) Apply(ctx context.Context, args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) diag.Diagnostics {
 	start := time.Now()
+	l.settle.begin(start)
+	defer l.endSettle()
+
 	l.mut.Lock()
 	defer l.mut.Unlock()
 	l.cm.controllerEvaluation.Set(1)
@@ -139,10 +183,14 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 		components   = make([]ComponentNode, 0, len(componentBlocks))
 		componentIDs = make([]ComponentID, 0, len(componentBlocks))
 		services     = make([]*ServiceNode, 0, len(l.services))
+
+		// resultsMut guards the variables above and diags, since independent
+		// nodes may be evaluated concurrently.
+		resultsMut sync.Mutex
 	)
 
 	tracer := l.tracer.Tracer("")
-	spanCtx, span := tracer.Start(context.Background(), "GraphEvaluate", trace.WithSpanKind(trace.SpanKindInternal))
+	spanCtx, span := tracer.Start(ctx, "GraphEvaluate", trace.WithSpanKind(trace.SpanKindInternal))
 	defer span.End()
 
 	logger := log.With(l.log, "trace_id", span.SpanContext().TraceID())
@@ -156,7 +204,7 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 	l.cache.ClearModuleExports()
 
 	// Evaluate all the components.
-	_ = dag.WalkTopological(&newGraph, newGraph.Leaves(), func(n dag.Node) error {
+	_ = dag.WalkTopologicalConcurrent(&newGraph, newGraph.Leaves(), l.globals.MaxConcurrentEvaluations, func(n dag.Node) error {
 		_, span := tracer.Start(spanCtx, "EvaluateNode", trace.WithSpanKind(trace.SpanKindInternal))
 		span.SetAttributes(attribute.String("node_id", n.NodeID()))
 		defer span.End()
@@ -170,10 +218,14 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 
 		switch n := n.(type) {
 		case ComponentNode:
+			err = l.evaluate(logger, n)
+			l.onNodeEvaluated(n, err)
+
+			resultsMut.Lock()
+			defer resultsMut.Unlock()
 			components = append(components, n)
 			componentIDs = append(componentIDs, n.ID())
-
-			if err = l.evaluate(logger, n); err != nil {
+			if err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
 					diags = append(diags, evalDiags...)
@@ -188,9 +240,12 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 			}
 
 		case *ServiceNode:
-			services = append(services, n)
+			err = l.evaluate(logger, n)
 
-			if err = l.evaluate(logger, n); err != nil {
+			resultsMut.Lock()
+			defer resultsMut.Unlock()
+			services = append(services, n)
+			if err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
 					diags = append(diags, evalDiags...)
@@ -206,6 +261,8 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 
 		case BlockNode:
 			if err = l.evaluate(logger, n); err != nil {
+				resultsMut.Lock()
+				defer resultsMut.Unlock()
 				diags.Add(diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
 					Message:  fmt.Sprintf("Failed to evaluate node for config block: %s", err),
@@ -242,6 +299,10 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 
 // Cleanup unregisters any existing metrics and optionally stops the worker pool.
 func (l *Loader) Cleanup(stopWorkerPool bool) {
+	l.retrier.Close()
+	if l.globals.ComponentLookup != nil {
+		l.globals.ComponentLookup.removeLoader(l)
+	}
 	if stopWorkerPool {
 		l.workerPool.Stop()
 	}
@@ -291,6 +352,24 @@ func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockS
 	return g, diags
 }
 
+// BuildGraph builds the graph of the given blocks without evaluating or
+// running any of its nodes. The returned graph has not been transitively
+// reduced, so it has an edge for every reference between nodes. BuildGraph
+// doesn't change the graph used by Apply.
+func (l *Loader) BuildGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) (*dag.Graph, diag.Diagnostics) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	prevOriginal := l.originalGraph
+	defer func() { l.originalGraph = prevOriginal }()
+
+	_, diags := l.loadNewGraph(args, componentBlocks, configBlocks)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return l.originalGraph, diags
+}
+
 func (l *Loader) splitComponentBlocks(blocks []*ast.BlockStmt) (componentBlocks, serviceBlocks []*ast.BlockStmt) {
 	componentBlocks = make([]*ast.BlockStmt, 0, len(blocks))
 	serviceBlocks = make([]*ast.BlockStmt, 0, len(l.services))
@@ -365,7 +444,7 @@ func (l *Loader) populateServiceNodes(g *dag.Graph, serviceBlocks []*ast.BlockSt
 		if node.Block() != nil {
 			diags.Add(diag.Diagnostic{
 				Severity: diag.SeverityLevelError,
-				Message:  fmt.Sprintf("duplicate definition of %q", blockID),
+				Message:  fmt.Sprintf("%q block already declared at %s", blockID, ast.StartPos(node.Block()).Position()),
 				StartPos: ast.StartPos(block).Position(),
 				EndPos:   ast.EndPos(block).Position(),
 			})
@@ -389,12 +468,12 @@ func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, con
 		node, newConfigNodeDiags := NewConfigNode(block, l.globals)
 		diags = append(diags, newConfigNodeDiags...)
 
-		if g.GetByID(node.NodeID()) != nil {
-			configBlockStartPos := ast.StartPos(block).Position()
+		if orig := g.GetByID(node.NodeID()); orig != nil {
+			origStartPos := ast.StartPos(orig.(BlockNode).Block()).Position()
 			diags.Add(diag.Diagnostic{
 				Severity: diag.SeverityLevelError,
-				Message:  fmt.Sprintf("%q block already declared at %s", node.NodeID(), configBlockStartPos),
-				StartPos: configBlockStartPos,
+				Message:  fmt.Sprintf("%q block already declared at %s", node.NodeID(), origStartPos),
+				StartPos: ast.StartPos(block).Position(),
 				EndPos:   ast.EndPos(block).Position(),
 			})
 
@@ -477,6 +556,16 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 				continue
 			}
 
+			if err := featuregate.CheckAllowed(registration.Stability, l.globals.MinStability, fmt.Sprintf("component %q", componentName)); err != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  err.Error(),
+					StartPos: block.NamePos.Position(),
+					EndPos:   block.NamePos.Add(len(componentName) - 1).Position(),
+				})
+				continue
+			}
+
 			// Create a new component
 			c = NewBuiltinComponentNode(l.globals, registration, block)
 		}
@@ -515,11 +604,28 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 			g.AddEdge(dag.Edge{From: n, To: ref.Target})
 		}
 		diags = append(diags, nodeDiags...)
+
+		if cn, ok := n.(*BuiltinComponentNode); ok && l.globals.ValidateOnly {
+			cn.SetUnbuiltReferences(unbuiltReferences(refs))
+		}
 	}
 
 	return diags
 }
 
+// unbuiltReferences returns the references of refs to exports of
+// components. Components aren't built while validating, so their exports are
+// zero values.
+func unbuiltReferences(refs []Reference) []Reference {
+	var unbuilt []Reference
+	for _, ref := range refs {
+		if _, ok := ref.Target.(ComponentNode); ok {
+			unbuilt = append(unbuilt, ref)
+		}
+	}
+	return unbuilt
+}
+
 // Variables returns the Variables the Loader exposes for other Flow components
 // to reference.
 func (l *Loader) Variables() map[string]interface{} {
@@ -547,6 +653,41 @@ func (l *Loader) Graph() *dag.Graph {
 	return l.graph.Clone()
 }
 
+// graphStats summarizes the size of the component graph.
+type graphStats struct {
+	nodesByKind     map[string]int // Number of nodes by kind (component, config, service).
+	edges           int            // Number of edges before the transitive reduction.
+	moduleInstances map[string]int // Number of modules created by component name.
+}
+
+// graphStats returns the size of the current component graph.
+func (l *Loader) graphStats() graphStats {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	stats := graphStats{
+		nodesByKind:     make(map[string]int),
+		edges:           len(l.originalGraph.Edges()),
+		moduleInstances: make(map[string]int),
+	}
+
+	for _, n := range l.originalGraph.Nodes() {
+		switch n := n.(type) {
+		case *BuiltinComponentNode:
+			stats.nodesByKind["component"]++
+			if ids := n.ModuleIDs(); len(ids) > 0 {
+				stats.moduleInstances[n.ComponentName()] += len(ids)
+			}
+		case *ServiceNode:
+			stats.nodesByKind["service"]++
+		case BlockNode:
+			stats.nodesByKind["config"]++
+		}
+	}
+
+	return stats
+}
+
 // OriginalGraph returns a copy of the graph before Reduce was called. This can be used if you want to show a UI of the
 // original graph before the reduce function was called.
 func (l *Loader) OriginalGraph() *dag.Graph {
@@ -574,22 +715,50 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 	l.cm.controllerEvaluation.Set(1)
 	defer l.cm.controllerEvaluation.Set(0)
 
+	// The graph changed when the first of the nodes was updated.
+	changed := updatedNodes[0].LastUpdatedTime
+	for _, parent := range updatedNodes[1:] {
+		if parent.LastUpdatedTime.Before(changed) {
+			changed = parent.LastUpdatedTime
+		}
+	}
+	l.settle.begin(changed)
+	defer l.endSettle()
+
 	l.mut.RLock()
 	defer l.mut.RUnlock()
 
-	dependenciesToParentsMap := make(map[dag.Node]*QueuedNode)
+	l.cm.updateBatchSize.Observe(float64(len(updatedNodes)))
+
+	var (
+		dependenciesToParentsMap = make(map[dag.Node]*QueuedNode)
+		dependenciesCount        int
+	)
 	for _, parent := range updatedNodes {
+		l.cm.componentUpdates.WithLabelValues(parent.Node.NodeID()).Inc()
+
 		// Make sure we're in-sync with the current exports of parent.
 		if componentNode, ok := parent.Node.(ComponentNode); ok {
-			l.cache.CacheExports(componentNode.ID(), componentNode.Exports())
+			if l.cache.CacheExports(componentNode.ID(), componentNode.Exports()) {
+				l.onExportsChanged(componentNode)
+			}
 		}
 		// We collect all nodes directly incoming to parent.
 		_ = dag.WalkIncomingNodes(l.graph, parent.Node, func(n dag.Node) error {
 			dependenciesToParentsMap[n] = parent
+			dependenciesCount++
 			return nil
 		})
 	}
 
+	// Dependants shared by several updated nodes are only evaluated once.
+	coalesced := dependenciesCount - len(dependenciesToParentsMap)
+	l.cm.coalescedEvaluations.Add(float64(coalesced))
+	span.SetAttributes(
+		attribute.Int("dependants_count", len(dependenciesToParentsMap)),
+		attribute.Int("coalesced_count", coalesced),
+	)
+
 	// Submit all dependencies for asynchronous evaluation.
 	// During evaluation, if a node's exports change, Flow will add it to updated nodes queue (controller.Queue) and
 	// the Flow controller will call EvaluateDependants on it again. This results in a concurrent breadth-first
@@ -603,12 +772,17 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 		var (
 			nodeRef, parentRef = n, parent
 			retryBackoff       = backoff.New(ctx, l.backoffConfig)
+			counted            = l.settle.submit(n.NodeID())
 			err                error
 		)
 		for retryBackoff.Ongoing() {
 			globalUniqueKey := path.Join(l.globals.ControllerID, nodeRef.NodeID())
 			err = l.workerPool.SubmitWithKey(globalUniqueKey, func() {
+				l.settle.started(nodeRef.NodeID())
 				l.concurrentEvalFn(nodeRef, dependantCtx, tracer, parentRef)
+				if counted {
+					l.endSettle()
+				}
 			})
 			if err != nil {
 				level.Error(l.log).Log(
@@ -626,6 +800,10 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 		}
 		span.SetAttributes(attribute.Int("retries", retryBackoff.NumRetries()))
 		if err != nil {
+			if counted {
+				l.settle.started(n.NodeID())
+				l.endSettle()
+			}
 			span.SetStatus(codes.Error, err.Error())
 		} else {
 			span.SetStatus(codes.Ok, "node submitted for evaluation")
@@ -656,30 +834,20 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 	switch n := n.(type) {
 	case BlockNode:
 		ectx := l.cache.BuildContext()
-		evalErr := n.Evaluate(ectx)
+		evalErr := l.evaluateWithTimeout(n, ectx)
+		if errors.As(evalErr, new(*evaluationTimeoutError)) {
+			// The node may still be evaluating, so it's not safe to read its
+			// arguments and exports.
+			level.Error(l.log).Log("msg", "failed to evaluate config", "node", n.NodeID(), "err", evalErr)
+			err = evalErr
+			break
+		}
 
-		// Only obtain loader lock after we have evaluated the node, allowing for concurrent evaluation.
-		l.mut.RLock()
-		err = l.postEvaluate(l.log, n, evalErr)
+		err = l.finishEvaluation(n, evalErr)
+	}
 
-		// Additional post-evaluation steps necessary for module exports.
-		if exp, ok := n.(*ExportConfigNode); ok {
-			l.cache.CacheModuleExportValue(exp.Label(), exp.Value())
-		}
-		if l.globals.OnExportsChange != nil && l.cache.ExportChangeIndex() != l.moduleExportIndex {
-			// Upgrade to write lock to update the module exports.
-			l.mut.RUnlock()
-			l.mut.Lock()
-			defer l.mut.Unlock()
-			// Check if the update still needed after obtaining the write lock and perform it.
-			if l.cache.ExportChangeIndex() != l.moduleExportIndex {
-				l.globals.OnExportsChange(l.cache.CreateModuleExports())
-				l.moduleExportIndex = l.cache.ExportChangeIndex()
-			}
-		} else {
-			// No need to upgrade to write lock, just release the read lock.
-			l.mut.RUnlock()
-		}
+	if bn, ok := n.(BlockNode); ok {
+		l.onNodeEvaluated(bn, err)
 	}
 
 	// We only use the error for updating the span status
@@ -690,14 +858,184 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 	}
 }
 
+// finishEvaluation caches the result of evaluating n and updates the module
+// exports if they changed.
+func (l *Loader) finishEvaluation(n BlockNode, evalErr error) error {
+	// Only obtain loader lock after we have evaluated the node, allowing for concurrent evaluation.
+	l.mut.RLock()
+	err := l.postEvaluate(l.log, n, evalErr)
+
+	// Additional post-evaluation steps necessary for module exports.
+	if exp, ok := n.(*ExportConfigNode); ok {
+		l.cache.CacheModuleExportValue(exp.Label(), exp.Value())
+	}
+	if l.globals.OnExportsChange != nil && l.cache.ExportChangeIndex() != l.moduleExportIndex {
+		// Upgrade to write lock to update the module exports.
+		l.mut.RUnlock()
+		l.mut.Lock()
+		defer l.mut.Unlock()
+		// Check if the update still needed after obtaining the write lock and perform it.
+		if l.cache.ExportChangeIndex() != l.moduleExportIndex {
+			l.globals.OnExportsChange(l.cache.CreateModuleExports())
+			l.moduleExportIndex = l.cache.ExportChangeIndex()
+		}
+	} else {
+		// No need to upgrade to write lock, just release the read lock.
+		l.mut.RUnlock()
+	}
+	return err
+}
+
+// finishLateEvaluation propagates the result of an evaluation of bn which
+// finished after exceeding the evaluation timeout. The dependants of bn were
+// evaluated without the result, so they're queued for evaluation again. If
+// the evaluation built the component of bn, the node already reported it
+// through OnComponentBuilt, so that the controller schedules it to run.
+func (l *Loader) finishLateEvaluation(bn BlockNode, evalErr error) {
+	l.mut.RLock()
+	current := l.graph.GetByID(bn.NodeID())
+	l.mut.RUnlock()
+	if current != bn {
+		// The node was removed or replaced by a reload.
+		return
+	}
+
+	err := l.finishEvaluation(bn, evalErr)
+	l.onNodeEvaluated(bn, err)
+	if l.globals.OnBlockNodeUpdate != nil {
+		l.globals.OnBlockNodeUpdate(bn)
+	}
+}
+
 // evaluate constructs the final context for the BlockNode and
 // evaluates it. mut must be held when calling evaluate.
 func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
 	ectx := l.cache.BuildContext()
-	err := bn.Evaluate(ectx)
+	err := l.evaluateWithTimeout(bn, ectx)
+	if errors.As(err, new(*evaluationTimeoutError)) {
+		// The node may still be evaluating, so it's not safe to read its
+		// arguments and exports.
+		level.Error(logger).Log("msg", "failed to evaluate config", "node", bn.NodeID(), "err", err)
+		return err
+	}
 	return l.postEvaluate(logger, bn, err)
 }
 
+// evaluationTimeoutError is returned when the evaluation of a node exceeds
+// the evaluation timeout, or when a previous evaluation of the node which
+// exceeded the timeout is still running.
+type evaluationTimeoutError struct {
+	nodeID  string
+	timeout time.Duration
+	pending bool
+}
+
+func (e *evaluationTimeoutError) Error() string {
+	if e.pending {
+		return fmt.Sprintf("previous evaluation of %s exceeded the evaluation timeout of %s and is still running", e.nodeID, e.timeout)
+	}
+	return fmt.Sprintf("evaluation of %s exceeded the evaluation timeout of %s", e.nodeID, e.timeout)
+}
+
+// evaluateWithTimeout evaluates bn, waiting at most for the configured
+// evaluation timeout. Evaluations can't be interrupted, so an evaluation which
+// exceeds the timeout keeps running in the background; bn is marked unhealthy
+// and isn't evaluated again until the running evaluation finishes. The result
+// of the running evaluation is then propagated to the dependants of bn.
+func (l *Loader) evaluateWithTimeout(bn BlockNode, scope *vm.Scope) error {
+	scope = l.nodeScope(bn, scope)
+
+	timeout := l.globals.EvaluationTimeout
+	if timeout <= 0 {
+		return l.evaluateNode(bn, scope)
+	}
+
+	nodeID := bn.NodeID()
+
+	l.pendingEvalsMut.Lock()
+	if _, pending := l.pendingEvals[nodeID]; pending {
+		l.pendingEvalsMut.Unlock()
+		return &evaluationTimeoutError{nodeID: nodeID, timeout: timeout, pending: true}
+	}
+	l.pendingEvalsMut.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- l.evaluateNode(bn, scope) }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	l.pendingEvalsMut.Lock()
+	l.pendingEvals[nodeID] = struct{}{}
+	l.pendingEvalsMut.Unlock()
+
+	err := &evaluationTimeoutError{nodeID: nodeID, timeout: timeout}
+	cn, isComponent := bn.(*BuiltinComponentNode)
+	if isComponent {
+		cn.setEvalResult(err)
+	}
+
+	go func() {
+		evalErr := <-done
+
+		// The evaluation may have finished right before the node was marked
+		// unhealthy above, so report its result again. The result is reported
+		// before the node can be evaluated again, so that it never overwrites
+		// the result of a newer evaluation.
+		l.pendingEvalsMut.Lock()
+		if isComponent {
+			cn.setEvalResult(evalErr)
+		}
+		delete(l.pendingEvals, nodeID)
+		l.pendingEvalsMut.Unlock()
+
+		l.finishLateEvaluation(bn, evalErr)
+	}()
+
+	return err
+}
+
+// evaluateNode evaluates bn and records how long the evaluation took.
+func (l *Loader) evaluateNode(bn BlockNode, scope *vm.Scope) error {
+	start := time.Now()
+	defer func() {
+		l.cm.nodeEvaluationTime.WithLabelValues(nodeComponentName(bn)).Observe(time.Since(start).Seconds())
+	}()
+	return bn.Evaluate(scope)
+}
+
+// nodeComponentName returns the name of the component of bn, or the name of
+// the block of bn if it isn't a component, such as "argument".
+func nodeComponentName(bn BlockNode) string {
+	if cn, ok := bn.(ComponentNode); ok {
+		return cn.ComponentName()
+	}
+	if b := bn.Block(); b != nil {
+		return strings.Join(b.Name, ".")
+	}
+	// Config nodes created for blocks missing from the configuration, such
+	// as the default logging block, have no block.
+	return bn.NodeID()
+}
+
+// endSettle records that an evaluation tracked by settle is done, and
+// observes how long the graph took to settle if it's settled now.
+func (l *Loader) endSettle() {
+	var queued int
+	if l.updateQueue != nil {
+		queued = l.updateQueue.Len()
+	}
+	if took, settled := l.settle.end(queued); settled {
+		l.cm.graphSettleTime.Observe(took.Seconds())
+	}
+}
+
 // postEvaluate is called after a node has been evaluated. It updates the caches and logs any errors.
 // mut must be held when calling postEvaluate.
 func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error {
@@ -706,9 +1044,11 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 		// Always update the cache both the arguments and exports, since both might
 		// change when a component gets re-evaluated. We also want to cache the arguments and exports in case of an error
 		l.cache.CacheArguments(c.ID(), c.Arguments())
-		l.cache.CacheExports(c.ID(), c.Exports())
+		if l.cache.CacheExports(c.ID(), c.Exports()) {
+			l.onExportsChanged(c)
+		}
 	case *ArgumentConfigNode:
-		if _, found := l.cache.moduleArguments[c.Label()]; !found {
+		if value, found := l.cache.GetModuleArgument(c.Label()); !found {
 			if c.Optional() {
 				l.cache.CacheModuleArgument(c.Label(), c.Default())
 			} else {
@@ -716,6 +1056,8 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 				// a more important error to address.
 				err = fmt.Errorf("missing required argument %q to module", c.Label())
 			}
+		} else if typeErr := c.CheckType(value); typeErr != nil && err == nil {
+			err = typeErr
 		}
 	}
 
@@ -1,23 +1,28 @@
 package controller_test
 
 import (
+	"context"
 	"errors"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/internal/worker"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/river/ast"
 	"github.com/grafana/river/diag"
 	"github.com/grafana/river/parser"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/trace/noop"
 
-	_ "github.com/grafana/agent/pkg/flow/internal/testcomponents" // Include test components
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents" // Include test components
 )
 
 func TestLoader(t *testing.T) {
@@ -132,6 +137,15 @@ func TestLoader(t *testing.T) {
 		require.ErrorContains(t, diags.ErrorOrNil(), `Unrecognized component name "doesnotexist`)
 	})
 
+	t.Run("Load with below minimum stability level", func(t *testing.T) {
+		opts := newLoaderOptions()
+		opts.ComponentGlobals.MinStability = featuregate.StabilityBeta
+
+		l := controller.NewLoader(opts)
+		diags := applyFromContent(t, l, []byte(testFile), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `component "testcomponents.tick" is at stability level "experimental", which is below the minimum allowed stability level "beta"`)
+	})
+
 	t.Run("Partial load with invalid reference", func(t *testing.T) {
 		invalidFile := `
 			testcomponents.tick "ticker" {
@@ -180,6 +194,332 @@ func TestLoader(t *testing.T) {
 	})
 }
 
+func TestLoader_EvaluationTimeout(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "slow" {
+			input = "hello"
+			lag   = "500ms"
+		}
+
+		testcomponents.passthrough "fast" {
+			input = "world"
+		}
+
+		testcomponents.passthrough "dependant" {
+			input = testcomponents.passthrough.slow.output
+		}
+	`
+
+	var (
+		l *controller.Loader
+
+		logger, _ = logging.New(os.Stderr, logging.DefaultOptions)
+		updated   = make(chan controller.BlockNode, 10)
+	)
+	l = controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:        logger,
+			TraceProvider: noop.NewTracerProvider(),
+			DataPath:      t.TempDir(),
+			OnBlockNodeUpdate: func(cn controller.BlockNode) {
+				updated <- cn
+			},
+			Registerer:        prometheus.NewRegistry(),
+			EvaluationTimeout: 50 * time.Millisecond,
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+		},
+		WorkerPool: worker.NewFixedWorkerPool(1, 10),
+	})
+	defer l.Cleanup(true)
+
+	start := time.Now()
+	diags := applyFromContent(t, l, []byte(testFile), nil)
+	require.Less(t, time.Since(start), 500*time.Millisecond, "Apply should not wait for the slow component")
+	require.ErrorContains(t, diags.ErrorOrNil(), "evaluation of testcomponents.passthrough.slow exceeded the evaluation timeout of 50ms")
+
+	slow := l.Graph().GetByID("testcomponents.passthrough.slow").(*controller.BuiltinComponentNode)
+	fast := l.Graph().GetByID("testcomponents.passthrough.fast").(*controller.BuiltinComponentNode)
+	require.Equal(t, component.HealthTypeUnhealthy, slow.CurrentHealth().Health)
+	require.NotEqual(t, component.HealthTypeUnhealthy, fast.CurrentHealth().Health)
+
+	// Once the slow evaluation finishes, the component is no longer unhealthy.
+	require.Eventually(t, func() bool {
+		return slow.CurrentHealth().Health != component.HealthTypeUnhealthy
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// The slow component reported its exports while it was being built, and
+	// the late result is propagated once more after the evaluation finished.
+	var slowUpdates int
+	require.Eventually(t, func() bool {
+		select {
+		case cn := <-updated:
+			if cn == slow {
+				slowUpdates++
+			}
+			l.EvaluateDependants(context.Background(), []*controller.QueuedNode{{Node: cn, LastUpdatedTime: time.Now()}})
+		default:
+		}
+		return slowUpdates == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	dependant := l.Graph().GetByID("testcomponents.passthrough.dependant").(*controller.BuiltinComponentNode)
+	require.Eventually(t, func() bool {
+		return dependant.Exports().(testcomponents.PassthroughExports).Output == "hello"
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestLoader_EvaluationTimeout_Runs(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "slow" {
+			input = "hello"
+			lag   = "300ms"
+		}
+	`
+
+	var (
+		logger, _ = logging.New(os.Stderr, logging.DefaultOptions)
+		events    controller.EventBus
+		built     = make(chan controller.ComponentNode, 1)
+	)
+	started, unsubscribe := events.Subscribe(10)
+	defer unsubscribe()
+
+	l := controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:            logger,
+			TraceProvider:     noop.NewTracerProvider(),
+			DataPath:          t.TempDir(),
+			OnBlockNodeUpdate: func(cn controller.BlockNode) { /* no-op */ },
+			OnComponentBuilt:  func(cn controller.ComponentNode) { built <- cn },
+			Registerer:        prometheus.NewRegistry(),
+			EvaluationTimeout: 50 * time.Millisecond,
+			Events:            &events,
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+		},
+		WorkerPool: worker.NewFixedWorkerPool(1, 10),
+	})
+	defer l.Cleanup(true)
+
+	diags := applyFromContent(t, l, []byte(testFile), nil)
+	require.ErrorContains(t, diags.ErrorOrNil(), "exceeded the evaluation timeout")
+
+	sched := controller.NewScheduler()
+	defer sched.Close()
+	synchronize := func() {
+		var runnables []controller.RunnableNode
+		for _, cn := range l.Components() {
+			runnables = append(runnables, cn)
+		}
+		require.NoError(t, sched.Synchronize(runnables))
+	}
+	synchronize()
+
+	// The late evaluation builds the component, which is then scheduled again
+	// and runs.
+	select {
+	case <-built:
+		synchronize()
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "component was never built")
+	}
+	require.Eventually(t, func() bool {
+		select {
+		case e := <-started:
+			return e.Type == controller.EventStarted && e.ComponentID == "testcomponents.passthrough.slow"
+		default:
+			return false
+		}
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestLoader_ConcurrentEvaluation(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "a" {
+			input = "a"
+			lag   = "300ms"
+		}
+
+		testcomponents.passthrough "b" {
+			input = "b"
+			lag   = "300ms"
+		}
+
+		testcomponents.passthrough "joined" {
+			input = testcomponents.passthrough.a.output + testcomponents.passthrough.b.output
+		}
+	`
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	l := controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:                   logger,
+			TraceProvider:            noop.NewTracerProvider(),
+			DataPath:                 t.TempDir(),
+			OnBlockNodeUpdate:        func(cn controller.BlockNode) { /* no-op */ },
+			Registerer:               prometheus.NewRegistry(),
+			MaxConcurrentEvaluations: 2,
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+		},
+	})
+
+	// a and b don't depend on each other, so they're evaluated at the same
+	// time.
+	start := time.Now()
+	diags := applyFromContent(t, l, []byte(testFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+	require.Less(t, time.Since(start), 600*time.Millisecond, "a and b should be evaluated concurrently")
+
+	joined := l.Graph().GetByID("testcomponents.passthrough.joined").(*controller.BuiltinComponentNode)
+	require.Equal(t, "ab", joined.Exports().(testcomponents.PassthroughExports).Output)
+	require.Len(t, l.Components(), 3)
+}
+
+func TestEvaluateDependantsMetrics(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "a" {
+			input = "a"
+		}
+
+		testcomponents.passthrough "b" {
+			input = "b"
+		}
+
+		testcomponents.passthrough "joined" {
+			input = testcomponents.passthrough.a.output + testcomponents.passthrough.b.output
+		}
+	`
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	reg := prometheus.NewRegistry()
+	pool := worker.NewFixedWorkerPool(1, 10)
+	defer pool.Stop()
+
+	l := controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:            logger,
+			TraceProvider:     noop.NewTracerProvider(),
+			DataPath:          t.TempDir(),
+			OnBlockNodeUpdate: func(cn controller.BlockNode) { /* no-op */ },
+			Registerer:        reg,
+			ControllerID:      "test",
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+		},
+		WorkerPool:  pool,
+		UpdateQueue: controller.NewQueue(),
+	})
+	diags := applyFromContent(t, l, []byte(testFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+
+	l.EvaluateDependants(context.Background(), []*controller.QueuedNode{
+		{Node: l.Graph().GetByID("testcomponents.passthrough.a").(controller.BlockNode), LastUpdatedTime: time.Now()},
+		{Node: l.Graph().GetByID("testcomponents.passthrough.b").(controller.BlockNode), LastUpdatedTime: time.Now()},
+	})
+
+	expect := `
+		# HELP agent_component_dependants_coalesced_total Number of dependant evaluations skipped because the dependant was already submitted for evaluation in the same batch
+		# TYPE agent_component_dependants_coalesced_total counter
+		agent_component_dependants_coalesced_total{controller_id="test"} 1
+		# HELP agent_component_update_queue_size Number of updated components waiting for their dependants to be submitted for evaluation.
+		# TYPE agent_component_update_queue_size gauge
+		agent_component_update_queue_size{controller_id="test"} 0
+		# HELP agent_component_updates_total Number of times a component updated its exports and caused its dependants to be evaluated
+		# TYPE agent_component_updates_total counter
+		agent_component_updates_total{component_id="testcomponents.passthrough.a",controller_id="test"} 1
+		agent_component_updates_total{component_id="testcomponents.passthrough.b",controller_id="test"} 1
+	`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect),
+		"agent_component_dependants_coalesced_total",
+		"agent_component_update_queue_size",
+		"agent_component_updates_total",
+	))
+}
+
+func TestEvaluationTimeMetrics(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "a" {
+			input = "a"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+	`
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	reg := prometheus.NewRegistry()
+	pool := worker.NewFixedWorkerPool(1, 10)
+	defer pool.Stop()
+
+	l := controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:            logger,
+			TraceProvider:     noop.NewTracerProvider(),
+			DataPath:          t.TempDir(),
+			OnBlockNodeUpdate: func(cn controller.BlockNode) { /* no-op */ },
+			Registerer:        reg,
+			ControllerID:      "test",
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+		},
+		WorkerPool:  pool,
+		UpdateQueue: controller.NewQueue(),
+	})
+	diags := applyFromContent(t, l, []byte(testFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+
+	// Every node is evaluated once, and the graph settled once the
+	// configuration was applied.
+	require.Equal(t, map[string]uint64{
+		"logging":                    1,
+		"testcomponents.passthrough": 2,
+		"tracing":                    1,
+	}, histogramCounts(t, reg, "agent_component_node_evaluation_seconds", "component_name"))
+	require.Equal(t, map[string]uint64{"": 1}, histogramCounts(t, reg, "agent_component_graph_settle_seconds", ""))
+
+	l.EvaluateDependants(context.Background(), []*controller.QueuedNode{
+		{Node: l.Graph().GetByID("testcomponents.passthrough.a").(controller.BlockNode), LastUpdatedTime: time.Now()},
+	})
+	require.Eventually(t, func() bool {
+		return histogramCounts(t, reg, "agent_component_graph_settle_seconds", "")[""] == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, uint64(3), histogramCounts(t, reg, "agent_component_node_evaluation_seconds", "component_name")["testcomponents.passthrough"])
+}
+
+// histogramCounts returns the number of observations of the histogram with
+// the given name, by the value of label.
+func histogramCounts(t *testing.T, reg *prometheus.Registry, name, label string) map[string]uint64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var value string
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == label {
+					value = lp.GetValue()
+				}
+			}
+			counts[value] += m.GetHistogram().GetSampleCount()
+		}
+	}
+	return counts
+}
+
 // TestScopeWithFailingComponent is used to ensure that the scope is filled out, even if the component
 // fails to properly start.
 func TestScopeWithFailingComponent(t *testing.T) {
@@ -244,7 +584,7 @@ func applyFromContent(t *testing.T, l *controller.Loader, componentBytes []byte,
 		}
 	}
 
-	applyDiags := l.Apply(nil, componentBlocks, configBlocks)
+	applyDiags := l.Apply(context.Background(), nil, componentBlocks, configBlocks)
 	diags = append(diags, applyDiags...)
 
 	return diags
@@ -318,3 +658,55 @@ func (f fakeModuleController) ModuleIDs() []string {
 
 func (f fakeModuleController) ClearModuleIDs() {
 }
+
+func TestGraphSizeMetrics(t *testing.T) {
+	testFile := `
+		testcomponents.passthrough "a" {
+			input = "a"
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+
+		testcomponents.passthrough "c" {
+			input = testcomponents.passthrough.a.output + testcomponents.passthrough.b.output
+		}
+	`
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	reg := prometheus.NewRegistry()
+
+	l := controller.NewLoader(controller.LoaderOptions{
+		ComponentGlobals: controller.ComponentGlobals{
+			Logger:            logger,
+			TraceProvider:     noop.NewTracerProvider(),
+			DataPath:          t.TempDir(),
+			OnBlockNodeUpdate: func(cn controller.BlockNode) { /* no-op */ },
+			Registerer:        reg,
+			ControllerID:      "test",
+			NewModuleController: func(id string) controller.ModuleController {
+				return nil
+			},
+		},
+	})
+	diags := applyFromContent(t, l, []byte(testFile), nil)
+	require.NoError(t, diags.ErrorOrNil())
+
+	// The edge from c to a is counted even though it's implied by the edges
+	// from c to b and b to a. The implicit logging and tracing blocks are
+	// counted as config nodes.
+	expect := `
+		# HELP agent_component_controller_graph_edges Number of references between nodes in the component graph.
+		# TYPE agent_component_controller_graph_edges gauge
+		agent_component_controller_graph_edges{controller_id="test"} 3
+		# HELP agent_component_controller_graph_nodes Number of nodes in the component graph by kind.
+		# TYPE agent_component_controller_graph_nodes gauge
+		agent_component_controller_graph_nodes{controller_id="test",kind="component"} 3
+		agent_component_controller_graph_nodes{controller_id="test",kind="config"} 2
+	`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect),
+		"agent_component_controller_graph_edges",
+		"agent_component_controller_graph_nodes",
+	))
+}
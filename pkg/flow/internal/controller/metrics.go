@@ -11,10 +11,16 @@ import (
 type controllerMetrics struct {
 	controllerEvaluation        prometheus.Gauge
 	componentEvaluationTime     prometheus.Histogram
+	nodeEvaluationTime          *prometheus.HistogramVec
+	graphSettleTime             prometheus.Histogram
 	dependenciesWaitTime        prometheus.Histogram
 	evaluationQueueSize         prometheus.Gauge
 	slowComponentThreshold      time.Duration
 	slowComponentEvaluationTime *prometheus.CounterVec
+	componentUpdates            *prometheus.CounterVec
+	updateBatchSize             prometheus.Histogram
+	coalescedEvaluations        prometheus.Counter
+	evaluationRetries           prometheus.Counter
 }
 
 // newControllerMetrics inits the metrics for the components controller
@@ -42,6 +48,23 @@ func newControllerMetrics(id string) *controllerMetrics {
 			Buckets:     evaluationTimesBuckets,
 		},
 	)
+	cm.nodeEvaluationTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "agent_component_node_evaluation_seconds",
+			Help:        "Time spent evaluating nodes of the component graph, by component name",
+			ConstLabels: map[string]string{"controller_id": id},
+			Buckets:     evaluationTimesBuckets,
+		},
+		[]string{"component_name"},
+	)
+	cm.graphSettleTime = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:        "agent_component_graph_settle_seconds",
+			Help:        "Time from a change of the component graph until all evaluations caused by it are done",
+			ConstLabels: map[string]string{"controller_id": id},
+			Buckets:     evaluationTimesBuckets,
+		},
+	)
 	cm.dependenciesWaitTime = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Name:        "agent_component_dependencies_wait_seconds",
@@ -63,6 +86,31 @@ func newControllerMetrics(id string) *controllerMetrics {
 		ConstLabels: map[string]string{"controller_id": id},
 	}, []string{"component_id"})
 
+	cm.componentUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "agent_component_updates_total",
+		Help:        "Number of times a component updated its exports and caused its dependants to be evaluated",
+		ConstLabels: map[string]string{"controller_id": id},
+	}, []string{"component_id"})
+
+	cm.updateBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:        "agent_component_update_batch_size",
+		Help:        "Number of updated components whose dependants are submitted for evaluation together",
+		ConstLabels: map[string]string{"controller_id": id},
+		Buckets:     []float64{1, 2, 5, 10, 25, 50, 100, 250},
+	})
+
+	cm.coalescedEvaluations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "agent_component_dependants_coalesced_total",
+		Help:        "Number of dependant evaluations skipped because the dependant was already submitted for evaluation in the same batch",
+		ConstLabels: map[string]string{"controller_id": id},
+	})
+
+	cm.evaluationRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "agent_component_evaluation_retries_total",
+		Help:        "Number of component evaluations retried after a failed evaluation",
+		ConstLabels: map[string]string{"controller_id": id},
+	})
+
 	return cm
 }
 
@@ -75,34 +123,97 @@ func (cm *controllerMetrics) onComponentEvaluationDone(name string, duration tim
 
 func (cm *controllerMetrics) Collect(ch chan<- prometheus.Metric) {
 	cm.componentEvaluationTime.Collect(ch)
+	cm.nodeEvaluationTime.Collect(ch)
+	cm.graphSettleTime.Collect(ch)
 	cm.controllerEvaluation.Collect(ch)
 	cm.dependenciesWaitTime.Collect(ch)
 	cm.evaluationQueueSize.Collect(ch)
 	cm.slowComponentEvaluationTime.Collect(ch)
+	cm.componentUpdates.Collect(ch)
+	cm.updateBatchSize.Collect(ch)
+	cm.coalescedEvaluations.Collect(ch)
+	cm.evaluationRetries.Collect(ch)
 }
 
 func (cm *controllerMetrics) Describe(ch chan<- *prometheus.Desc) {
 	cm.componentEvaluationTime.Describe(ch)
+	cm.nodeEvaluationTime.Describe(ch)
+	cm.graphSettleTime.Describe(ch)
 	cm.controllerEvaluation.Describe(ch)
 	cm.dependenciesWaitTime.Describe(ch)
 	cm.evaluationQueueSize.Describe(ch)
 	cm.slowComponentEvaluationTime.Describe(ch)
+	cm.componentUpdates.Describe(ch)
+	cm.updateBatchSize.Describe(ch)
+	cm.coalescedEvaluations.Describe(ch)
+	cm.evaluationRetries.Describe(ch)
 }
 
 type controllerCollector struct {
 	l                      *Loader
+	maxComponentSeries     int
 	runningComponentsTotal *prometheus.Desc
+	truncatedSeries        *prometheus.Desc
+	updateQueueSize        *prometheus.Desc
+	updateQueueCoalesced   *prometheus.Desc
+	updateQueueSuppressed  *prometheus.Desc
+	graphNodes             *prometheus.Desc
+	graphEdges             *prometheus.Desc
+	moduleInstances        *prometheus.Desc
 }
 
-func newControllerCollector(l *Loader, id string) *controllerCollector {
+func newControllerCollector(l *Loader, id string, maxComponentSeries int) *controllerCollector {
 	return &controllerCollector{
-		l: l,
+		l:                  l,
+		maxComponentSeries: maxComponentSeries,
 		runningComponentsTotal: prometheus.NewDesc(
 			"agent_component_controller_running_components",
 			"Total number of running components.",
 			[]string{"health_type"},
 			map[string]string{"controller_id": id},
 		),
+		truncatedSeries: prometheus.NewDesc(
+			"agent_component_metrics_truncated_series",
+			"Number of series dropped from the last collection of a component's metrics because the component exceeded the series limit.",
+			[]string{"component_id"},
+			map[string]string{"controller_id": id},
+		),
+		updateQueueSize: prometheus.NewDesc(
+			"agent_component_update_queue_size",
+			"Number of updated components waiting for their dependants to be submitted for evaluation.",
+			nil,
+			map[string]string{"controller_id": id},
+		),
+		updateQueueCoalesced: prometheus.NewDesc(
+			"agent_component_update_queue_coalesced_total",
+			"Number of component updates merged with an update of the same component which was already queued.",
+			nil,
+			map[string]string{"controller_id": id},
+		),
+		updateQueueSuppressed: prometheus.NewDesc(
+			"agent_component_update_queue_suppressed_total",
+			"Number of component updates held back because the component's dependants were evaluated less than the minimum update interval ago.",
+			nil,
+			map[string]string{"controller_id": id},
+		),
+		graphNodes: prometheus.NewDesc(
+			"agent_component_controller_graph_nodes",
+			"Number of nodes in the component graph by kind.",
+			[]string{"kind"},
+			map[string]string{"controller_id": id},
+		),
+		graphEdges: prometheus.NewDesc(
+			"agent_component_controller_graph_edges",
+			"Number of references between nodes in the component graph.",
+			nil,
+			map[string]string{"controller_id": id},
+		),
+		moduleInstances: prometheus.NewDesc(
+			"agent_component_controller_module_instances",
+			"Number of module instances created by components of the controller.",
+			[]string{"component_name"},
+			map[string]string{"controller_id": id},
+		),
 	}
 }
 
@@ -113,15 +224,72 @@ func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 		health := component.CurrentHealth().Health.String()
 		componentsByHealth[health]++
 		if builtinComponent, ok := component.(*BuiltinComponentNode); ok {
-			builtinComponent.registry.Collect(ch)
+			truncated := collectWithLimit(builtinComponent.getRegistry(), cc.maxComponentSeries, ch)
+			if truncated > 0 {
+				ch <- prometheus.MustNewConstMetric(cc.truncatedSeries, prometheus.GaugeValue, float64(truncated), builtinComponent.globalID)
+			}
 		}
 	}
 
 	for health, count := range componentsByHealth {
 		ch <- prometheus.MustNewConstMetric(cc.runningComponentsTotal, prometheus.GaugeValue, float64(count), health)
 	}
+
+	stats := cc.l.graphStats()
+	for kind, count := range stats.nodesByKind {
+		ch <- prometheus.MustNewConstMetric(cc.graphNodes, prometheus.GaugeValue, float64(count), kind)
+	}
+	ch <- prometheus.MustNewConstMetric(cc.graphEdges, prometheus.GaugeValue, float64(stats.edges))
+	for name, count := range stats.moduleInstances {
+		ch <- prometheus.MustNewConstMetric(cc.moduleInstances, prometheus.GaugeValue, float64(count), name)
+	}
+
+	if cc.l.updateQueue != nil {
+		ch <- prometheus.MustNewConstMetric(cc.updateQueueSize, prometheus.GaugeValue, float64(cc.l.updateQueue.Len()))
+
+		coalesced, suppressed := cc.l.updateQueue.Stats()
+		ch <- prometheus.MustNewConstMetric(cc.updateQueueCoalesced, prometheus.CounterValue, float64(coalesced))
+		ch <- prometheus.MustNewConstMetric(cc.updateQueueSuppressed, prometheus.CounterValue, float64(suppressed))
+	}
 }
 
 func (cc *controllerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- cc.runningComponentsTotal
+	ch <- cc.truncatedSeries
+	ch <- cc.updateQueueSize
+	ch <- cc.updateQueueCoalesced
+	ch <- cc.updateQueueSuppressed
+	ch <- cc.graphNodes
+	ch <- cc.graphEdges
+	ch <- cc.moduleInstances
+}
+
+// collectWithLimit collects metrics from c and forwards at most limit of them
+// to ch. The number of metrics which were dropped is returned. If limit is 0
+// or less, all metrics are forwarded.
+//
+// Which metrics are dropped once the limit is exceeded is not guaranteed to be
+// stable across collections.
+func collectWithLimit(c prometheus.Collector, limit int, ch chan<- prometheus.Metric) (truncated int) {
+	if limit <= 0 {
+		c.Collect(ch)
+		return 0
+	}
+
+	inner := make(chan prometheus.Metric)
+	go func() {
+		defer close(inner)
+		c.Collect(inner)
+	}()
+
+	var sent int
+	for m := range inner {
+		if sent >= limit {
+			truncated++
+			continue
+		}
+		ch <- m
+		sent++
+	}
+	return truncated
 }
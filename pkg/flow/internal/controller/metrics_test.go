@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectWithLimit(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	for i := 0; i < 10; i++ {
+		reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: fmt.Sprintf("test_gauge_%d", i),
+			Help: "Test gauge.",
+		}))
+	}
+
+	tt := []struct {
+		name            string
+		limit           int
+		expectCollected int
+		expectTruncated int
+	}{
+		{name: "no limit", limit: 0, expectCollected: 10, expectTruncated: 0},
+		{name: "under limit", limit: 20, expectCollected: 10, expectTruncated: 0},
+		{name: "over limit", limit: 4, expectCollected: 4, expectTruncated: 6},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ch := make(chan prometheus.Metric, 20)
+			truncated := collectWithLimit(reg, tc.limit, ch)
+			close(ch)
+
+			require.Equal(t, tc.expectTruncated, truncated)
+			require.Len(t, ch, tc.expectCollected)
+		})
+	}
+}
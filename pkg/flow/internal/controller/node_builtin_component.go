@@ -8,16 +8,19 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime/pprof"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/logging/level"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/river/ast"
+	"github.com/grafana/river/diag"
 	"github.com/grafana/river/vm"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
@@ -62,15 +65,25 @@ type DialFunc func(ctx context.Context, network, address string) (net.Conn, erro
 // ComponentGlobals are used by BuiltinComponentNodes to build managed components. All
 // BuiltinComponentNodes should use the same ComponentGlobals.
 type ComponentGlobals struct {
-	Logger              *logging.Logger                        // Logger shared between all managed components.
-	TraceProvider       trace.TracerProvider                   // Tracer shared between all managed components.
-	DataPath            string                                 // Shared directory where component data may be stored
-	OnBlockNodeUpdate   func(cn BlockNode)                     // Informs controller that we need to reevaluate
-	OnExportsChange     func(exports map[string]any)           // Invoked when the managed component updated its exports
-	Registerer          prometheus.Registerer                  // Registerer for serving agent and component metrics
-	ControllerID        string                                 // ID of controller.
-	NewModuleController func(id string) ModuleController       // Func to generate a module controller.
-	GetServiceData      func(name string) (interface{}, error) // Get data for a service.
+	Logger                   *logging.Logger                        // Logger shared between all managed components.
+	TraceProvider            trace.TracerProvider                   // Tracer shared between all managed components.
+	DataPath                 string                                 // Shared directory where component data may be stored
+	OnBlockNodeUpdate        func(cn BlockNode)                     // Informs controller that we need to reevaluate
+	OnComponentBuilt         func(cn ComponentNode)                 // Informs controller that a managed component was built and must be scheduled; may be nil.
+	OnExportsChange          func(exports map[string]any)           // Invoked when the managed component updated its exports
+	Registerer               prometheus.Registerer                  // Registerer for serving agent and component metrics
+	ControllerID             string                                 // ID of controller.
+	NewModuleController      func(id string) ModuleController       // Func to generate a module controller.
+	GetServiceData           func(name string) (interface{}, error) // Get data for a service.
+	MaxComponentSeries       int                                    // Maximum number of series collected per component; 0 means no limit.
+	MinStability             featuregate.Stability                  // Minimum allowed stability level of components.
+	EvaluationTimeout        time.Duration                          // Maximum time to wait for a single node evaluation; 0 means no limit.
+	AgentID                  string                                 // ID of the agent running the controller.
+	ClusterName              string                                 // Name of the cluster the agent belongs to.
+	ComponentLookup          *ComponentLookup                       // Implements component.exports; may be nil.
+	MaxConcurrentEvaluations int                                    // Maximum number of independent nodes evaluated at the same time by Apply; 0 or 1 evaluates one at a time.
+	Events                   *EventBus                              // Bus to publish component events to; may be nil.
+	ValidateOnly             bool                                   // Decode and validate arguments without building components other than module loaders.
 }
 
 // BuiltinComponentNode is a controller node which manages a builtin component.
@@ -86,16 +99,44 @@ type BuiltinComponentNode struct {
 	nodeID            string // Cached from id.String() to avoid allocating new strings every time NodeID is called.
 	reg               component.Registration
 	managedOpts       component.Options
-	registry          *prometheus.Registry
 	exportsType       reflect.Type
 	moduleController  ModuleController
+	events            *EventBus          // Bus to publish events of the component to; may be nil.
+	validateOnly      bool               // Whether the component is only validated.
 	OnBlockNodeUpdate func(cn BlockNode) // Informs controller that we need to reevaluate
+	onComponentBuilt  func(cn ComponentNode)
 
-	mut     sync.RWMutex
-	block   *ast.BlockStmt // Current River block to derive args from
-	eval    *vm.Evaluator
-	managed component.Component // Inner managed component
-	args    component.Arguments // Evaluated arguments for the managed component
+	registryMut sync.RWMutex
+	registry    *prometheus.Registry // Registry of the managed component's metrics
+
+	mut      sync.RWMutex
+	managed  component.Component // Inner managed component
+	args     component.Arguments // Evaluated arguments for the managed component
+	disabled bool                // Whether the enabled attribute evaluated to false
+
+	// unbuiltRefs are the references of the block to exports of other
+	// components. When validating, those components aren't built, so their
+	// exports are zero values.
+	unbuiltRefs []Reference
+
+	// enabledChanged is signaled when the managed component is built after the
+	// node was disabled, so that Run starts it.
+	enabledChanged chan struct{}
+
+	// runMut guards the context of the running managed component, so that
+	// evaluate can stop it when the node is disabled.
+	runMut    sync.Mutex
+	runCancel context.CancelFunc
+	runDone   chan struct{}
+
+	// NOTE: block and eval have their own mutex so that the block of a
+	// component can be read and updated while an evaluation which exceeded
+	// the evaluation timeout is still holding mut.
+
+	blockMut sync.RWMutex
+	block    *ast.BlockStmt // Current River block to derive args from
+	eval     *vm.Evaluator
+	enabled  *vm.Evaluator // Evaluator of the enabled attribute; nil if unset
 
 	// NOTE(rfratto): health and exports have their own mutex because they may be
 	// set asynchronously while mut is still being held (i.e., when calling Evaluate
@@ -103,8 +144,11 @@ type BuiltinComponentNode struct {
 
 	healthMut  sync.RWMutex
 	evalHealth component.Health // Health of the last evaluate
+	evalErr    error            // Error of the last evaluate
 	runHealth  component.Health // Health of running the component
 
+	healthHistory healthHistory // Recent transitions of the controller-reported health
+
 	exportsMut sync.RWMutex
 	exports    component.Exports // Evaluated exports for the managed component
 }
@@ -144,10 +188,14 @@ func NewBuiltinComponentNode(globals ComponentGlobals, reg component.Registratio
 		reg:               reg,
 		exportsType:       getExportsType(reg),
 		moduleController:  globals.NewModuleController(globalID),
+		events:            globals.Events,
+		validateOnly:      globals.ValidateOnly,
 		OnBlockNodeUpdate: globals.OnBlockNodeUpdate,
+		onComponentBuilt:  globals.OnComponentBuilt,
 
 		block: b,
-		eval:  vm.New(b.Body),
+
+		enabledChanged: make(chan struct{}, 1),
 
 		// Prepopulate arguments and exports with their zero values.
 		args:    reg.Args,
@@ -156,20 +204,19 @@ func NewBuiltinComponentNode(globals ComponentGlobals, reg component.Registratio
 		evalHealth: initHealth,
 		runHealth:  initHealth,
 	}
+	cn.eval, cn.enabled = newComponentEvaluators(b)
 	cn.managedOpts = getManagedOptions(globals, cn)
+	cn.healthHistory.Record(initHealth)
 
 	return cn
 }
 
 func getManagedOptions(globals ComponentGlobals, cn *BuiltinComponentNode) component.Options {
-	cn.registry = prometheus.NewRegistry()
 	return component.Options{
-		ID:     cn.globalID,
-		Logger: log.With(globals.Logger, "component", cn.globalID),
-		Registerer: prometheus.WrapRegistererWith(prometheus.Labels{
-			"component_id": cn.globalID,
-		}, cn.registry),
-		Tracer: tracing.WrapTracer(globals.TraceProvider, cn.globalID),
+		ID:         cn.globalID,
+		Logger:     log.With(globals.Logger, "component", cn.globalID),
+		Registerer: cn.newRegisterer(),
+		Tracer:     tracing.WrapTracer(globals.TraceProvider, cn.globalID),
 
 		DataPath: filepath.Join(globals.DataPath, cn.globalID),
 
@@ -182,6 +229,28 @@ func getManagedOptions(globals ComponentGlobals, cn *BuiltinComponentNode) compo
 	}
 }
 
+// newRegisterer replaces the registry of the node with an empty one and
+// returns a Registerer for the managed component which registers into it.
+func (cn *BuiltinComponentNode) newRegisterer() prometheus.Registerer {
+	reg := prometheus.NewRegistry()
+
+	cn.registryMut.Lock()
+	cn.registry = reg
+	cn.registryMut.Unlock()
+
+	return prometheus.WrapRegistererWith(prometheus.Labels{
+		"component_id": cn.globalID,
+	}, reg)
+}
+
+// getRegistry returns the registry which holds the metrics of the managed
+// component.
+func (cn *BuiltinComponentNode) getRegistry() *prometheus.Registry {
+	cn.registryMut.RLock()
+	defer cn.registryMut.RUnlock()
+	return cn.registry
+}
+
 func getExportsType(reg component.Registration) reflect.Type {
 	if reg.Exports != nil {
 		return reflect.TypeOf(reg.Exports)
@@ -225,10 +294,30 @@ func (cn *BuiltinComponentNode) UpdateBlock(b *ast.BlockStmt) {
 		panic("UpdateBlock called with an River block with a different component ID")
 	}
 
-	cn.mut.Lock()
-	defer cn.mut.Unlock()
+	cn.blockMut.Lock()
+	defer cn.blockMut.Unlock()
 	cn.block = b
-	cn.eval = vm.New(b.Body)
+	cn.eval, cn.enabled = newComponentEvaluators(b)
+}
+
+// enabledAttr is the name of the attribute which can be set on any component
+// block to turn the component on and off. It's handled by the controller and
+// never passed to the component.
+const enabledAttr = "enabled"
+
+// newComponentEvaluators returns the evaluator for the arguments of the
+// component declared by b, and the evaluator for its enabled attribute. The
+// enabled evaluator is nil if the attribute isn't set.
+func newComponentEvaluators(b *ast.BlockStmt) (args *vm.Evaluator, enabled *vm.Evaluator) {
+	body := make(ast.Body, 0, len(b.Body))
+	for _, stmt := range b.Body {
+		if attr, ok := stmt.(*ast.AttributeStmt); ok && attr.Name.Name == enabledAttr {
+			enabled = vm.New(attr.Value)
+			continue
+		}
+		body = append(body, stmt)
+	}
+	return vm.New(body), enabled
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed component
@@ -238,57 +327,227 @@ func (cn *BuiltinComponentNode) UpdateBlock(b *ast.BlockStmt) {
 // Evaluate will return an error if the River block cannot be evaluated or if
 // decoding to arguments fails.
 func (cn *BuiltinComponentNode) Evaluate(scope *vm.Scope) error {
-	err := cn.evaluate(scope)
+	var (
+		updated bool
+		err     error
+	)
+	pprof.Do(context.Background(), cn.pprofLabels(), func(context.Context) {
+		updated, err = cn.evaluate(scope)
+	})
+	cn.setEvalResult(err)
+	if updated {
+		cn.publishEvent(EventReloaded)
+	}
+	return err
+}
+
+// SetUnbuiltReferences sets the references of the block to exports of
+// components which aren't built while validating. Errors decoding those
+// exports are ignored, since the exports are zero values.
+func (cn *BuiltinComponentNode) SetUnbuiltReferences(refs []Reference) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.unbuiltRefs = refs
+}
+
+// causedByUnbuiltExports reports whether err, returned from evaluating the
+// block, may be caused by a zero value export of a component which isn't
+// built. Errors without a position can't be attributed to an expression, so
+// they're attributed to the exports if the block references any.
+// causedByUnbuiltExports must only be called with cn.mut held.
+func (cn *BuiltinComponentNode) causedByUnbuiltExports(err error) bool {
+	var d diag.Diagnostic
+	if !errors.As(err, &d) || !d.StartPos.Valid() {
+		return len(cn.unbuiltRefs) > 0
+	}
+	end := d.EndPos
+	if !end.Valid() {
+		end = d.StartPos
+	}
+	return cn.referencesUnbuiltExports(d.StartPos.Offset, end.Offset)
+}
+
+// enabledDependsOnUnbuiltExports reports whether the enabled attribute of
+// block references exports of components which aren't built.
+// enabledDependsOnUnbuiltExports must only be called with cn.mut held.
+func (cn *BuiltinComponentNode) enabledDependsOnUnbuiltExports(block *ast.BlockStmt) bool {
+	for _, stmt := range block.Body {
+		if attr, ok := stmt.(*ast.AttributeStmt); ok && attr.Name.Name == enabledAttr {
+			start := ast.StartPos(attr.Value).Position().Offset
+			end := ast.EndPos(attr.Value).Position().Offset
+			return cn.referencesUnbuiltExports(start, end)
+		}
+	}
+	return false
+}
+
+// referencesUnbuiltExports reports whether any reference to exports of
+// components which aren't built is between the start and end offsets.
+// referencesUnbuiltExports must only be called with cn.mut held.
+func (cn *BuiltinComponentNode) referencesUnbuiltExports(start, end int) bool {
+	for _, ref := range cn.unbuiltRefs {
+		offset := ref.Pos.Position().Offset
+		if offset >= start && offset <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// isModuleLoader reports whether the component loads modules. Module loaders
+// are recognized by name, since whether a component creates modules is only
+// known once it's built.
+func (cn *BuiltinComponentNode) isModuleLoader() bool {
+	return strings.HasPrefix(cn.componentName, "module.")
+}
 
-	switch err {
-	case nil:
+// setEvalResult updates the evaluation health based on the result of an
+// evaluation.
+func (cn *BuiltinComponentNode) setEvalResult(err error) {
+	cn.healthMut.Lock()
+	cn.evalErr = err
+	cn.healthMut.Unlock()
+
+	switch {
+	case err == nil && cn.isDisabled():
+		cn.setEvalHealth(component.HealthTypeHealthy, "component disabled")
+	case err == nil:
 		cn.setEvalHealth(component.HealthTypeHealthy, "component evaluated")
 	default:
 		msg := fmt.Sprintf("component evaluation failed: %s", err)
 		cn.setEvalHealth(component.HealthTypeUnhealthy, msg)
 	}
-	return err
 }
 
-func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
+// evaluate evaluates the block of the node and builds or updates the managed
+// component. evaluate reports whether an existing managed component was
+// updated with new arguments.
+func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) (bool, error) {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
 
+	cn.blockMut.RLock()
+	block, eval, enabledEval := cn.block, cn.eval, cn.enabled
+	cn.blockMut.RUnlock()
+
+	if enabledEval != nil {
+		var enabled bool
+		if err := enabledEval.Evaluate(scope, &enabled); err != nil {
+			if cn.validateOnly && cn.causedByUnbuiltExports(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("decoding River: %s: %w", enabledAttr, err)
+		}
+		// Whether a component is enabled isn't known while validating if it
+		// depends on exports of components which aren't built, so its
+		// arguments are validated anyway.
+		if !enabled && !(cn.validateOnly && cn.enabledDependsOnUnbuiltExports(block)) {
+			cn.disable()
+			return false, nil
+		}
+	}
+	wasDisabled := cn.disabled
+	cn.disabled = false
+
 	argsPointer := cn.reg.CloneArguments()
-	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
-		return fmt.Errorf("decoding River: %w", err)
+	if err := eval.Evaluate(scope, argsPointer); err != nil {
+		if cn.validateOnly && cn.causedByUnbuiltExports(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("decoding River: %w", err)
 	}
 
 	// args is always a pointer to the args type, so we want to deference it since
 	// components expect a non-pointer.
 	argsCopyValue := reflect.ValueOf(argsPointer).Elem().Interface()
 
+	if cn.validateOnly && (!cn.isModuleLoader() || len(cn.unbuiltRefs) > 0) {
+		// The arguments were validated while decoding them. Building the
+		// component could have side effects, such as opening listeners. Module
+		// loaders are built so that the content of their modules is validated
+		// too, unless their arguments depend on exports which aren't known.
+		cn.args = argsCopyValue
+		return false, nil
+	}
+
 	if cn.managed == nil {
 		// We haven't built the managed component successfully yet.
 		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
 		if err != nil {
-			return fmt.Errorf("building component: %w", err)
+			// Drop the metrics registered by the failed build, so that the
+			// component can register them again when the build is retried.
+			cn.managedOpts.Registerer = cn.newRegisterer()
+			return false, fmt.Errorf("building component: %w", err)
 		}
+		// managed is also read by currentHealth while only holding healthMut,
+		// which may happen while a slow evaluation is still holding mut.
+		cn.healthMut.Lock()
 		cn.managed = managed
+		cn.healthMut.Unlock()
 		cn.args = argsCopyValue
 
-		return nil
+		if wasDisabled {
+			select {
+			case cn.enabledChanged <- struct{}{}:
+			default:
+			}
+		}
+
+		// Run returns immediately if it's called before the managed component is
+		// built. Components built after they were scheduled, such as when a
+		// failed evaluation is retried, must be scheduled again to run.
+		if cn.onComponentBuilt != nil {
+			cn.onComponentBuilt(cn)
+		}
+		return false, nil
 	}
 
 	if reflect.DeepEqual(cn.args, argsCopyValue) {
 		// Ignore components which haven't changed. This reduces the cost of
 		// calling evaluate for components where evaluation is expensive (e.g., if
 		// re-evaluating requires re-starting some internal logic).
-		return nil
+		return false, nil
 	}
 
 	// Update the existing managed component
 	if err := cn.managed.Update(argsCopyValue); err != nil {
-		return fmt.Errorf("updating component: %w", err)
+		return false, fmt.Errorf("updating component: %w", err)
 	}
 
 	cn.args = argsCopyValue
-	return nil
+	return true, nil
+}
+
+// disable stops and drops the managed component, if one was built. The
+// component is built again once the node is enabled. disable must only be
+// called with mut held.
+func (cn *BuiltinComponentNode) disable() {
+	if cn.disabled {
+		return
+	}
+	cn.disabled = true
+
+	if cn.managed == nil {
+		return
+	}
+
+	cn.runMut.Lock()
+	cancel, done := cn.runCancel, cn.runDone
+	cn.runMut.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	cn.healthMut.Lock()
+	cn.managed = nil
+	cn.healthMut.Unlock()
+	cn.args = cn.reg.Args
+	cn.managedOpts.Registerer = cn.newRegisterer()
+
+	if cn.exportsType != nil {
+		cn.setExports(cn.reg.Exports)
+	}
 }
 
 // Run runs the managed component in the calling goroutine until ctx is
@@ -297,30 +556,99 @@ func (cn *BuiltinComponentNode) evaluate(scope *vm.Scope) error {
 //
 // Run will immediately return ErrUnevaluated if Evaluate has never been called
 // successfully. Otherwise, Run will return nil.
+//
+// While the node is disabled, Run doesn't run a managed component and waits
+// for the node to be enabled again.
 func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
-	cn.mut.RLock()
-	managed := cn.managed
-	cn.mut.RUnlock()
+	for {
+		managed, runCtx, disabled := cn.startRun(ctx)
+		if disabled {
+			cn.setRunHealth(component.HealthTypeHealthy, "component disabled")
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-cn.enabledChanged:
+				continue
+			}
+		}
+		if managed == nil {
+			return ErrUnevaluated
+		}
+
+		cn.setRunHealth(component.HealthTypeHealthy, "started component")
+		cn.publishEvent(EventStarted)
+
+		// Goroutines started by the component inherit the labels, so profiles can
+		// be attributed to the component which started them.
+		var err error
+		pprof.Do(runCtx, cn.pprofLabels(), func(ctx context.Context) {
+			err = managed.Run(ctx)
+		})
+		cn.finishRun()
+
+		logger := cn.managedOpts.Logger
+		if ctx.Err() == nil && cn.isDisabled() {
+			level.Info(logger).Log("msg", "component stopped, since it was disabled")
+			cn.publishEvent(EventStopped)
+			continue
+		}
 
-	if managed == nil {
-		return ErrUnevaluated
+		var exitMsg string
+		if err != nil {
+			level.Error(logger).Log("msg", "component exited with error", "err", err)
+			exitMsg = fmt.Sprintf("component shut down with error: %s", err)
+		} else {
+			level.Info(logger).Log("msg", "component exited")
+			exitMsg = "component shut down normally"
+		}
+
+		cn.setRunHealth(component.HealthTypeExited, exitMsg)
+		cn.publishEvent(EventStopped)
+		return err
 	}
+}
 
-	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+func (cn *BuiltinComponentNode) isDisabled() bool {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.disabled
+}
 
-	var exitMsg string
-	logger := cn.managedOpts.Logger
-	if err != nil {
-		level.Error(logger).Log("msg", "component exited with error", "err", err)
-		exitMsg = fmt.Sprintf("component shut down with error: %s", err)
-	} else {
-		level.Info(logger).Log("msg", "component exited")
-		exitMsg = "component shut down normally"
+// startRun returns the managed component to run and the context to run it
+// with, or reports that the node is disabled. The context is canceled when
+// the node is disabled; finishRun must be called once the managed component
+// exits.
+func (cn *BuiltinComponentNode) startRun(ctx context.Context) (component.Component, context.Context, bool) {
+	// mut is held while the run is recorded, so that disable can't drop the
+	// managed component without stopping it.
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	if cn.disabled || cn.managed == nil {
+		return nil, nil, cn.disabled
 	}
 
-	cn.setRunHealth(component.HealthTypeExited, exitMsg)
-	return err
+	runCtx, cancel := context.WithCancel(ctx)
+	cn.runMut.Lock()
+	cn.runCancel, cn.runDone = cancel, make(chan struct{})
+	cn.runMut.Unlock()
+	return cn.managed, runCtx, false
+}
+
+// finishRun records that the managed component started by startRun exited.
+func (cn *BuiltinComponentNode) finishRun() {
+	cn.runMut.Lock()
+	defer cn.runMut.Unlock()
+
+	cn.runCancel()
+	close(cn.runDone)
+	cn.runCancel, cn.runDone = nil, nil
+}
+
+// pprofLabels returns the profiler labels of goroutines running or
+// evaluating the managed component.
+func (cn *BuiltinComponentNode) pprofLabels() pprof.LabelSet {
+	return pprof.Labels("component_id", cn.globalID, "component_name", cn.componentName)
 }
 
 // ErrUnevaluated is returned if BuiltinComponentNode.Run is called before a managed
@@ -336,8 +664,8 @@ func (cn *BuiltinComponentNode) Arguments() component.Arguments {
 
 // Block implements BlockNode and returns the current block of the managed component.
 func (cn *BuiltinComponentNode) Block() *ast.BlockStmt {
-	cn.mut.RLock()
-	defer cn.mut.RUnlock()
+	cn.blockMut.RLock()
+	defer cn.blockMut.RUnlock()
 	return cn.block
 }
 
@@ -405,6 +733,26 @@ func (cn *BuiltinComponentNode) CurrentHealth() component.Health {
 	return component.LeastHealthy(runHealth, evalHealth)
 }
 
+// LastEvaluation returns the time when the BuiltinComponentNode was last
+// evaluated, or the zero time if it hasn't been evaluated yet.
+func (cn *BuiltinComponentNode) LastEvaluation() time.Time {
+	cn.healthMut.RLock()
+	defer cn.healthMut.RUnlock()
+
+	if cn.evalHealth.Health == component.HealthTypeUnknown {
+		return time.Time{}
+	}
+	return cn.evalHealth.UpdateTime
+}
+
+// HealthHistory returns the most recent transitions of the health of the
+// BuiltinComponentNode, ordered from oldest to newest. Only the health from
+// Run and Evaluate is recorded, since health reported from the component is
+// only known when CurrentHealth is called.
+func (cn *BuiltinComponentNode) HealthHistory() []component.Health {
+	return cn.healthHistory.List()
+}
+
 // DebugInfo returns debugging information from the managed component (if any).
 func (cn *BuiltinComponentNode) DebugInfo() interface{} {
 	cn.mut.RLock()
@@ -416,34 +764,85 @@ func (cn *BuiltinComponentNode) DebugInfo() interface{} {
 	return nil
 }
 
+// EvaluationError returns the error of the last evaluation of the
+// component, or nil if the last evaluation succeeded.
+func (cn *BuiltinComponentNode) EvaluationError() error {
+	cn.healthMut.RLock()
+	defer cn.healthMut.RUnlock()
+	return cn.evalErr
+}
+
 // setEvalHealth sets the internal health from a call to Evaluate. See Health
 // for information on how overall health is calculated.
 func (cn *BuiltinComponentNode) setEvalHealth(t component.HealthType, msg string) {
 	cn.healthMut.Lock()
-	defer cn.healthMut.Unlock()
-
 	cn.evalHealth = component.Health{
 		Health:     t,
 		Message:    msg,
 		UpdateTime: time.Now(),
 	}
+	health := component.LeastHealthy(cn.runHealth, cn.evalHealth)
+	cn.healthMut.Unlock()
+
+	cn.recordHealth(health)
 }
 
 // setRunHealth sets the internal health from a call to Run. See Health for
 // information on how overall health is calculated.
 func (cn *BuiltinComponentNode) setRunHealth(t component.HealthType, msg string) {
 	cn.healthMut.Lock()
-	defer cn.healthMut.Unlock()
-
 	cn.runHealth = component.Health{
 		Health:     t,
 		Message:    msg,
 		UpdateTime: time.Now(),
 	}
+	health := component.LeastHealthy(cn.runHealth, cn.evalHealth)
+	cn.healthMut.Unlock()
+
+	cn.recordHealth(health)
+}
+
+// recordHealth records h into the health history, and publishes an event if
+// the component became unhealthy or is no longer unhealthy. recordHealth is
+// only called when the health from Run or Evaluate changes.
+func (cn *BuiltinComponentNode) recordHealth(h component.Health) {
+	prev, changed := cn.healthHistory.Record(h)
+	if !changed {
+		return
+	}
+
+	switch {
+	case h.Health == component.HealthTypeUnhealthy && prev.Health != component.HealthTypeUnhealthy:
+		cn.publishHealthEvent(EventUnhealthy, h)
+	case h.Health != component.HealthTypeUnhealthy && prev.Health == component.HealthTypeUnhealthy:
+		cn.publishHealthEvent(EventRecovered, h)
+	}
+}
+
+// publishEvent publishes an event of type t with the current health of the
+// component.
+func (cn *BuiltinComponentNode) publishEvent(t EventType) {
+	if cn.events == nil {
+		return
+	}
+	cn.publishHealthEvent(t, cn.CurrentHealth())
+}
+
+func (cn *BuiltinComponentNode) publishHealthEvent(t EventType, h component.Health) {
+	cn.events.Publish(Event{
+		Time:        time.Now(),
+		Type:        t,
+		ComponentID: cn.globalID,
+		Health:      h.Health,
+		Message:     h.Message,
+	})
 }
 
 // ModuleIDs returns the current list of modules that this component is
 // managing.
 func (cn *BuiltinComponentNode) ModuleIDs() []string {
+	if cn.moduleController == nil {
+		return nil
+	}
 	return cn.moduleController.ModuleIDs()
 }
@@ -1,9 +1,19 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"os"
 	"path/filepath"
+	"runtime/pprof"
 	"testing"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/parser"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,3 +44,168 @@ func TestLocalID(t *testing.T) {
 	})
 	require.Equal(t, "/data/local.id", filepath.ToSlash(mo.DataPath))
 }
+
+type labelsComponent struct {
+	labels chan map[string]string
+}
+
+func (c labelsComponent) Run(ctx context.Context) error {
+	labels := make(map[string]string)
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		labels[key] = value
+		return true
+	})
+	c.labels <- labels
+	<-ctx.Done()
+	return nil
+}
+
+func (labelsComponent) Update(component.Arguments) error { return nil }
+
+func TestPprofLabels(t *testing.T) {
+	cn := &BuiltinComponentNode{
+		nodeID:        "test.labels.a",
+		globalID:      "module.file.b/test.labels.a",
+		componentName: "test.labels",
+		managedOpts:   component.Options{Logger: log.NewNopLogger()},
+	}
+	labels := make(chan map[string]string, 1)
+	cn.managed = labelsComponent{labels: labels}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = cn.Run(ctx) }()
+
+	require.Equal(t, map[string]string{
+		"component_id":   "module.file.b/test.labels.a",
+		"component_name": "test.labels",
+	}, <-labels)
+}
+
+func TestBuildRetryRegistersMetrics(t *testing.T) {
+	var builds int
+	reg := component.Registration{
+		Name: "test.metrics",
+		Args: struct{}{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			builds++
+			opts.Registerer.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "test_builds_total",
+			}))
+			if builds == 1 {
+				return nil, errors.New("transient error")
+			}
+			return labelsComponent{}, nil
+		},
+	}
+
+	file, err := parser.ParseFile("", []byte(`test.metrics "a" {}`))
+	require.NoError(t, err)
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	cn := NewBuiltinComponentNode(ComponentGlobals{
+		Logger: logger,
+		NewModuleController: func(id string) ModuleController {
+			return nil
+		},
+	}, reg, file.Body[0].(*ast.BlockStmt))
+
+	// The retried build must be able to register the metrics registered by
+	// the failed build again.
+	require.ErrorContains(t, cn.Evaluate(nil), "transient error")
+	require.NoError(t, cn.Evaluate(nil))
+
+	families, err := cn.getRegistry().Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+}
+
+func TestHealthEvents(t *testing.T) {
+	var builds int
+	reg := component.Registration{
+		Name: "test.events",
+		Args: struct{}{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			builds++
+			if builds == 1 {
+				return nil, errors.New("transient error")
+			}
+			return labelsComponent{}, nil
+		},
+	}
+
+	file, err := parser.ParseFile("", []byte(`test.events "a" {}`))
+	require.NoError(t, err)
+
+	var bus EventBus
+	events, unsubscribe := bus.Subscribe(10)
+	defer unsubscribe()
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	cn := NewBuiltinComponentNode(ComponentGlobals{
+		Logger:       logger,
+		ControllerID: "module.file.a",
+		Events:       &bus,
+		NewModuleController: func(id string) ModuleController {
+			return nil
+		},
+	}, reg, file.Body[0].(*ast.BlockStmt))
+
+	require.Error(t, cn.Evaluate(nil))
+	e := <-events
+	require.Equal(t, EventUnhealthy, e.Type)
+	require.Equal(t, "module.file.a/test.events.a", e.ComponentID)
+	require.Equal(t, component.HealthTypeUnhealthy, e.Health)
+
+	// Failing again isn't a transition.
+	builds = 0
+	require.Error(t, cn.Evaluate(nil))
+	require.NoError(t, cn.Evaluate(nil))
+	e = <-events
+	require.Equal(t, EventRecovered, e.Type)
+	require.Empty(t, events)
+}
+
+// healthComponent is a component which reports the health it's set to.
+type healthComponent struct {
+	health *component.Health
+}
+
+func (healthComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (healthComponent) Update(component.Arguments) error { return nil }
+
+func (c healthComponent) CurrentHealth() component.Health { return *c.health }
+
+func TestCurrentHealth_NoHistory(t *testing.T) {
+	health := component.Health{Health: component.HealthTypeHealthy}
+	reg := component.Registration{
+		Name: "test.health",
+		Args: struct{}{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return healthComponent{health: &health}, nil
+		},
+	}
+
+	file, err := parser.ParseFile("", []byte(`test.health "a" {}`))
+	require.NoError(t, err)
+
+	logger, _ := logging.New(os.Stderr, logging.DefaultOptions)
+	cn := NewBuiltinComponentNode(ComponentGlobals{
+		Logger: logger,
+		NewModuleController: func(id string) ModuleController {
+			return nil
+		},
+	}, reg, file.Body[0].(*ast.BlockStmt))
+	require.NoError(t, cn.Evaluate(nil))
+	history := cn.HealthHistory()
+
+	// Health reported by the component is returned, but requesting it doesn't
+	// record it.
+	health = component.Health{Health: component.HealthTypeUnhealthy, Message: "broken"}
+	require.Equal(t, health, cn.CurrentHealth())
+	require.Equal(t, history, cn.HealthHistory())
+}
@@ -8,10 +8,11 @@ import (
 )
 
 const (
-	argumentBlockID = "argument"
-	exportBlockID   = "export"
-	loggingBlockID  = "logging"
-	tracingBlockID  = "tracing"
+	argumentBlockID   = "argument"
+	exportBlockID     = "export"
+	loggingBlockID    = "logging"
+	tracingBlockID    = "tracing"
+	moduleMetaBlockID = "module_meta"
 )
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -26,6 +27,8 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals) (BlockNode, d
 		return NewLoggingConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
+	case moduleMetaBlockID:
+		return NewModuleMetaConfigNode(block, globals), nil
 	default:
 		var diags diag.Diagnostics
 		diags.Add(diag.Diagnostic{
@@ -44,6 +47,7 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals) (BlockNode, d
 type ConfigNodeMap struct {
 	logging     *LoggingConfigNode
 	tracing     *TracingConfigNode
+	moduleMeta  *ModuleMetaConfigNode
 	argumentMap map[string]*ArgumentConfigNode
 	exportMap   map[string]*ExportConfigNode
 }
@@ -54,6 +58,7 @@ func NewConfigNodeMap() *ConfigNodeMap {
 	return &ConfigNodeMap{
 		logging:     nil,
 		tracing:     nil,
+		moduleMeta:  nil,
 		argumentMap: map[string]*ArgumentConfigNode{},
 		exportMap:   map[string]*ExportConfigNode{},
 	}
@@ -73,6 +78,8 @@ func (nodeMap *ConfigNodeMap) Append(configNode BlockNode) diag.Diagnostics {
 		nodeMap.logging = n
 	case *TracingConfigNode:
 		nodeMap.tracing = n
+	case *ModuleMetaConfigNode:
+		nodeMap.moduleMeta = n
 	default:
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -124,6 +131,15 @@ func (nodeMap *ConfigNodeMap) ValidateModuleConstraints(isInModule bool) diag.Di
 		return diags
 	}
 
+	if nodeMap.moduleMeta != nil {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  "module_meta block only allowed inside a module",
+			StartPos: ast.StartPos(nodeMap.moduleMeta.Block()).Position(),
+			EndPos:   ast.EndPos(nodeMap.moduleMeta.Block()).Position(),
+		})
+	}
+
 	for key := range nodeMap.argumentMap {
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -2,8 +2,10 @@ package controller
 
 import (
 	"fmt"
+	"reflect"
 	"sync"
 
+	"github.com/grafana/river"
 	"github.com/grafana/river/ast"
 	"github.com/grafana/river/vm"
 )
@@ -18,6 +20,7 @@ type ArgumentConfigNode struct {
 	eval         *vm.Evaluator
 	defaultValue any
 	optional     bool
+	typ          string
 }
 
 var _ BlockNode = (*ArgumentConfigNode)(nil)
@@ -39,6 +42,20 @@ type argumentBlock struct {
 	Optional bool   `river:"optional,attr,optional"`
 	Default  any    `river:"default,attr,optional"`
 	Comment  string `river:"comment,attr,optional"`
+	Type     string `river:"type,attr,optional"`
+}
+
+// argumentTypes are the supported values for the type of an argument. An
+// empty type is the same as "any".
+var argumentTypes = map[string]struct{}{
+	"any":      {},
+	"number":   {},
+	"string":   {},
+	"bool":     {},
+	"list":     {},
+	"object":   {},
+	"function": {},
+	"capsule":  {},
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed config block
@@ -56,12 +73,87 @@ func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding River: %w", err)
 	}
 
+	// Optional is set before the type is checked so that an invalid type
+	// isn't reported as a missing argument. Arguments which declare a default
+	// value are always optional.
+	cn.optional = argument.Optional || hasAttribute(cn.block, "default")
+
+	if _, ok := argumentTypes[argument.Type]; argument.Type != "" && !ok {
+		return fmt.Errorf("unsupported type %q for argument %q", argument.Type, cn.label)
+	}
+	if err := checkArgumentType(argument.Type, argument.Default); err != nil {
+		return fmt.Errorf("invalid default value for argument %q: %w", cn.label, err)
+	}
+
 	cn.defaultValue = argument.Default
-	cn.optional = argument.Optional
+	cn.typ = argument.Type
+
+	return nil
+}
+
+// hasAttribute reports whether block sets the attribute called name.
+func hasAttribute(block *ast.BlockStmt, name string) bool {
+	for _, stmt := range block.Body {
+		if attr, ok := stmt.(*ast.AttributeStmt); ok && attr.Name.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckType returns an error if value doesn't match the declared type of the
+// argument.
+func (cn *ArgumentConfigNode) CheckType(value any) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
 
+	if err := checkArgumentType(cn.typ, value); err != nil {
+		return fmt.Errorf("invalid value for argument %q to module: %w", cn.label, err)
+	}
 	return nil
 }
 
+// checkArgumentType returns an error if value isn't of type typ. null values
+// are accepted for every type.
+func checkArgumentType(typ string, value any) error {
+	if typ == "" || typ == "any" || value == nil {
+		return nil
+	}
+	if actual := riverTypeOf(value); actual != typ {
+		return fmt.Errorf("expected %s, got %s", typ, actual)
+	}
+	return nil
+}
+
+// riverTypeOf returns the name of the River type which value is represented
+// as.
+func riverTypeOf(value any) string {
+	// Capsules may have any underlying kind, such as the string of a
+	// rivertypes.Secret.
+	if _, ok := value.(river.Capsule); ok {
+		return "capsule"
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Func:
+		return "function"
+	default:
+		return "capsule"
+	}
+}
+
 func (cn *ArgumentConfigNode) Optional() bool {
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/river/ast"
+	"github.com/grafana/river/vm"
+)
+
+// ModuleMetaConfigNode represents the module_meta block, which describes the
+// module it's declared in. Module loaders read and enforce the block before
+// the module is loaded; the node only validates it.
+type ModuleMetaConfigNode struct {
+	nodeID        string
+	componentName string
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River blocks to derive config from
+	eval  *vm.Evaluator
+}
+
+var _ BlockNode = (*ModuleMetaConfigNode)(nil)
+
+// NewModuleMetaConfigNode creates a new ModuleMetaConfigNode from an initial
+// ast.BlockStmt. The underlying config isn't applied until Evaluate is called.
+func NewModuleMetaConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *ModuleMetaConfigNode {
+	return &ModuleMetaConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}
+}
+
+type moduleMetaBlock struct {
+	Name            string `river:"name,attr,optional"`
+	Version         string `river:"version,attr,optional"`
+	MinAgentVersion string `river:"min_agent_version,attr,optional"`
+	Description     string `river:"description,attr,optional"`
+}
+
+// Evaluate implements BlockNode and validates the managed config block by
+// re-evaluating its River block with the provided scope.
+//
+// Evaluate will return an error if the River block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *ModuleMetaConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	var meta moduleMetaBlock
+	if err := cn.eval.Evaluate(scope, &meta); err != nil {
+		return fmt.Errorf("decoding River: %w", err)
+	}
+	return nil
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *ModuleMetaConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *ModuleMetaConfigNode) NodeID() string { return cn.nodeID }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"runtime/pprof"
 	"sync"
 
 	"github.com/grafana/agent/component"
@@ -121,5 +122,9 @@ func (sn *ServiceNode) Evaluate(scope *vm.Scope) error {
 }
 
 func (sn *ServiceNode) Run(ctx context.Context) error {
-	return sn.svc.Run(ctx, sn.host)
+	var err error
+	pprof.Do(ctx, pprof.Labels("service", sn.NodeID()), func(ctx context.Context) {
+		err = sn.svc.Run(ctx, sn.host)
+	})
+	return err
 }
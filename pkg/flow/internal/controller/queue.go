@@ -5,13 +5,28 @@ import (
 	"time"
 )
 
-// Queue is a thread-safe, insertion-ordered set of nodes.
+// Queue is a thread-safe, insertion-ordered set of nodes keyed by node ID.
+// Since it's a set, the Queue never holds more nodes than the graph has.
 //
-// Queue is intended for tracking nodes that have been updated for later reevaluation.
+// Queue is intended for tracking nodes that have been updated for later
+// reevaluation. Multiple updates of the same node which happen before the
+// queue is drained are coalesced into a single entry.
+//
+// A Queue may also limit how often each node is dequeued. Updates of a node
+// which was dequeued less than the minimum interval ago are held back until
+// the interval passes, coalescing any further updates of the node in the
+// meantime.
 type Queue struct {
-	mut         sync.Mutex
-	queuedSet   map[*QueuedNode]struct{}
-	queuedOrder []*QueuedNode
+	mut          sync.Mutex
+	minInterval  time.Duration
+	queuedSet    map[string]*QueuedNode
+	queuedOrder  []*QueuedNode
+	lastDequeued map[string]time.Time // Node ID -> time the node was last dequeued.
+	timer        *time.Timer          // Notifies updateCh once held back nodes may be dequeued.
+
+	// Counters reported as metrics by the controller.
+	coalescedTotal  int
+	suppressedTotal int
 
 	updateCh chan struct{}
 }
@@ -23,43 +38,154 @@ type QueuedNode struct {
 
 // NewQueue returns a new queue.
 func NewQueue() *Queue {
+	return NewRateLimitedQueue(0)
+}
+
+// NewRateLimitedQueue returns a new queue which dequeues each node at most
+// once per minInterval. A minInterval of 0 disables the limit.
+func NewRateLimitedQueue(minInterval time.Duration) *Queue {
 	return &Queue{
-		updateCh:    make(chan struct{}, 1),
-		queuedSet:   make(map[*QueuedNode]struct{}),
-		queuedOrder: make([]*QueuedNode, 0),
+		minInterval:  minInterval,
+		updateCh:     make(chan struct{}, 1),
+		queuedSet:    make(map[string]*QueuedNode),
+		queuedOrder:  make([]*QueuedNode, 0),
+		lastDequeued: make(map[string]time.Time),
 	}
 }
 
-// Enqueue inserts a new BlockNode into the Queue. Enqueue is a no-op if the
-// BlockNode is already in the Queue.
+// Enqueue inserts a new BlockNode into the Queue. If a node with the same ID
+// is already in the Queue, the update is coalesced with the queued one and
+// the original update time is kept.
+//
+// If the node was dequeued less than the minimum interval ago, the update is
+// counted as suppressed and held back until the interval passes.
 func (q *Queue) Enqueue(c *QueuedNode) {
 	q.mut.Lock()
 	defer q.mut.Unlock()
 
+	id := c.Node.NodeID()
+
 	// Skip if already queued.
-	if _, ok := q.queuedSet[c]; ok {
+	if _, ok := q.queuedSet[id]; ok {
+		q.coalescedTotal++
 		return
 	}
 
 	q.queuedOrder = append(q.queuedOrder, c)
-	q.queuedSet[c] = struct{}{}
+	q.queuedSet[id] = c
+
+	if _, limited := q.readyAt(id); limited {
+		q.suppressedTotal++
+		q.scheduleNotify()
+		return
+	}
+	q.notify()
+}
+
+// readyAt returns the time from which the node with the given ID may be
+// dequeued again, and whether that time is in the future. mut must be held
+// when calling readyAt.
+func (q *Queue) readyAt(id string) (time.Time, bool) {
+	last, ok := q.lastDequeued[id]
+	if !ok || q.minInterval <= 0 {
+		return time.Time{}, false
+	}
+	readyAt := last.Add(q.minInterval)
+	return readyAt, time.Now().Before(readyAt)
+}
+
+// notify writes to updateCh without blocking.
+func (q *Queue) notify() {
 	select {
 	case q.updateCh <- struct{}{}:
 	default:
 	}
 }
 
+// scheduleNotify schedules a write to updateCh for when the earliest held
+// back node may be dequeued. mut must be held when calling scheduleNotify.
+func (q *Queue) scheduleNotify() {
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	if next, ok := q.nextReadyAt(); ok {
+		q.timer = time.AfterFunc(time.Until(next), q.notify)
+	}
+}
+
+// nextReadyAt returns the earliest time at which a held back node may be
+// dequeued. mut must be held when calling nextReadyAt.
+func (q *Queue) nextReadyAt() (time.Time, bool) {
+	var (
+		next  time.Time
+		found bool
+	)
+	for _, c := range q.queuedOrder {
+		readyAt, limited := q.readyAt(c.Node.NodeID())
+		if limited && (!found || readyAt.Before(next)) {
+			next, found = readyAt, true
+		}
+	}
+	return next, found
+}
+
 // Chan returns a channel which is written to when the queue is non-empty.
 func (q *Queue) Chan() <-chan struct{} { return q.updateCh }
 
-// DequeueAll removes all BlockNode from the queue and returns them.
+// Len returns the number of nodes in the queue.
+func (q *Queue) Len() int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	return len(q.queuedOrder)
+}
+
+// Stats returns the total number of coalesced updates and the total number
+// of updates held back by the minimum interval.
+func (q *Queue) Stats() (coalesced, suppressed int) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	return q.coalescedTotal, q.suppressedTotal
+}
+
+// DequeueAll removes all BlockNode from the queue and returns them. Nodes
+// held back by the minimum interval stay in the queue.
 func (q *Queue) DequeueAll() []*QueuedNode {
 	q.mut.Lock()
 	defer q.mut.Unlock()
 
-	all := q.queuedOrder
-	q.queuedOrder = make([]*QueuedNode, 0)
-	q.queuedSet = make(map[*QueuedNode]struct{})
+	if q.minInterval <= 0 {
+		all := q.queuedOrder
+		q.queuedOrder = make([]*QueuedNode, 0)
+		q.queuedSet = make(map[string]*QueuedNode)
+		return all
+	}
+
+	var (
+		now  = time.Now()
+		all  = make([]*QueuedNode, 0, len(q.queuedOrder))
+		held = make([]*QueuedNode, 0)
+	)
+	for _, c := range q.queuedOrder {
+		id := c.Node.NodeID()
+		if _, limited := q.readyAt(id); limited {
+			held = append(held, c)
+			continue
+		}
+		all = append(all, c)
+		delete(q.queuedSet, id)
+		q.lastDequeued[id] = now
+	}
+	q.queuedOrder = held
+
+	// Forget nodes which are no longer limited so lastDequeued doesn't grow
+	// with every node ever updated.
+	for id, last := range q.lastDequeued {
+		if now.Sub(last) >= q.minInterval {
+			delete(q.lastDequeued, id)
+		}
+	}
 
+	q.scheduleNotify()
 	return all
 }
@@ -8,8 +8,20 @@ import (
 	"go.uber.org/atomic"
 )
 
+// queueTestNode is a BlockNode with a fixed ID.
+type queueTestNode struct {
+	BlockNode
+	id string
+}
+
+func (n *queueTestNode) NodeID() string { return n.id }
+
+func newQueuedNode(id string) *QueuedNode {
+	return &QueuedNode{Node: &queueTestNode{id: id}, LastUpdatedTime: time.Now()}
+}
+
 func TestEnqueueDequeue(t *testing.T) {
-	tn := &QueuedNode{}
+	tn := newQueuedNode("a")
 	q := NewQueue()
 	q.Enqueue(tn)
 	require.Lenf(t, q.queuedSet, 1, "queue should be 1")
@@ -26,7 +38,7 @@ func TestDequeue_Empty(t *testing.T) {
 }
 
 func TestDequeue_InOrder(t *testing.T) {
-	c1, c2, c3 := &QueuedNode{}, &QueuedNode{}, &QueuedNode{}
+	c1, c2, c3 := newQueuedNode("a"), newQueuedNode("b"), newQueuedNode("c")
 	q := NewQueue()
 	q.Enqueue(c1)
 	q.Enqueue(c2)
@@ -41,7 +53,7 @@ func TestDequeue_InOrder(t *testing.T) {
 }
 
 func TestDequeue_NoDuplicates(t *testing.T) {
-	c1, c2 := &QueuedNode{}, &QueuedNode{}
+	c1, c2 := newQueuedNode("a"), newQueuedNode("b")
 	q := NewQueue()
 	q.Enqueue(c1)
 	q.Enqueue(c1)
@@ -55,10 +67,64 @@ func TestDequeue_NoDuplicates(t *testing.T) {
 	require.Len(t, q.queuedSet, 0)
 	require.Same(t, c1, all[0])
 	require.Same(t, c2, all[1])
+
+	coalesced, suppressed := q.Stats()
+	require.Equal(t, 4, coalesced)
+	require.Equal(t, 0, suppressed)
+}
+
+func TestDequeue_CoalescesByNodeID(t *testing.T) {
+	first, second := newQueuedNode("a"), newQueuedNode("a")
+	second.LastUpdatedTime = first.LastUpdatedTime.Add(time.Second)
+
+	q := NewQueue()
+	q.Enqueue(first)
+	q.Enqueue(second)
+
+	all := q.DequeueAll()
+	require.Len(t, all, 1)
+	require.Same(t, first, all[0], "the oldest update should be kept")
+}
+
+func TestDequeue_MinInterval(t *testing.T) {
+	q := NewRateLimitedQueue(200 * time.Millisecond)
+
+	first := newQueuedNode("a")
+	q.Enqueue(first)
+	all := q.DequeueAll()
+	require.Len(t, all, 1)
+	require.Same(t, first, all[0])
+
+	// Updates of a within the interval are held back and coalesced, while
+	// other nodes are dequeued right away.
+	held := newQueuedNode("a")
+	q.Enqueue(held)
+	q.Enqueue(newQueuedNode("a"))
+	q.Enqueue(newQueuedNode("b"))
+	<-q.Chan()
+	all = q.DequeueAll()
+	require.Len(t, all, 1)
+	require.Equal(t, "b", all[0].Node.NodeID())
+	require.Equal(t, 1, q.Len())
+
+	coalesced, suppressed := q.Stats()
+	require.Equal(t, 1, coalesced)
+	require.Equal(t, 1, suppressed)
+
+	// The queue notifies once the held back update may be dequeued.
+	select {
+	case <-q.Chan():
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "queue didn't notify after the interval passed")
+	}
+	all = q.DequeueAll()
+	require.Len(t, all, 1)
+	require.Same(t, held, all[0])
+	require.Equal(t, 0, q.Len())
 }
 
 func TestEnqueue_ChannelNotification(t *testing.T) {
-	c1 := &QueuedNode{}
+	c1 := newQueuedNode("a")
 	q := NewQueue()
 
 	notificationsCount := atomic.Int32{}
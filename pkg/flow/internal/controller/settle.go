@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// settleTracker measures how long the graph takes to settle after it's
+// changed, either by applying a new configuration or by a node updating its
+// exports. The graph is settled once no evaluations are in progress and no
+// updated nodes are queued.
+type settleTracker struct {
+	mut     sync.Mutex
+	start   time.Time           // When the graph stopped being settled; zero while it's settled.
+	active  int                 // Evaluations in progress, including submitted dependants.
+	waiting map[string]struct{} // Dependants submitted to the worker pool which haven't started evaluating.
+}
+
+// begin records that an evaluation caused by a change at time at started.
+// end must be called once the evaluation is done.
+func (st *settleTracker) begin(at time.Time) {
+	st.mut.Lock()
+	defer st.mut.Unlock()
+
+	if st.start.IsZero() || at.Before(st.start) {
+		st.start = at
+	}
+	st.active++
+}
+
+// end records that an evaluation started by begin or submit is done. If the
+// graph settled, end returns how long it took to settle.
+func (st *settleTracker) end(queued int) (time.Duration, bool) {
+	st.mut.Lock()
+	defer st.mut.Unlock()
+
+	st.active--
+	if st.active > 0 || queued > 0 || st.start.IsZero() {
+		return 0, false
+	}
+	took := time.Since(st.start)
+	st.start = time.Time{}
+	return took, true
+}
+
+// submit records that the node with the given ID is submitted to the worker
+// pool for evaluation. submit returns false if the node was already waiting
+// to be evaluated, since the worker pool ignores the submission then. If
+// submit returns true, end must be called once the evaluation is done.
+func (st *settleTracker) submit(nodeID string) bool {
+	st.mut.Lock()
+	defer st.mut.Unlock()
+
+	if _, ok := st.waiting[nodeID]; ok {
+		return false
+	}
+	if st.waiting == nil {
+		st.waiting = make(map[string]struct{})
+	}
+	st.waiting[nodeID] = struct{}{}
+	st.active++
+	return true
+}
+
+// started records that a node submitted to the worker pool started
+// evaluating, so that it can be submitted again.
+func (st *settleTracker) started(nodeID string) {
+	st.mut.Lock()
+	defer st.mut.Unlock()
+	delete(st.waiting, nodeID)
+}
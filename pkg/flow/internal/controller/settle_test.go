@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettleTracker(t *testing.T) {
+	var st settleTracker
+
+	changed := time.Now().Add(-time.Minute)
+	st.begin(changed)
+	require.True(t, st.submit("a"))
+	require.False(t, st.submit("a"), "the worker pool ignores nodes which are already waiting")
+
+	// The graph isn't settled while the dependant is evaluated.
+	_, settled := st.end(0)
+	require.False(t, settled)
+
+	st.started("a")
+	require.True(t, st.submit("a"), "a node which started evaluating can be submitted again")
+	st.started("a")
+	_, settled = st.end(0)
+	require.False(t, settled)
+
+	// Queued updates will cause more evaluations.
+	_, settled = st.end(1)
+	require.False(t, settled)
+
+	st.begin(time.Now())
+	took, settled := st.end(0)
+	require.True(t, settled)
+	require.GreaterOrEqual(t, took, time.Minute, "the graph changed when the first evaluation was caused")
+
+	st.begin(time.Now())
+	took, settled = st.end(0)
+	require.True(t, settled)
+	require.Less(t, took, time.Minute)
+}
@@ -13,6 +13,12 @@ import (
 //
 // The current state of valueCache can then be built into a *vm.Scope for other
 // components to be evaluated.
+//
+// The most recently built *vm.Scope is cached and shared between callers of
+// BuildContext. When values change, only the top-level variables affected by
+// the change are rebuilt into a new scope; unaffected variables are reused.
+// Scopes returned by BuildContext are never mutated afterwards, so they are
+// safe to use concurrently with updates to the cache.
 type valueCache struct {
 	mut                sync.RWMutex
 	components         map[string]ComponentID // NodeID -> ComponentID
@@ -21,6 +27,28 @@ type valueCache struct {
 	moduleArguments    map[string]any         // key -> module arguments value
 	moduleExports      map[string]any         // name -> value for the value of module exports
 	moduleChangedIndex int                    // Everytime a change occurs this is incremented
+	variables          map[string]any         // Variables provided by the controller, such as agent.
+
+	scope        *vm.Scope           // Most recently built scope; nil if it must be fully rebuilt.
+	dirtyBlocks  map[string]struct{} // Top-level block names which changed since scope was built.
+	dirtyModArgs bool                // Set when module arguments changed since scope was built.
+}
+
+const (
+	// agentVariable is the name of the variable which exposes information
+	// about the agent running the controller, such as agent.id and
+	// agent.cluster_name.
+	agentVariable = "agent"
+
+	// componentVariable is the name of the variable which exposes functions
+	// for looking up components by ID, such as component.exports.
+	componentVariable = "component"
+)
+
+// isControllerVariable returns true if name is a variable provided by the
+// controller rather than by a node.
+func isControllerVariable(name string) bool {
+	return name == agentVariable || name == componentVariable
 }
 
 // newValueCache creates a new ValueCache.
@@ -31,9 +59,34 @@ func newValueCache() *valueCache {
 		exports:         make(map[string]interface{}),
 		moduleArguments: make(map[string]any),
 		moduleExports:   make(map[string]any),
+		dirtyBlocks:     make(map[string]struct{}),
 	}
 }
 
+// SetVariable sets the value of a variable provided by the controller. name
+// must be one of the names accepted by isControllerVariable.
+func (vc *valueCache) SetVariable(name string, value any) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	if vc.variables == nil {
+		vc.variables = make(map[string]any)
+	}
+	vc.variables[name] = value
+	// Variables are rarely set, so rebuild the whole scope.
+	vc.scope = nil
+}
+
+// GetExports returns the most recently cached exports of the node with the
+// given ID.
+func (vc *valueCache) GetExports(nodeID string) (any, bool) {
+	vc.mut.RLock()
+	defer vc.mut.RUnlock()
+
+	exports, ok := vc.exports[nodeID]
+	return exports, ok
+}
+
 // CacheArguments will cache the provided arguments by the given id. args may
 // be nil to store an empty object.
 func (vc *valueCache) CacheArguments(id ComponentID, args component.Arguments) {
@@ -51,8 +104,9 @@ func (vc *valueCache) CacheArguments(id ComponentID, args component.Arguments) {
 }
 
 // CacheExports will cache the provided exports using the given id. exports may
-// be nil to store an empty object.
-func (vc *valueCache) CacheExports(id ComponentID, exports component.Exports) {
+// be nil to store an empty object. CacheExports returns true if the exports
+// changed.
+func (vc *valueCache) CacheExports(id ComponentID, exports component.Exports) bool {
 	vc.mut.Lock()
 	defer vc.mut.Unlock()
 
@@ -63,7 +117,10 @@ func (vc *valueCache) CacheExports(id ComponentID, exports component.Exports) {
 	if exports != nil {
 		exportsVal = exports
 	}
+	prev, found := vc.exports[nodeID]
 	vc.exports[nodeID] = exportsVal
+	vc.dirtyBlocks[id[0]] = struct{}{}
+	return !found || !reflect.DeepEqual(prev, exportsVal)
 }
 
 // CacheModuleArgument will cache the provided exports using the given id.
@@ -76,6 +133,17 @@ func (vc *valueCache) CacheModuleArgument(key string, value any) {
 	} else {
 		vc.moduleArguments[key] = value
 	}
+	vc.dirtyModArgs = true
+}
+
+// GetModuleArgument returns the cached value of the module argument with the
+// given key.
+func (vc *valueCache) GetModuleArgument(key string) (any, bool) {
+	vc.mut.RLock()
+	defer vc.mut.RUnlock()
+
+	value, found := vc.moduleArguments[key]
+	return value, found
 }
 
 // CacheModuleExportValue saves the value to the map
@@ -139,6 +207,7 @@ func (vc *valueCache) SyncIDs(ids []ComponentID) {
 		if _, keep := expectMap[id]; keep {
 			continue
 		}
+		vc.dirtyBlocks[vc.components[id][0]] = struct{}{}
 		delete(vc.components, id)
 		delete(vc.args, id)
 		delete(vc.exports, id)
@@ -155,15 +224,45 @@ func (vc *valueCache) SyncModuleArgs(args map[string]any) {
 			continue
 		}
 		delete(vc.moduleArguments, id)
+		vc.dirtyModArgs = true
 	}
 }
 
 // BuildContext builds a vm.Scope based on the current set of cached values.
 // The arguments and exports for the same ID are merged into one object.
+//
+// The returned scope may be shared with other callers and must not be
+// modified.
 func (vc *valueCache) BuildContext() *vm.Scope {
 	vc.mut.RLock()
-	defer vc.mut.RUnlock()
+	if vc.scope != nil && len(vc.dirtyBlocks) == 0 && !vc.dirtyModArgs {
+		defer vc.mut.RUnlock()
+		return vc.scope
+	}
+	vc.mut.RUnlock()
 
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+
+	// Another caller may have rebuilt the scope while we were waiting for the
+	// write lock.
+	if vc.scope != nil && len(vc.dirtyBlocks) == 0 && !vc.dirtyModArgs {
+		return vc.scope
+	}
+
+	if vc.scope == nil {
+		vc.scope = vc.buildFullScope()
+	} else {
+		vc.scope = vc.buildPartialScope()
+	}
+	vc.dirtyBlocks = make(map[string]struct{})
+	vc.dirtyModArgs = false
+	return vc.scope
+}
+
+// buildFullScope builds a new scope from scratch. mut must be held when
+// calling buildFullScope.
+func (vc *valueCache) buildFullScope() *vm.Scope {
 	scope := &vm.Scope{
 		Parent:    nil,
 		Variables: make(map[string]interface{}),
@@ -181,23 +280,72 @@ func (vc *valueCache) BuildContext() *vm.Scope {
 		scope.Variables[blockName] = vc.buildValue(ids, 1)
 	}
 
-	// Add module arguments to the scope.
-	if len(vc.moduleArguments) > 0 {
-		scope.Variables["argument"] = make(map[string]any)
+	if args := vc.buildModuleArguments(); args != nil {
+		scope.Variables["argument"] = args
 	}
-	for key, value := range vc.moduleArguments {
-		keyMap := make(map[string]any)
-		keyMap["value"] = value
+	for name, value := range vc.variables {
+		scope.Variables[name] = value
+	}
+	return scope
+}
 
-		switch args := scope.Variables["argument"].(type) {
-		case map[string]any:
-			args[key] = keyMap
+// buildPartialScope builds a new scope which reuses the values from the
+// previously built scope, only rebuilding the values for top-level variables
+// which changed. The previous scope is left untouched, since it may still be
+// in use by an in-flight evaluation. mut must be held when calling
+// buildPartialScope.
+func (vc *valueCache) buildPartialScope() *vm.Scope {
+	scope := &vm.Scope{
+		Parent:    nil,
+		Variables: make(map[string]interface{}, len(vc.scope.Variables)),
+	}
+	for name, value := range vc.scope.Variables {
+		scope.Variables[name] = value
+	}
+
+	if len(vc.dirtyBlocks) > 0 {
+		var componentsByBlockName = make(map[string][]ComponentID, len(vc.dirtyBlocks))
+		for _, id := range vc.components {
+			if _, dirty := vc.dirtyBlocks[id[0]]; dirty {
+				componentsByBlockName[id[0]] = append(componentsByBlockName[id[0]], id)
+			}
+		}
+
+		for blockName := range vc.dirtyBlocks {
+			ids, ok := componentsByBlockName[blockName]
+			if !ok {
+				// All components with this block name were removed.
+				delete(scope.Variables, blockName)
+				continue
+			}
+			scope.Variables[blockName] = vc.buildValue(ids, 1)
 		}
 	}
 
+	if vc.dirtyModArgs {
+		if args := vc.buildModuleArguments(); args != nil {
+			scope.Variables["argument"] = args
+		} else {
+			delete(scope.Variables, "argument")
+		}
+	}
 	return scope
 }
 
+// buildModuleArguments converts the set of module arguments into a single
+// value, returning nil if there are no module arguments.
+func (vc *valueCache) buildModuleArguments() map[string]any {
+	if len(vc.moduleArguments) == 0 {
+		return nil
+	}
+
+	args := make(map[string]any, len(vc.moduleArguments))
+	for key, value := range vc.moduleArguments {
+		args[key] = map[string]any{"value": value}
+	}
+	return args
+}
+
 // buildValue recursively converts the set of user components into a single
 // value. offset is used to determine which element in the userComponentName
 // we're looking at.
@@ -174,3 +174,29 @@ func TestModuleArgumentCache(t *testing.T) {
 		})
 	}
 }
+
+func TestValueCacheScopeReuse(t *testing.T) {
+	vc := newValueCache()
+
+	vc.CacheExports(ComponentID{"foo"}, fooExports{SomethingElse: true})
+	vc.CacheExports(ComponentID{"bar", "label_a"}, fooExports{SomethingElse: false})
+
+	first := vc.BuildContext()
+	require.Same(t, first, vc.BuildContext(), "unchanged cache should reuse the scope")
+
+	// Updating the exports of foo should create a new scope without modifying
+	// the previous one.
+	vc.CacheExports(ComponentID{"foo"}, fooExports{SomethingElse: false})
+	second := vc.BuildContext()
+	require.NotSame(t, first, second)
+	require.Equal(t, fooExports{SomethingElse: true}, first.Variables["foo"])
+	require.Equal(t, fooExports{SomethingElse: false}, second.Variables["foo"])
+	require.Equal(t, first.Variables["bar"], second.Variables["bar"])
+
+	// Removing all components for a block name should remove the variable.
+	vc.SyncIDs([]ComponentID{{"bar", "label_a"}})
+	third := vc.BuildContext()
+	require.NotContains(t, third.Variables, "foo")
+	require.Contains(t, second.Variables, "foo")
+	require.Contains(t, third.Variables, "bar")
+}
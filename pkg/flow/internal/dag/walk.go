@@ -1,5 +1,7 @@
 package dag
 
+import "sync"
+
 // WalkFunc is a function that gets invoked when walking a Graph. Walking will
 // stop if WalkFunc returns a non-nil error.
 type WalkFunc func(n Node) error
@@ -105,3 +107,81 @@ func WalkTopological(g *Graph, start []Node, fn WalkFunc) error {
 
 	return nil
 }
+
+// WalkTopologicalConcurrent is like WalkTopological, but invokes fn for up to
+// concurrency nodes at the same time. A node is still not visited until all
+// of its outgoing edges are visited, so fn is only called concurrently for
+// nodes which don't depend on each other. If concurrency is less than 2,
+// WalkTopologicalConcurrent behaves like WalkTopological.
+//
+// Walking stops once fn returns a non-nil error, though nodes which are
+// already being visited aren't interrupted. The first error returned by fn is
+// returned.
+func WalkTopologicalConcurrent(g *Graph, start []Node, concurrency int, fn WalkFunc) error {
+	if concurrency < 2 {
+		return WalkTopological(g, start, fn)
+	}
+
+	var (
+		mut           sync.Mutex
+		visited       = make(nodeSet)
+		remainingDeps = make(map[Node]int)
+		firstErr      error
+
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	var visit func(n Node)
+	visit = func(n Node) {
+		defer wg.Done()
+
+		sem <- struct{}{}
+		err := fn(n)
+		<-sem
+
+		mut.Lock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if firstErr != nil {
+			mut.Unlock()
+			return
+		}
+
+		// Queue the incoming edges of n whose outgoing edges have all been
+		// visited, like WalkTopological.
+		var ready []Node
+		for dependant := range g.inEdges[n] {
+			if _, ok := remainingDeps[dependant]; !ok {
+				remainingDeps[dependant] = len(g.outEdges[dependant])
+			}
+			remainingDeps[dependant]--
+
+			if remainingDeps[dependant] == 0 && !visited.Has(dependant) {
+				visited.Add(dependant)
+				ready = append(ready, dependant)
+			}
+		}
+		mut.Unlock()
+
+		for _, dependant := range ready {
+			wg.Add(1)
+			go visit(dependant)
+		}
+	}
+
+	mut.Lock()
+	for _, n := range start {
+		if visited.Has(n) {
+			continue
+		}
+		visited.Add(n)
+		wg.Add(1)
+		go visit(n)
+	}
+	mut.Unlock()
+
+	wg.Wait()
+	return firstErr
+}
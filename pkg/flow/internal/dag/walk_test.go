@@ -0,0 +1,65 @@
+package dag
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWalkTopologicalConcurrent(t *testing.T) {
+	var g Graph
+	var (
+		nodeA = stringNode("a")
+		nodeB = stringNode("b")
+		nodeC = stringNode("c")
+		nodeD = stringNode("d")
+	)
+	g.Add(nodeA)
+	g.Add(nodeB)
+	g.Add(nodeC)
+	g.Add(nodeD)
+	g.AddEdge(Edge{nodeC, nodeA})
+	g.AddEdge(Edge{nodeC, nodeB})
+	g.AddEdge(Edge{nodeD, nodeC})
+
+	var (
+		mut     sync.Mutex
+		visited []Node
+
+		// a and b don't depend on each other, so they must be visited at the
+		// same time for both of them to get past the barrier.
+		barrier sync.WaitGroup
+	)
+	barrier.Add(2)
+
+	err := WalkTopologicalConcurrent(&g, g.Leaves(), 2, func(n Node) error {
+		if n == nodeA || n == nodeB {
+			barrier.Done()
+			waited := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(waited)
+			}()
+			select {
+			case <-waited:
+			case <-time.After(time.Second):
+				t.Errorf("%s wasn't visited concurrently with its sibling", n.NodeID())
+			}
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		visited = append(visited, n)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(visited) != 4 {
+		t.Fatalf("expected 4 visited nodes, got %d", len(visited))
+	}
+	if visited[2] != nodeC || visited[3] != nodeD {
+		t.Fatalf("expected c and d to be visited after their dependencies, got %v", visited)
+	}
+}
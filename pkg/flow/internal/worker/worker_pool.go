@@ -37,8 +37,12 @@ type fixedWorkerPool struct {
 
 var _ Pool = (*fixedWorkerPool)(nil)
 
+// DefaultMaxQueueSize is the max queue size of pools created by
+// NewDefaultWorkerPool.
+const DefaultMaxQueueSize = 1024
+
 func NewDefaultWorkerPool() Pool {
-	return NewFixedWorkerPool(runtime.NumCPU(), 1024)
+	return NewFixedWorkerPool(runtime.NumCPU(), DefaultMaxQueueSize)
 }
 
 // NewFixedWorkerPool creates a new Pool with the given number of workers and given max queue size.
@@ -2,11 +2,17 @@ package flow
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"path"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/worker"
 	"github.com/grafana/agent/pkg/flow/logging"
@@ -14,6 +20,9 @@ import (
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/river/scanner"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/maps"
 )
 
@@ -48,6 +57,16 @@ func (m *moduleController) NewModule(id string, export component.ExportFunc) (co
 		fullPath = path.Join(fullPath, id)
 	}
 
+	if m.o.MaxModuleDepth > 0 {
+		depth := 1
+		for owner := m.o.Owner; owner != nil; owner = owner.o.Owner {
+			depth++
+		}
+		if depth > m.o.MaxModuleDepth {
+			return nil, fmt.Errorf("module %q exceeds the maximum module depth of %d", fullPath, m.o.MaxModuleDepth)
+		}
+	}
+
 	mod := newModule(&moduleOptions{
 		ID:                      fullPath,
 		export:                  export,
@@ -69,7 +88,7 @@ func (m *moduleController) removeModule(mod *module) {
 func (m *moduleController) addModule(mod *module) error {
 	m.mut.Lock()
 	defer m.mut.Unlock()
-	if err := m.o.ModuleRegistry.Register(mod.o.ID, mod); err != nil {
+	if err := m.o.ModuleRegistry.Register(mod.o.ID, mod, m.o.MaxModules); err != nil {
 		level.Error(m.o.Logger).Log("msg", "error registering module", "id", mod.o.ID, "err", err)
 		return err
 	}
@@ -88,6 +107,16 @@ func (m *moduleController) ModuleIDs() []string {
 type module struct {
 	f *Flow
 	o *moduleOptions
+
+	mut    sync.RWMutex
+	source moduleSource // Most recently loaded config and arguments.
+}
+
+// moduleSource identifies a config loaded by a module along with the
+// arguments it was loaded with.
+type moduleSource struct {
+	sha  [sha256.Size]byte
+	args map[string]any
 }
 
 type moduleOptions struct {
@@ -98,42 +127,188 @@ type moduleOptions struct {
 }
 
 var (
-	_ component.Module = (*module)(nil)
+	_ component.Module           = (*module)(nil)
+	_ component.HealthModule     = (*module)(nil)
+	_ component.ComponentsModule = (*module)(nil)
+	_ component.ContextModule    = (*module)(nil)
 )
 
 // newModule creates a module instance for a specific component.
 func newModule(o *moduleOptions) *module {
-	return &module{
-		o: o,
-		f: newController(controllerOptions{
-			IsModule:          true,
-			ModuleRegistry:    o.ModuleRegistry,
-			ComponentRegistry: o.ComponentRegistry,
-			WorkerPool:        o.WorkerPool,
-			Options: Options{
-				ControllerID: o.ID,
-				Tracer:       o.Tracer,
-				Reg:          o.Reg,
-				Logger:       o.Logger,
-				DataPath:     o.DataPath,
-				OnExportsChange: func(exports map[string]any) {
-					if o.export != nil {
-						o.export(exports)
-					}
-				},
-				Services: o.ServiceMap.List(),
+	mod := &module{o: o}
+	mod.f = newController(controllerOptions{
+		IsModule:        true,
+		Module:          mod,
+		ModuleRegistry:  o.ModuleRegistry,
+		WorkerPool:      o.WorkerPool,
+		ComponentLookup: o.ComponentLookup,
+		Events:          o.Events,
+		ValidateOnly:    o.ValidateOnly,
+		Options: Options{
+			ControllerID: o.ID,
+			Tracer:       o.Tracer,
+			Reg:          o.Reg,
+			Logger:       o.Logger,
+			DataPath:     o.DataPath,
+			OnExportsChange: func(exports map[string]any) {
+				if o.export != nil {
+					o.export(exports)
+				}
 			},
-		}),
-	}
+			Services:          o.ServiceMap.List(),
+			ComponentRegistry: o.ComponentRegistry,
+
+			MaxComponentSeries: o.MaxComponentSeries,
+			MinStability:       o.MinStability,
+			EvaluationTimeout:  o.EvaluationTimeout,
+			MaxModuleDepth:     o.MaxModuleDepth,
+			MaxModules:         o.MaxModules,
+			AuditLogger:        o.AuditLogger,
+			AgentID:            o.AgentID,
+			ClusterName:        o.ClusterName,
+
+			MaxConcurrentEvaluations: o.MaxConcurrentEvaluations,
+			MinUpdateInterval:        o.MinUpdateInterval,
+			RollbackOnFailure:        o.RollbackOnFailure,
+		},
+	})
+	return mod
 }
 
 // LoadConfig parses River config and loads it.
 func (c *module) LoadConfig(config []byte, args map[string]any) error {
+	return c.LoadConfigContext(context.Background(), config, args)
+}
+
+// LoadConfigContext implements [component.ContextModule]. Parsing config and
+// evaluating the components of the module are traced as children of the span
+// in ctx.
+func (c *module) LoadConfigContext(ctx context.Context, config []byte, args map[string]any) error {
+	tracer := c.f.tracer.Tracer("")
+	_, span := tracer.Start(ctx, "ParseModule", trace.WithSpanKind(trace.SpanKindInternal))
+	span.SetAttributes(attribute.String("module_id", c.o.ID))
 	ff, err := ParseSource(c.o.ID, config)
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
 		return err
 	}
-	return c.f.LoadSource(ff, args)
+	span.End()
+
+	// The source is recorded before the config is loaded, since modules nested
+	// in this one are loaded while the config is. It's reverted if loading
+	// fails, so that only successfully loaded sources are checked for cycles.
+	source := moduleSource{sha: ff.SHA256(), args: args}
+	if err := c.checkCycle(source); err != nil {
+		return err
+	}
+	prev := c.setSource(source)
+
+	if err := c.f.loadSource(ctx, ff, args); err != nil {
+		c.setSource(prev)
+		return err
+	}
+	return nil
+}
+
+// maxModuleRecursion is the maximum number of modules a module may be nested
+// in which loaded the same config with different arguments. It bounds
+// recursive modules even if MaxModuleDepth is unset.
+const maxModuleRecursion = 16
+
+// checkCycle returns an error if a module which c is nested in loaded the same
+// config with the same arguments. Such a module would nest modules endlessly,
+// no matter where the config was retrieved from. Modules which load
+// themselves with different arguments, for example to recurse until a
+// component is disabled, may be nested up to maxModuleRecursion times, so
+// that arguments which never stop changing don't nest modules endlessly
+// either.
+func (c *module) checkCycle(source moduleSource) error {
+	var recursion int
+	for m := c.o.parent.o.Owner; m != nil; m = m.o.parent.o.Owner {
+		other := m.getSource()
+		if other.sha != source.sha {
+			continue
+		}
+		if !other.equal(source) {
+			recursion++
+			if recursion >= maxModuleRecursion {
+				return fmt.Errorf("module recursion limit exceeded: %s loads the same config as %d modules it's nested in, up to %s", c.o.ID, recursion, m.o.ID)
+			}
+			continue
+		}
+
+		// Both IDs are paths of loader IDs, and the ID of m is a prefix of the
+		// ID of c.
+		chain := strings.Split(c.o.ID, "/")[strings.Count(m.o.ID, "/"):]
+		return fmt.Errorf("module cycle detected: %s loads the same config as %s (%s)", c.o.ID, m.o.ID, strings.Join(chain, " -> "))
+	}
+	return nil
+}
+
+func (c *module) getSource() moduleSource {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.source
+}
+
+// setSource records the source of c and returns the previous one.
+func (c *module) setSource(source moduleSource) moduleSource {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	prev := c.source
+	c.source = source
+	return prev
+}
+
+// equal reports whether s and other have the same config and arguments. Nil
+// and empty arguments are considered equal.
+func (s moduleSource) equal(other moduleSource) bool {
+	if s.sha != other.sha {
+		return false
+	}
+	if len(s.args) == 0 && len(other.args) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(s.args, other.args)
+}
+
+// CurrentHealth implements component.HealthModule. Only components which are
+// unhealthy or have exited are taken into account, so that components which
+// are still starting up don't affect the health of the module. Either makes
+// the module unhealthy, since the module itself keeps running.
+func (c *module) CurrentHealth() component.Health {
+	health := component.Health{Health: component.HealthTypeHealthy}
+	for _, cn := range c.f.loader.Components() {
+		h := cn.CurrentHealth()
+		if h.Health != component.HealthTypeUnhealthy && h.Health != component.HealthTypeExited {
+			continue
+		}
+		health = component.LeastHealthy(health, component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("component %s is %s: %s", cn.NodeID(), h.Health, h.Message),
+			UpdateTime: h.UpdateTime,
+		})
+	}
+	return health
+}
+
+// Components implements component.ComponentsModule.
+func (c *module) Components() []component.ComponentState {
+	nodes := c.f.loader.Components()
+
+	states := make([]component.ComponentState, 0, len(nodes))
+	for _, cn := range nodes {
+		state := component.ComponentState{
+			ID:     cn.NodeID(),
+			Health: cn.CurrentHealth(),
+		}
+		if bcn, ok := cn.(*controller.BuiltinComponentNode); ok {
+			state.LastEvaluation = bcn.LastEvaluation()
+		}
+		states = append(states, state)
+	}
+	return states
 }
 
 // Run starts the Module. No components within the Module
@@ -146,6 +321,12 @@ func (c *module) Run(ctx context.Context) error {
 	}
 	defer c.o.parent.removeModule(c)
 
+	// Components of the module can't be looked up until it's registered, so
+	// evaluate the nodes which tried to look them up again.
+	if c.o.ComponentLookup != nil {
+		c.o.ComponentLookup.ModuleStarted(c.o.ID)
+	}
+
 	c.f.Run(ctx)
 	return nil
 }
@@ -174,7 +355,7 @@ type moduleControllerOptions struct {
 	ID string
 
 	// ComponentRegistry is where controllers can look up components.
-	ComponentRegistry controller.ComponentRegistry
+	ComponentRegistry ComponentRegistry
 
 	// ModuleRegistry is a shared registry of running modules from the same root
 	// controller.
@@ -187,4 +368,60 @@ type moduleControllerOptions struct {
 	// WorkerPool is a worker pool that can be used to run tasks asynchronously. A default pool will be created if this
 	// is nil.
 	WorkerPool worker.Pool
+
+	// MaxComponentSeries is the maximum number of series each component may
+	// expose. A value of 0 disables the limit.
+	MaxComponentSeries int
+
+	// MinStability is the minimum stability level of components which may be
+	// used in the module.
+	MinStability featuregate.Stability
+
+	// EvaluationTimeout is the maximum amount of time to wait for a single
+	// node in the module to be evaluated. A value of 0 disables the timeout.
+	EvaluationTimeout time.Duration
+
+	// MaxModuleDepth is the maximum number of modules which may be nested in
+	// each other. A value of 0 disables the limit.
+	MaxModuleDepth int
+
+	// MaxModules is the maximum number of modules which may run at the same
+	// time. A value of 0 disables the limit.
+	MaxModules int
+
+	// AuditLogger receives an entry for every configuration load of the
+	// module. May be nil.
+	AuditLogger log.Logger
+
+	// AgentID and ClusterName identify the agent running the module.
+	AgentID     string
+	ClusterName string
+
+	// MaxConcurrentEvaluations is the maximum number of independent nodes in
+	// the module evaluated at the same time when a config is loaded.
+	MaxConcurrentEvaluations int
+
+	// MinUpdateInterval is the minimum amount of time between evaluations of
+	// the dependants of a single node in the module.
+	MinUpdateInterval time.Duration
+
+	// RollbackOnFailure validates the configurations loaded by the module
+	// before applying them.
+	RollbackOnFailure bool
+
+	// ComponentLookup implements the component.exports function for the
+	// whole tree of controllers.
+	ComponentLookup *controller.ComponentLookup
+
+	// Events is the bus shared by the whole tree of controllers to publish
+	// events of components to.
+	Events *controller.EventBus
+
+	// ValidateOnly validates the components of the module instead of building
+	// them.
+	ValidateOnly bool
+
+	// Owner is the module which contains the components that create modules
+	// with this controller. Owner is nil for the root controller.
+	Owner *module
 }
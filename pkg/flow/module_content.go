@@ -0,0 +1,85 @@
+package flow
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow/logging/level"
+)
+
+// moduleContentDir is the directory in the data path where modules persist
+// the content they were last loaded with without errors.
+const moduleContentDir = "modules"
+
+// ModuleContent is the content a module was last loaded with without errors.
+type ModuleContent struct {
+	Content []byte    `json:"-"`
+	SHA256  string    `json:"sha256"`
+	Time    time.Time `json:"time"` // When the module was first loaded with Content.
+}
+
+// ModuleContent returns the content the module with the given ID was last
+// loaded with without errors. Modules persist their content in the data path, so the
+// content is available after the module stops and after restarts. An error
+// wrapping [os.ErrNotExist] is returned if no content is persisted for the
+// module.
+func (f *Flow) ModuleContent(moduleID string) (*ModuleContent, error) {
+	dir := moduleContentPath(f.opts.DataPath, path.Join(f.opts.ControllerID, moduleID))
+
+	var mc ModuleContent
+	bb, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bb, &mc); err != nil {
+		return nil, fmt.Errorf("decoding metadata of module %q: %w", moduleID, err)
+	}
+	mc.Content, err = os.ReadFile(filepath.Join(dir, "content.river"))
+	if err != nil {
+		return nil, err
+	}
+	return &mc, nil
+}
+
+func moduleContentPath(dataPath, moduleID string) string {
+	return filepath.Join(dataPath, moduleContentDir, filepath.FromSlash(moduleID))
+}
+
+// persistModuleContent writes the content of source to the data path, if it
+// differs from the content the module was previously loaded with. Failures
+// are only logged, since the module itself was loaded. f.loadMut must be
+// held when calling persistModuleContent.
+func (f *Flow) persistModuleContent(source *Source) {
+	if f.opts.DataPath == "" {
+		return
+	}
+	sha := source.SHA256()
+	if sha == f.persistedHash {
+		return
+	}
+
+	dir := moduleContentPath(f.opts.DataPath, f.opts.ControllerID)
+	meta, err := json.Marshal(ModuleContent{
+		SHA256: hex.EncodeToString(sha[:]),
+		Time:   time.Now(),
+	})
+	if err == nil {
+		err = os.MkdirAll(dir, 0750)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "content.river"), source.sourceMap[f.opts.ControllerID], 0640)
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "metadata.json"), meta, 0640)
+	}
+	if err != nil {
+		level.Warn(f.log).Log("msg", "failed to persist module content", "err", err)
+		return
+	}
+	f.persistedHash = sha
+}
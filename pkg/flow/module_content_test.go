@@ -0,0 +1,64 @@
+package flow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleContent(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	ctrl := New(testOptions(t))
+	defer cleanUpController(ctrl)
+
+	load := func(inner string) error {
+		source, err := ParseSource(t.Name(), []byte(`
+			module.string "m" {
+				content = `+"`"+inner+"`"+`
+			}
+		`))
+		require.NoError(t, err)
+		return ctrl.LoadSource(source, nil)
+	}
+
+	const (
+		first  = `testcomponents.passthrough "inner" { input = "first" }`
+		second = `testcomponents.passthrough "inner" { input = "second" }`
+	)
+
+	_, err := ctrl.ModuleContent("module.string.m")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, load(first))
+	mc, err := ctrl.ModuleContent("module.string.m")
+	require.NoError(t, err)
+	require.Equal(t, first, string(mc.Content))
+	firstHash := sha256.Sum256([]byte(first))
+	require.Equal(t, hex.EncodeToString(firstHash[:]), mc.SHA256)
+	firstTime := mc.Time
+
+	// Loading the same content again keeps the time it was first loaded.
+	require.NoError(t, load(first))
+	mc, err = ctrl.ModuleContent("module.string.m")
+	require.NoError(t, err)
+	require.Equal(t, firstTime, mc.Time)
+
+	require.NoError(t, load(second))
+	mc, err = ctrl.ModuleContent("module.string.m")
+	require.NoError(t, err)
+	require.Equal(t, second, string(mc.Content))
+	require.True(t, mc.Time.After(firstTime))
+
+	// Content which fails to load isn't persisted.
+	require.Error(t, load(`testcomponents.missing "inner" {}`))
+	mc, err = ctrl.ModuleContent("module.string.m")
+	require.NoError(t, err)
+	require.Equal(t, second, string(mc.Content))
+
+	_, err = ctrl.ModuleContent("module.string.missing")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -5,12 +5,16 @@ package flow_test
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/component"
+	mod "github.com/grafana/agent/component/module"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow"
 	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
 	"github.com/grafana/agent/pkg/flow/logging"
@@ -23,6 +27,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 
+	_ "github.com/grafana/agent/component/local/file"
+	"github.com/grafana/agent/component/module/foreach"
 	_ "github.com/grafana/agent/component/module/string"
 )
 
@@ -141,6 +147,177 @@ func TestUpdates_ThroughModule(t *testing.T) {
 	}, 3*time.Second, 10*time.Millisecond)
 }
 
+func TestUpdates_FunctionThroughModule(t *testing.T) {
+	// The module exports a function instead of a value, which the parent
+	// config calls.
+	module := `
+	export "pick" {
+		value = coalesce
+	}
+`
+
+	config := `
+	module.string "test" {
+		content = ` + strconv.Quote(module) + `
+	}
+
+	testcomponents.passthrough "pt" {
+		input = module.string.test.exports.pick("", "picked")
+		lag = "1ms"
+	}
+`
+
+	ctrl := flow.New(testOptions(t))
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.NotNil(t, f)
+
+	err = ctrl.LoadSource(f, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.pt")
+		return export.Output == "picked"
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestUpdates_Foreach(t *testing.T) {
+	module := `
+	argument "each" { }
+
+	argument "factor" { }
+
+	export "value" {
+		value = argument.each.value * argument.factor.value
+	}
+`
+
+	configWithCollection := func(collection string) string {
+		return `
+	module.foreach "multiply" {
+		collection = ` + collection + `
+		content    = ` + strconv.Quote(module) + `
+
+		arguments {
+			factor = 2
+		}
+	}
+`
+	}
+
+	ctrl := flow.New(testOptions(t))
+	f, err := flow.ParseSource(t.Name(), []byte(configWithCollection("[1, 2]")))
+	require.NoError(t, err)
+	require.NotNil(t, f)
+
+	err = ctrl.LoadSource(f, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		export := getExport[foreach.Exports](t, ctrl, "", "module.foreach.multiply")
+		return fmt.Sprint(export.Exports) == "map[0:map[value:2] 1:map[value:4]]"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Elements which are removed from the collection stop their module.
+	f, err = flow.ParseSource(t.Name(), []byte(configWithCollection(`{ a = 5 }`)))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	require.Eventually(t, func() bool {
+		export := getExport[foreach.Exports](t, ctrl, "", "module.foreach.multiply")
+		return fmt.Sprint(export.Exports) == "map[a:map[value:10]]"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	info, err := ctrl.GetComponent(component.ID{LocalID: "module.foreach.multiply"}, component.InfoOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"module.foreach.multiply/each_a"}, info.ModuleIDs)
+}
+
+func TestUpdates_UnchangedNestedModule(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	configWithInput := func(a, b string) string {
+		nested := func(input string) string {
+			return `testcomponents.passthrough "static" { input = ` + strconv.Quote(input) + ` }`
+		}
+		outer := `
+	module.string "a" {
+		content = ` + strconv.Quote(nested(a)) + `
+	}
+
+	module.string "b" {
+		content = ` + strconv.Quote(nested(b)) + `
+	}
+`
+		return `
+	module.string "outer" {
+		content = ` + strconv.Quote(outer) + `
+	}
+`
+	}
+
+	ctrl := runModuleTestController(t, flow.Options{})
+	f, err := flow.ParseSource(t.Name(), []byte(configWithInput("a", "b")))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	// The components in a module are evaluated whenever the module is loaded,
+	// which updates their health.
+	health := func(loader string) (component.Health, error) {
+		id := component.ID{ModuleID: "module.string.outer/module.string." + loader, LocalID: "testcomponents.passthrough.static"}
+		info, err := ctrl.GetComponent(id, component.InfoOptions{GetHealth: true})
+		if err != nil {
+			return component.Health{}, err
+		}
+		return info.Health, nil
+	}
+	running := func(loader string) bool {
+		h, err := health(loader)
+		return err == nil && h.Message == "started component"
+	}
+	require.Eventually(t, func() bool {
+		return running("a") && running("b")
+	}, 3*time.Second, 10*time.Millisecond)
+	healthA, err := health("a")
+	require.NoError(t, err)
+	healthB, err := health("b")
+	require.NoError(t, err)
+
+	// Only the nested module whose content changed is loaded again.
+	f, err = flow.ParseSource(t.Name(), []byte(configWithInput("a", "b changed")))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	newHealthA, err := health("a")
+	require.NoError(t, err)
+	require.Equal(t, healthA, newHealthA)
+	newHealthB, err := health("b")
+	require.NoError(t, err)
+	require.True(t, newHealthB.UpdateTime.After(healthB.UpdateTime))
+}
+
 func TestUpdates_TwoModules_SameCompNames(t *testing.T) {
 	// We use this module in a Flow config below.
 	module := `
@@ -209,6 +386,406 @@ func TestUpdates_TwoModules_SameCompNames(t *testing.T) {
 	}, 3*time.Second, 10*time.Millisecond)
 }
 
+func TestModuleCycle(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	// The module passes its own config to a nested module, which would nest
+	// modules endlessly.
+	module := `
+	argument "config" {}
+
+	module.string "inner" {
+		content = argument.config.value
+
+		arguments {
+			config = argument.config.value
+		}
+	}
+`
+	config := fmt.Sprintf(`
+	module.string "outer" {
+		content = %[1]q
+
+		arguments {
+			config = %[1]q
+		}
+	}
+`, module)
+
+	ctrl := runModuleTestController(t, flow.Options{})
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+
+	err = ctrl.LoadSource(f, nil)
+	require.ErrorContains(t, err, "module cycle detected: module.string.outer/module.string.inner loads the same config as module.string.outer (module.string.outer -> module.string.inner)")
+}
+
+func TestModuleRecursion(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	// The module loads itself with a decreasing depth until the nested module
+	// is disabled, so modules aren't nested endlessly.
+	module := `
+	argument "config" {}
+	argument "depth" {}
+
+	module.string "inner" {
+		enabled = argument.depth.value > 0
+		content = argument.config.value
+
+		arguments {
+			config = argument.config.value
+			depth  = argument.depth.value - 1
+		}
+	}
+`
+	config := func(depth int) string {
+		return fmt.Sprintf(`
+	module.string "outer" {
+		content = %[1]q
+
+		arguments {
+			config = %[1]q
+			depth  = %[2]d
+		}
+	}
+`, module, depth)
+	}
+
+	ctrl := runModuleTestController(t, flow.Options{})
+	f, err := flow.ParseSource(t.Name(), []byte(config(2)))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	// Deeper recursion is still bounded by the maximum module depth.
+	ctrl = runModuleTestController(t, flow.Options{MaxModuleDepth: 5})
+	f, err = flow.ParseSource(t.Name(), []byte(config(100)))
+	require.NoError(t, err)
+	require.ErrorContains(t, ctrl.LoadSource(f, nil), "exceeds the maximum module depth of 5")
+}
+
+func TestModuleRecursionLimit(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	// The arguments of the module change on every level, so it's not a cycle,
+	// but it never stops nesting modules.
+	module := `
+	argument "config" {}
+	argument "n" {}
+
+	module.string "inner" {
+		content = argument.config.value
+
+		arguments {
+			config = argument.config.value
+			n      = argument.n.value + 1
+		}
+	}
+`
+	config := fmt.Sprintf(`
+	module.string "outer" {
+		content = %[1]q
+
+		arguments {
+			config = %[1]q
+			n      = 0
+		}
+	}
+`, module)
+
+	// Recursion is limited even without a maximum module depth.
+	ctrl := runModuleTestController(t, flow.Options{})
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.ErrorContains(t, ctrl.LoadSource(f, nil), "module recursion limit exceeded")
+}
+
+func TestModuleHealth(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+
+	// The file is read by a component nested two modules deep.
+	nested := `
+	local.file "inner" {
+		filename       = ` + strconv.Quote(filename) + `
+		detector       = "poll"
+		poll_frequency = "10ms"
+	}
+`
+	config := `
+	module.string "outer" {
+		content = ` + strconv.Quote(`module.string "nested" { content = `+strconv.Quote(nested)+` }`) + `
+	}
+`
+
+	ctrl := runModuleTestController(t, flow.Options{})
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	outerHealth := func() component.Health {
+		info, err := ctrl.GetComponent(component.ID{LocalID: "module.string.outer"}, component.InfoOptions{GetHealth: true})
+		require.NoError(t, err)
+		return info.Health
+	}
+
+	require.Eventually(t, func() bool {
+		return outerHealth().Health == component.HealthTypeHealthy
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Once the file is removed, the outer module reports the unhealthy
+	// component of the nested module.
+	require.NoError(t, os.Remove(filename))
+	require.Eventually(t, func() bool {
+		return outerHealth().Health == component.HealthTypeUnhealthy
+	}, 3*time.Second, 10*time.Millisecond)
+	require.Contains(t, outerHealth().Message, "component module.string.nested is unhealthy: component local.file.inner is unhealthy:")
+
+	require.NoError(t, os.WriteFile(filename, []byte("hello"), 0644))
+	require.Eventually(t, func() bool {
+		return outerHealth().Health == component.HealthTypeHealthy
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestModuleDebugInfo(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	module := `
+	testcomponents.passthrough "static" {
+		input = "hello"
+	}
+`
+	config := `
+	module.string "test" {
+		content = ` + strconv.Quote(module) + `
+	}
+`
+
+	ctrl := runModuleTestController(t, flow.Options{})
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	debugInfo := func() mod.DebugInfo {
+		info, err := ctrl.GetComponent(component.ID{LocalID: "module.string.test"}, component.InfoOptions{GetDebugInfo: true})
+		require.NoError(t, err)
+		return info.DebugInfo.(mod.DebugInfo)
+	}
+
+	require.Eventually(t, func() bool {
+		components := debugInfo().Components
+		return len(components) == 1 && components[0].Health == component.HealthTypeHealthy
+	}, 3*time.Second, 10*time.Millisecond)
+
+	components := debugInfo().Components
+	require.Equal(t, "testcomponents.passthrough.static", components[0].ID)
+	require.False(t, components[0].LastEvaluation.IsZero())
+}
+
+func TestModuleAgentVariable(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	module := `
+	export "agent" {
+		value = agent.id + "," + agent.cluster_name + "," + agent.module_id
+	}
+`
+	config := `
+	module.string "test" {
+		content = ` + strconv.Quote(module) + `
+	}
+
+	testcomponents.passthrough "root" {
+		input = agent.module_id + "," + module.string.test.exports.agent
+	}
+`
+
+	ctrl := runModuleTestController(t, flow.Options{
+		ControllerID: "root",
+		AgentID:      "agent-1",
+		ClusterName:  "cluster-a",
+	})
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(f, nil))
+
+	require.Eventually(t, func() bool {
+		export := getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.root")
+		return export.Output == "root,agent-1,cluster-a,root/module.string.test"
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestComponentExportsFunction(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	config := `
+	module.string "a" {
+		content = "testcomponents.passthrough \"pt\" { input = \"hello\" }"
+	}
+
+	module.string "b" {
+		content = "export \"output\" { value = coalesce(component.exports(\"module.string.a/testcomponents.passthrough.pt\"), {output = \"unknown\"}).output }"
+	}
+
+	testcomponents.passthrough "root" {
+		input = module.string.b.exports.output
+	}
+`
+
+	t.Run("experimental", func(t *testing.T) {
+		ctrl := runModuleTestController(t, flow.Options{MinStability: featuregate.StabilityExperimental})
+		f, err := flow.ParseSource(t.Name(), []byte(config))
+		require.NoError(t, err)
+		// Components of modules can't be looked up until the modules run, so
+		// module.string.b falls back to the default value at first.
+		require.NoError(t, ctrl.LoadSource(f, nil))
+		require.Equal(t, "unknown", getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.root").Output)
+
+		// module.string.b is evaluated again once module.string.a runs.
+		require.Eventually(t, func() bool {
+			export := getExport[testcomponents.PassthroughExports](t, ctrl, "", "testcomponents.passthrough.root")
+			return export.Output == "hello"
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		ctrl := runModuleTestController(t, flow.Options{MinStability: featuregate.StabilityExperimental})
+		f, err := flow.ParseSource(t.Name(), []byte(`
+			testcomponents.passthrough "a" {
+				input = coalesce(component.exports("testcomponents.passthrough.b"), {output = "a"}).output
+			}
+
+			testcomponents.passthrough "b" {
+				input = coalesce(component.exports("testcomponents.passthrough.a"), {output = "b"}).output
+			}
+		`))
+		require.NoError(t, err)
+
+		err = ctrl.LoadSource(f, nil)
+		require.ErrorContains(t, err, "would form a cycle")
+	})
+
+	t.Run("stable", func(t *testing.T) {
+		ctrl := runModuleTestController(t, flow.Options{MinStability: featuregate.StabilityStable})
+		f, err := flow.ParseSource(t.Name(), []byte(`
+			logging {
+				level = component.exports("testcomponents.passthrough.other").output
+			}
+		`))
+		require.NoError(t, err)
+
+		err = ctrl.LoadSource(f, nil)
+		require.ErrorContains(t, err, `function component.exports is at stability level "experimental"`)
+	})
+}
+
+func TestModuleEvaluationTimeout(t *testing.T) {
+	t.Cleanup(func() { verifyNoGoroutineLeaks(t) })
+
+	module := `
+	testcomponents.passthrough "slow" {
+		input = "hello"
+		lag   = "300ms"
+	}
+`
+	config := `
+	module.string "test" {
+		content = ` + strconv.Quote(module) + `
+	}
+`
+
+	ctrl := runModuleTestController(t, flow.Options{EvaluationTimeout: 50 * time.Millisecond})
+	f, err := flow.ParseSource(t.Name(), []byte(config))
+	require.NoError(t, err)
+
+	// A module loader whose module has a slow component times out like any
+	// other component, so it doesn't block the load.
+	start := time.Now()
+	err = ctrl.LoadSource(f, nil)
+	require.Less(t, time.Since(start), 300*time.Millisecond, "LoadSource should not wait for the slow component")
+	require.ErrorContains(t, err, "evaluation of module.string.test exceeded the evaluation timeout of 50ms")
+
+	// Wait for the slow evaluation to finish before checking for leaks.
+	time.Sleep(500 * time.Millisecond)
+}
+
+func TestModuleLimits(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	config := `
+	module.string "a" {
+		content = "module.string \"nested\" { content = \"\" }"
+	}
+
+	module.string "b" {
+		content = ""
+	}
+`
+
+	t.Run("depth", func(t *testing.T) {
+		ctrl := runModuleTestController(t, flow.Options{MaxModuleDepth: 1})
+		f, err := flow.ParseSource(t.Name(), []byte(config))
+		require.NoError(t, err)
+
+		err = ctrl.LoadSource(f, nil)
+		require.ErrorContains(t, err, `module "module.string.a/module.string.nested" exceeds the maximum module depth of 1`)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		ctrl := runModuleTestController(t, flow.Options{MaxModules: 2})
+		f, err := flow.ParseSource(t.Name(), []byte(config))
+		require.NoError(t, err)
+		require.NoError(t, ctrl.LoadSource(f, nil))
+
+		// Three modules are loaded, but only two of them can run. The loader
+		// whose module can't run exits.
+		require.Eventually(t, func() bool {
+			var exited int
+			for _, id := range []component.ID{
+				{LocalID: "module.string.a"},
+				{LocalID: "module.string.b"},
+				{ModuleID: "module.string.a", LocalID: "module.string.nested"},
+			} {
+				info, err := ctrl.GetComponent(id, component.InfoOptions{GetHealth: true})
+				if err != nil {
+					return false
+				}
+				if info.Health.Health == component.HealthTypeExited {
+					exited++
+				}
+			}
+			return exited == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+// runModuleTestController runs a new controller with o until the test ends.
+// testOptions isn't used, since its cluster service is only stopped if a
+// config was loaded successfully.
+func runModuleTestController(t *testing.T, o flow.Options) *flow.Flow {
+	t.Helper()
+
+	logger, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+	o.Logger = logger
+	o.DataPath = t.TempDir()
+
+	ctrl := flow.New(o)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return ctrl
+}
+
 func testOptions(t *testing.T) flow.Options {
 	t.Helper()
 	s, err := logging.New(os.Stderr, logging.DefaultOptions)
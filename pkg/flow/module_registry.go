@@ -38,14 +38,18 @@ func (reg *moduleRegistry) List() []*module {
 }
 
 // Register registers a module by ID. It returns an error if that module is
+// already registered, or if limit is greater than 0 and limit modules are
 // already registered.
-func (reg *moduleRegistry) Register(id string, mod *module) error {
+func (reg *moduleRegistry) Register(id string, mod *module, limit int) error {
 	reg.mut.Lock()
 	defer reg.mut.Unlock()
 
 	if _, exist := reg.modules[id]; exist {
 		return fmt.Errorf("module %q already exists", id)
 	}
+	if limit > 0 && len(reg.modules) >= limit {
+		return fmt.Errorf("module %q can't run, since the limit of %d running modules was reached", id, limit)
+	}
 
 	reg.modules[id] = mod
 	return nil
@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/internal/featuregate"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/worker"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/service"
+	"github.com/grafana/river/rivertypes"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +37,13 @@ const argumentWithFullOptsConfig = `
 		default = "default_value"
 	}`
 
+const argumentTypedConfig = `
+	argument "port" {
+		type     = "number"
+		optional = true
+		default  = 8080
+	}`
+
 const exportStringConfig = `
 	export "username" {
 		value = "bob"
@@ -45,6 +54,12 @@ const exportDummy = `
 		value = "bob"
 	}`
 
+const moduleMetaConfig = `
+	module_meta {
+		name    = "test"
+		version = "1.2.0"
+	}`
+
 const serviceConfig = `
 	testservice {}`
 
@@ -119,6 +134,54 @@ func TestModule(t *testing.T) {
 			name:                "Argument block with comment is parseable",
 			exportModuleContent: argumentWithFullOptsConfig,
 		},
+		{
+			name:                "Argument with default is optional",
+			exportModuleContent: `argument "port" { default = 8080 }` + "\n" + `export "port" { value = argument.port.value }`,
+			expectedExports:     []string{"port"},
+		},
+		{
+			name:                "Typed argument with matching value",
+			exportModuleContent: argumentTypedConfig,
+			args:                map[string]interface{}{"port": 9090},
+		},
+		{
+			name:                  "Typed argument with mismatched value",
+			exportModuleContent:   argumentTypedConfig,
+			args:                  map[string]interface{}{"port": "9090"},
+			expectedErrorContains: "invalid value for argument \"port\" to module: expected number, got string",
+		},
+		{
+			name:                "Typed argument with capsule value",
+			exportModuleContent: `argument "password" { type = "capsule" }`,
+			args:                map[string]interface{}{"password": rivertypes.Secret("secret")},
+		},
+		{
+			name:                  "Typed argument with capsule value for string",
+			exportModuleContent:   `argument "password" { type = "string" }`,
+			args:                  map[string]interface{}{"password": rivertypes.Secret("secret")},
+			expectedErrorContains: "invalid value for argument \"password\" to module: expected string, got capsule",
+		},
+		{
+			name:                  "Typed argument with mismatched default",
+			exportModuleContent:   "argument \"port\" {\n type = \"number\"\n default = \"8080\"\n optional = true\n}",
+			expectedErrorContains: "invalid default value for argument \"port\": expected number, got string",
+		},
+		{
+			name:                  "Argument with unsupported type",
+			exportModuleContent:   `argument "port" { type = "integer" }`,
+			args:                  map[string]interface{}{"port": 9090},
+			expectedErrorContains: "unsupported type \"integer\" for argument \"port\"",
+		},
+		{
+			name:                "Module meta block allowed in module config",
+			exportModuleContent: moduleMetaConfig + exportStringConfig,
+			expectedExports:     []string{"username"},
+		},
+		{
+			name:                  "Duplicate module meta config",
+			exportModuleContent:   moduleMetaConfig + moduleMetaConfig,
+			expectedErrorContains: "\"module_meta\" block already declared",
+		},
 	}
 
 	for _, tc := range tt {
@@ -169,6 +232,16 @@ func TestExportsNotInModules(t *testing.T) {
 	require.ErrorContains(t, err, "export blocks only allowed inside a module")
 }
 
+func TestModuleMetaNotInModules(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	f := New(testOptions(t))
+	defer cleanUpController(f)
+	fl, err := ParseSource("test", []byte(moduleMetaConfig))
+	require.NoError(t, err)
+	err = f.LoadSource(fl, nil)
+	require.ErrorContains(t, err, "module_meta block only allowed inside a module")
+}
+
 func TestExportsWhenNotUsed(t *testing.T) {
 	defer verifyNoGoroutineLeaks(t)
 	f := New(testOptions(t))
@@ -274,9 +347,10 @@ func testModuleControllerOptions(t *testing.T) *moduleControllerOptions {
 
 func init() {
 	component.Register(component.Registration{
-		Name:    "test.module",
-		Args:    TestArguments{},
-		Exports: TestExports{},
+		Name:      "test.module",
+		Stability: featuregate.StabilityExperimental,
+		Args:      TestArguments{},
+		Exports:   TestExports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return &testModule{
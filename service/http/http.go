@@ -4,6 +4,8 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -44,6 +46,20 @@ type Options struct {
 	ReadyFunc  func() bool
 	ReloadFunc func() (*flow.Source, error)
 
+	// ValidateFunc checks the configuration which would be loaded by
+	// ReloadFunc without applying it.
+	ValidateFunc func() error
+
+	// DrainFunc stops scraping components and then shuts down the process.
+	// DrainFunc must not block.
+	DrainFunc func()
+
+	// RefreshFunc requests the component with the given ID to reload its
+	// content from its external source. If id is empty, all such components
+	// are requested to reload. RefreshFunc returns the number of components
+	// which were requested to reload, and must not block until they did.
+	RefreshFunc func(id string) (int, error)
+
 	HTTPListenAddr   string // Address to listen for HTTP traffic on.
 	MemoryListenAddr string // Address to accept in-memory traffic on.
 	EnablePProf      bool   // Whether pprof endpoints should be exposed.
@@ -184,11 +200,15 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 	}
 
 	if s.opts.ReloadFunc != nil {
-		r.HandleFunc("/-/reload", func(w http.ResponseWriter, _ *http.Request) {
+		r.HandleFunc("/-/reload", func(w http.ResponseWriter, r *http.Request) {
 			level.Info(s.log).Log("msg", "reload requested via /-/reload endpoint")
 			defer level.Info(s.log).Log("msg", "config reloaded")
 
 			_, err := s.opts.ReloadFunc()
+			if acceptsJSON(r) {
+				writeReloadJSON(w, err)
+				return
+			}
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -197,6 +217,52 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}).Methods(http.MethodGet, http.MethodPost)
 	}
 
+	if s.opts.ValidateFunc != nil {
+		r.HandleFunc("/-/validate", func(w http.ResponseWriter, r *http.Request) {
+			level.Info(s.log).Log("msg", "validation requested via /-/validate endpoint")
+
+			err := s.opts.ValidateFunc()
+			if acceptsJSON(r) {
+				writeReloadJSON(w, err)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintln(w, "config is valid")
+		}).Methods(http.MethodGet, http.MethodPost)
+	}
+
+	if s.opts.DrainFunc != nil {
+		r.HandleFunc("/-/drain", func(w http.ResponseWriter, _ *http.Request) {
+			level.Info(s.log).Log("msg", "drain requested via /-/drain endpoint")
+
+			s.opts.DrainFunc()
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintln(w, "stopping scrapes, the agent shuts down afterwards")
+		}).Methods(http.MethodPost)
+	}
+
+	if s.opts.RefreshFunc != nil {
+		r.HandleFunc("/-/refresh", func(w http.ResponseWriter, r *http.Request) {
+			id := r.URL.Query().Get("id")
+			level.Info(s.log).Log("msg", "refresh requested via /-/refresh endpoint", "id", id)
+
+			refreshed, err := s.opts.RefreshFunc(id)
+			switch {
+			case errors.Is(err, component.ErrComponentNotFound):
+				http.Error(w, fmt.Sprintf("component %q not found", id), http.StatusNotFound)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintf(w, "refresh requested for %d components\n", refreshed)
+		}).Methods(http.MethodPost)
+	}
+
 	// Wire custom service handlers for services which depend on the http
 	// service.
 	//
@@ -258,6 +324,45 @@ func (s *Service) getServiceRoutes(host service.Host) []serviceRoute {
 	return routes
 }
 
+// acceptsJSON reports whether the client of r asked for a JSON response.
+func acceptsJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == "application/json" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeReloadJSON writes the result of a reload or validation as JSON. Errors are written
+// as a list of structured errors so that clients can point to the position
+// in the configuration which caused them.
+func writeReloadJSON(w http.ResponseWriter, err error) {
+	type reloadJSON struct {
+		Status string                  `json:"status"`
+		Errors []component.ErrorDetail `json:"errors,omitempty"`
+	}
+
+	resp := reloadJSON{Status: "success"}
+	status := http.StatusOK
+	if err != nil {
+		resp = reloadJSON{Status: "error", Errors: component.ErrorDetails(err)}
+		status = http.StatusBadRequest
+	}
+
+	bb, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(bb)
+}
+
 func (s *Service) componentHandler(host service.Host) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Trim the path prefix to get our full path.
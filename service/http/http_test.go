@@ -3,7 +3,10 @@ package http
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/grafana/agent/component"
@@ -12,6 +15,8 @@ import (
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/agent/service"
 	"github.com/grafana/river"
+	"github.com/grafana/river/diag"
+	"github.com/grafana/river/token"
 	"github.com/phayes/freeport"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/config"
@@ -45,6 +50,85 @@ func TestHTTP(t *testing.T) {
 	})
 }
 
+func TestDrain(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	env, err := newTestEnvironment(t)
+	require.NoError(t, err)
+	require.NoError(t, env.ApplyConfig(`/* empty */`))
+
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	util.Eventually(t, func(t require.TestingT) {
+		resp, err := http.Post(fmt.Sprintf("http://%s/-/drain", env.ListenAddr()), "", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	})
+	require.True(t, env.drained.Load())
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	env, err := newTestEnvironment(t)
+	require.NoError(t, err)
+	require.NoError(t, env.ApplyConfig(`/* empty */`))
+
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	refresh := func(t require.TestingT, query string) *http.Response {
+		resp, err := http.Post(fmt.Sprintf("http://%s/-/refresh%s", env.ListenAddr(), query), "", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp
+	}
+
+	util.Eventually(t, func(t require.TestingT) {
+		require.Equal(t, http.StatusAccepted, refresh(t, "").StatusCode)
+	})
+	require.Equal(t, http.StatusAccepted, refresh(t, "?id=module.git.shared/remote.http.config").StatusCode)
+	require.Equal(t, http.StatusNotFound, refresh(t, "?id=module.git.missing").StatusCode)
+
+	require.Equal(t, []string{"", "module.git.shared/remote.http.config", "module.git.missing"}, env.refreshed)
+}
+
+func TestValidate(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+
+	env, err := newTestEnvironment(t)
+	require.NoError(t, err)
+	require.NoError(t, env.ApplyConfig(`/* empty */`))
+
+	go func() {
+		require.NoError(t, env.Run(ctx))
+	}()
+
+	validate := func(t require.TestingT) (int, string) {
+		resp, err := http.Post(fmt.Sprintf("http://%s/-/validate", env.ListenAddr()), "", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		bb, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(bb)
+	}
+
+	util.Eventually(t, func(t require.TestingT) {
+		status, _ := validate(t)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	env.invalid.Store(true)
+	status, body := validate(t)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Contains(t, body, `component "local.file.missing" does not exist`)
+}
+
 func TestTLS(t *testing.T) {
 	ctx := componenttest.TestContext(t)
 
@@ -155,9 +239,49 @@ func Test_Toggle_TLS(t *testing.T) {
 	}
 }
 
+func TestWriteReloadJSON(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writeReloadJSON(rec, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"status": "success"}`, rec.Body.String())
+	})
+
+	t.Run("Diagnostics", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		writeReloadJSON(rec, fmt.Errorf("error during the initial grafana/agent load: %w", diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			StartPos: token.Position{Filename: "config.river", Line: 4, Column: 3},
+			Message:  "component \"local.file.missing\" does not exist or is out of scope",
+		}}))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.JSONEq(t, `{
+			"status": "error",
+			"errors": [{
+				"severity": "error",
+				"message": "component \"local.file.missing\" does not exist or is out of scope",
+				"file": "config.river",
+				"line": 4,
+				"column": 3
+			}]
+		}`, rec.Body.String())
+	})
+}
+
+func TestAcceptsJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/-/reload", nil)
+	require.False(t, acceptsJSON(req))
+
+	req.Header.Set("Accept", "text/html, application/json;q=0.9")
+	require.True(t, acceptsJSON(req))
+}
+
 type testEnvironment struct {
-	svc  *Service
-	addr string
+	svc       *Service
+	addr      string
+	drained   atomic.Bool
+	invalid   atomic.Bool
+	refreshed []string
 }
 
 func newTestEnvironment(t *testing.T) (*testEnvironment, error) {
@@ -166,23 +290,37 @@ func newTestEnvironment(t *testing.T) (*testEnvironment, error) {
 		return nil, err
 	}
 
-	svc := New(Options{
+	env := &testEnvironment{
+		addr: fmt.Sprintf("127.0.0.1:%d", port),
+	}
+	env.svc = New(Options{
 		Logger:   util.TestLogger(t),
 		Tracer:   noop.NewTracerProvider(),
 		Gatherer: prometheus.NewRegistry(),
 
 		ReadyFunc:  func() bool { return true },
 		ReloadFunc: func() (*flow.Source, error) { return nil, nil },
+		DrainFunc:  func() { env.drained.Store(true) },
+		RefreshFunc: func(id string) (int, error) {
+			env.refreshed = append(env.refreshed, id)
+			if id == "module.git.missing" {
+				return 0, component.ErrComponentNotFound
+			}
+			return 1, nil
+		},
+		ValidateFunc: func() error {
+			if env.invalid.Load() {
+				return fmt.Errorf("component \"local.file.missing\" does not exist")
+			}
+			return nil
+		},
 
 		HTTPListenAddr:   fmt.Sprintf("127.0.0.1:%d", port),
 		MemoryListenAddr: "agent.internal:12345",
 		EnablePProf:      true,
 	})
 
-	return &testEnvironment{
-		svc:  svc,
-		addr: fmt.Sprintf("127.0.0.1:%d", port),
-	}, nil
+	return env, nil
 }
 
 func (env *testEnvironment) ApplyConfig(config string) error {
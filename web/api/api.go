@@ -32,10 +32,20 @@ func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	// id to contain / characters, which is used by nested module IDs and
 	// component IDs.
 
+	r.Handle(path.Join(urlPrefix, "/modules"), httputil.CompressionHandler{Handler: f.listModulesHandler()})
+	r.Handle(path.Join(urlPrefix, "/modules/graph"), httputil.CompressionHandler{Handler: f.moduleGraphHandler()})
 	r.Handle(path.Join(urlPrefix, "/modules/{moduleID:.+}/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
+	r.Handle(path.Join(urlPrefix, "/modules/{moduleID:.+}/content"), httputil.CompressionHandler{Handler: f.moduleContentHandler()})
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components/{id:.+}"), httputil.CompressionHandler{Handler: f.getComponentHandler()})
+	r.Handle(path.Join(urlPrefix, "/debuginfo/{id:.+}"), httputil.CompressionHandler{Handler: f.debugInfoHandler()})
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: f.getClusteringPeersHandler()})
+	r.Handle(path.Join(urlPrefix, "/config/resolved"), httputil.CompressionHandler{Handler: f.resolvedConfigHandler()})
+	r.Handle(path.Join(urlPrefix, "/config/diff"), httputil.CompressionHandler{Handler: f.configDiffHandler()})
+	r.Handle(path.Join(urlPrefix, "/config/status"), httputil.CompressionHandler{Handler: f.configStatusHandler()})
+	// The events stream isn't compressed, so that events are sent to clients
+	// as soon as they're published.
+	r.Handle(path.Join(urlPrefix, "/events"), f.eventsHandler())
 }
 
 func (f *FlowAPI) listComponentsHandler() http.HandlerFunc {
@@ -74,6 +84,8 @@ func (f *FlowAPI) getComponentHandler() http.HandlerFunc {
 			GetArguments: true,
 			GetExports:   true,
 			GetDebugInfo: true,
+
+			GetHealthHistory: true,
 		})
 		if err != nil {
 			http.NotFound(w, r)
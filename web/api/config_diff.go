@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grafana/agent/pkg/flow"
+)
+
+// loadDiffProvider is implemented by component providers which record the
+// changes made by their most recent configuration load.
+type loadDiffProvider interface {
+	LastLoadDiff() flow.LoadDiff
+}
+
+// configDiffHandler returns the components added, removed, and changed by the
+// most recent configuration load as JSON.
+func (f *FlowAPI) configDiffHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		provider, ok := f.flow.(loadDiffProvider)
+		if !ok {
+			http.Error(w, "configuration diffs are not supported", http.StatusNotImplemented)
+			return
+		}
+
+		bb, err := json.Marshal(provider.LastLoadDiff())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/stretchr/testify/require"
+)
+
+type testDiffProvider struct {
+	testProvider
+	diff flow.LoadDiff
+}
+
+func (p testDiffProvider) LastLoadDiff() flow.LoadDiff { return p.diff }
+
+func TestConfigDiffHandler(t *testing.T) {
+	get := func(t *testing.T, provider component.Provider) (int, string) {
+		t.Helper()
+
+		r := mux.NewRouter()
+		NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+		srv := httptest.NewServer(r)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/api/v0/web/config/diff")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		bb, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(bb)
+	}
+
+	t.Run("Supported", func(t *testing.T) {
+		status, body := get(t, testDiffProvider{diff: flow.LoadDiff{
+			Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Added:   []string{"prometheus.scrape.default"},
+			Removed: []string{},
+			Changed: []string{"module.file.a"},
+			Modules: []string{"module.file.a"},
+		}})
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{
+			"time": "2024-01-02T03:04:05Z",
+			"added": ["prometheus.scrape.default"],
+			"removed": [],
+			"changed": ["module.file.a"],
+			"modules": ["module.file.a"]
+		}`, body)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		status, _ := get(t, testProvider{})
+		require.Equal(t, http.StatusNotImplemented, status)
+	})
+}
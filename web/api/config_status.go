@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grafana/agent/pkg/flow"
+)
+
+// loadStatusProvider is implemented by component providers which record the
+// outcome of their configuration loads.
+type loadStatusProvider interface {
+	LoadStatus() flow.LoadStatus
+}
+
+// configStatusHandler returns the most recent successful and failed
+// configuration loads as JSON.
+func (f *FlowAPI) configStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		provider, ok := f.flow.(loadStatusProvider)
+		if !ok {
+			http.Error(w, "configuration load status is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		bb, err := json.Marshal(provider.LoadStatus())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/stretchr/testify/require"
+)
+
+type testStatusProvider struct {
+	testProvider
+	status flow.LoadStatus
+}
+
+func (p testStatusProvider) LoadStatus() flow.LoadStatus { return p.status }
+
+func TestConfigStatusHandler(t *testing.T) {
+	provider := testStatusProvider{status: flow.LoadStatus{
+		LastGood: &flow.LoadAttempt{
+			Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			SHA256: "aaaa",
+		},
+		LastFailure: &flow.LoadAttempt{
+			Time:       time.Date(2024, 1, 2, 4, 4, 5, 0, time.UTC),
+			SHA256:     "bbbb",
+			RolledBack: true,
+			Errors:     []component.ErrorDetail{{Severity: "error", Message: "module.git.shared: failed"}},
+		},
+	}}
+
+	r := mux.NewRouter()
+	NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v0/web/config/status")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	bb, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{
+		"lastGood": {"time": "2024-01-02T03:04:05Z", "sha256": "aaaa", "rolledBack": false},
+		"lastFailure": {
+			"time": "2024-01-02T04:04:05Z",
+			"sha256": "bbbb",
+			"rolledBack": true,
+			"errors": [{"severity": "error", "message": "module.git.shared: failed"}]
+		}
+	}`, string(bb))
+}
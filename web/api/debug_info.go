@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/river/encoding/riverjson"
+)
+
+// debugInfoHandler returns the debug info of a single component. The
+// component is addressed by its full path, which includes the IDs of all of
+// the modules it is nested in, such as
+// module.file.a/module.git.b/discovery.kubernetes.pods.
+//
+// Unlike the component details endpoint, only the debug info of the component
+// is retrieved, which avoids encoding the arguments and exports of components
+// which export large values.
+func (f *FlowAPI) debugInfoHandler() http.HandlerFunc {
+	type debugInfoJSON struct {
+		ID        string          `json:"id"`
+		ModuleID  string          `json:"moduleID"`
+		LocalID   string          `json:"localID"`
+		Name      string          `json:"name"`
+		DebugInfo json.RawMessage `json:"debugInfo"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := component.ParseID(mux.Vars(r)["id"])
+
+		info, err := f.flow.GetComponent(id, component.InfoOptions{
+			GetDebugInfo: true,
+		})
+		switch {
+		case errors.Is(err, component.ErrComponentNotFound):
+			http.Error(w, fmt.Sprintf("component %q not found", id), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		debugInfo, err := riverjson.MarshalBody(info.DebugInfo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		bb, err := json.Marshal(debugInfoJSON{
+			ID:        info.ID.String(),
+			ModuleID:  info.ID.ModuleID,
+			LocalID:   info.ID.LocalID,
+			Name:      info.ComponentName,
+			DebugInfo: debugInfo,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
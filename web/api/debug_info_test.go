@@ -0,0 +1,86 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/stretchr/testify/require"
+)
+
+type testDebugInfo struct {
+	Targets int `river:"targets,attr"`
+}
+
+type testProvider struct {
+	components map[component.ID]*component.Info
+}
+
+func (p testProvider) GetComponent(id component.ID, _ component.InfoOptions) (*component.Info, error) {
+	info, ok := p.components[id]
+	if !ok {
+		return nil, component.ErrComponentNotFound
+	}
+	return info, nil
+}
+
+func (p testProvider) ListComponents(moduleID string, _ component.InfoOptions) ([]*component.Info, error) {
+	var infos []*component.Info
+	for _, info := range p.components {
+		if info.ID.ModuleID == moduleID {
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func TestDebugInfoHandler(t *testing.T) {
+	nestedID := component.ID{
+		ModuleID: "module.file.a/module.git.b/module.http.c",
+		LocalID:  "discovery.kubernetes.pods",
+	}
+	provider := testProvider{components: map[component.ID]*component.Info{
+		nestedID: {
+			ID:            nestedID,
+			ComponentName: "discovery.kubernetes",
+			Label:         "pods",
+			DebugInfo:     testDebugInfo{Targets: 5},
+		},
+	}}
+
+	r := mux.NewRouter()
+	NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(t *testing.T, path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		bb, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(bb)
+	}
+
+	t.Run("Nested component", func(t *testing.T) {
+		status, body := get(t, "/api/v0/web/debuginfo/module.file.a/module.git.b/module.http.c/discovery.kubernetes.pods")
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `{
+			"id": "module.file.a/module.git.b/module.http.c/discovery.kubernetes.pods",
+			"moduleID": "module.file.a/module.git.b/module.http.c",
+			"localID": "discovery.kubernetes.pods",
+			"name": "discovery.kubernetes",
+			"debugInfo": [{"name": "targets", "type": "attr", "value": {"type": "number", "value": 5}}]
+		}`, body)
+	})
+
+	t.Run("Missing component", func(t *testing.T) {
+		status, body := get(t, "/api/v0/web/debuginfo/module.file.a/discovery.kubernetes.pods")
+		require.Equal(t, http.StatusNotFound, status)
+		require.Contains(t, body, `component "module.file.a/discovery.kubernetes.pods" not found`)
+	})
+}
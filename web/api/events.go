@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/agent/pkg/flow"
+)
+
+// eventsBufferSize is the number of events buffered for each client of the
+// events stream. Events are dropped for clients which fall further behind.
+const eventsBufferSize = 100
+
+// eventsProvider is implemented by component providers which publish events
+// of their components.
+type eventsProvider interface {
+	SubscribeEvents(buffer int) (<-chan flow.Event, func())
+}
+
+// eventsHandler streams the events of components as server-sent events, one
+// JSON-encoded event per message, until the client disconnects.
+func (f *FlowAPI) eventsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := f.flow.(eventsProvider)
+		if !ok {
+			http.Error(w, "events are not supported", http.StatusNotImplemented)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		events, unsubscribe := provider.SubscribeEvents(eventsBufferSize)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e := <-events:
+				bb, err := json.Marshal(e)
+				if err != nil {
+					return
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, bb); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
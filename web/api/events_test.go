@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/stretchr/testify/require"
+)
+
+type testEventsProvider struct {
+	testProvider
+	events chan flow.Event
+}
+
+func (p testEventsProvider) SubscribeEvents(int) (<-chan flow.Event, func()) {
+	return p.events, func() {}
+}
+
+func TestEventsHandler(t *testing.T) {
+	serve := func(t *testing.T, provider component.Provider) *httptest.Server {
+		t.Helper()
+
+		r := mux.NewRouter()
+		NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+		srv := httptest.NewServer(r)
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	t.Run("Supported", func(t *testing.T) {
+		provider := testEventsProvider{events: make(chan flow.Event, 1)}
+		srv := serve(t, provider)
+
+		resp, err := http.Get(srv.URL + "/api/v0/web/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		provider.events <- flow.Event{
+			Time:        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Type:        "started",
+			ComponentID: "module.file.a/local.file.b",
+			Health:      component.HealthTypeHealthy,
+			Message:     "started component",
+		}
+
+		sc := bufio.NewScanner(resp.Body)
+		require.True(t, sc.Scan())
+		require.Equal(t, "event: started", sc.Text())
+		require.True(t, sc.Scan())
+		data, ok := strings.CutPrefix(sc.Text(), "data: ")
+		require.True(t, ok)
+		require.JSONEq(t, `{
+			"time": "2024-01-02T03:04:05Z",
+			"type": "started",
+			"component_id": "module.file.a/local.file.b",
+			"health": "healthy",
+			"message": "started component"
+		}`, data)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		resp, err := http.Get(serve(t, testProvider{}).URL + "/api/v0/web/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+}
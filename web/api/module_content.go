@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/pkg/flow"
+)
+
+// moduleContentProvider is implemented by component providers which persist
+// the content their modules were loaded with.
+type moduleContentProvider interface {
+	ModuleContent(moduleID string) (*flow.ModuleContent, error)
+}
+
+// moduleContentHandler returns the content the requested module was last
+// loaded with as a River file. The hash of the content and when the module
+// was loaded with it are sent as headers.
+func (f *FlowAPI) moduleContentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := f.flow.(moduleContentProvider)
+		if !ok {
+			http.Error(w, "module content is not supported", http.StatusNotImplemented)
+			return
+		}
+
+		moduleID := mux.Vars(r)["moduleID"]
+		mc, err := provider.ModuleContent(moduleID)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			http.Error(w, fmt.Sprintf("no content persisted for module %q", moduleID), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(moduleID)+".river"))
+		w.Header().Set("Last-Modified", mc.Time.UTC().Format(http.TimeFormat))
+		w.Header().Set("X-Content-SHA256", mc.SHA256)
+		_, _ = w.Write(mc.Content)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/stretchr/testify/require"
+)
+
+type testContentProvider struct {
+	testProvider
+	contents map[string]*flow.ModuleContent
+}
+
+func (p testContentProvider) ModuleContent(moduleID string) (*flow.ModuleContent, error) {
+	mc, ok := p.contents[moduleID]
+	if !ok {
+		return nil, fmt.Errorf("reading content of %q: %w", moduleID, os.ErrNotExist)
+	}
+	return mc, nil
+}
+
+func TestModuleContentHandler(t *testing.T) {
+	provider := testContentProvider{contents: map[string]*flow.ModuleContent{
+		"module.git.a/module.string.b": {
+			Content: []byte(`prometheus.scrape "default" {}`),
+			SHA256:  "abcd",
+			Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+	}}
+
+	r := mux.NewRouter()
+	NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(t *testing.T, path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		bb, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(bb)
+	}
+
+	t.Run("Nested module", func(t *testing.T) {
+		resp, body := get(t, "/api/v0/web/modules/module.git.a/module.string.b/content")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, `prometheus.scrape "default" {}`, body)
+		require.Equal(t, "abcd", resp.Header.Get("X-Content-SHA256"))
+		require.Equal(t, "Tue, 02 Jan 2024 03:04:05 GMT", resp.Header.Get("Last-Modified"))
+		require.Equal(t, `attachment; filename="module.string.b.river"`, resp.Header.Get("Content-Disposition"))
+	})
+
+	t.Run("Missing module", func(t *testing.T) {
+		resp, body := get(t, "/api/v0/web/modules/module.git.missing/content")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		require.Contains(t, body, `no content persisted for module "module.git.missing"`)
+	})
+}
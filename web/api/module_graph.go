@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/agent/component"
+)
+
+// moduleGraphLoader is a module loader in the graph returned by
+// moduleGraphHandler.
+type moduleGraphLoader struct {
+	ID       string `json:"id"`
+	ModuleID string `json:"moduleID"`
+	LocalID  string `json:"localID"`
+	Name     string `json:"name"`
+
+	// Modules run by the loader.
+	Modules []string `json:"modules"`
+
+	// Loaders nested in the modules run by the loader.
+	Children []string `json:"children"`
+
+	// Nodes which reference the exports of the loader. They're evaluated
+	// again whenever the exports of the loader change.
+	Dependants []string `json:"dependants"`
+}
+
+// moduleGraphHandler returns the graph of module loaders, the module loaders
+// nested in their modules, and the nodes which depend on the exports of each
+// loader. The graph is returned as JSON, or in the DOT language if the format
+// query parameter is set to dot.
+func (f *FlowAPI) moduleGraphHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "dot" {
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+			return
+		}
+
+		loaders := buildModuleGraph(component.GetAllComponents(f.flow, component.InfoOptions{}))
+
+		if format == "dot" {
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			writeModuleGraphDOT(w, loaders)
+			return
+		}
+
+		bb, err := json.Marshal(loaders)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
+
+// buildModuleGraph returns the module loaders from infos, sorted by ID.
+func buildModuleGraph(infos []*component.Info) []*moduleGraphLoader {
+	var (
+		loaders []*moduleGraphLoader
+
+		// Loaders by the ID of the modules they run.
+		moduleOwners = make(map[string]*moduleGraphLoader)
+	)
+
+	for _, info := range infos {
+		if len(info.ModuleIDs) == 0 {
+			continue
+		}
+
+		loader := &moduleGraphLoader{
+			ID:         info.ID.String(),
+			ModuleID:   info.ID.ModuleID,
+			LocalID:    info.ID.LocalID,
+			Name:       info.ComponentName,
+			Modules:    append([]string{}, info.ModuleIDs...),
+			Children:   []string{},
+			Dependants: make([]string, 0, len(info.ReferencedBy)),
+		}
+		for _, ref := range info.ReferencedBy {
+			// References are local to the module of the loader.
+			if info.ID.ModuleID != "" {
+				ref = path.Join(info.ID.ModuleID, ref)
+			}
+			loader.Dependants = append(loader.Dependants, ref)
+		}
+		sort.Strings(loader.Modules)
+		sort.Strings(loader.Dependants)
+
+		loaders = append(loaders, loader)
+		for _, moduleID := range info.ModuleIDs {
+			moduleOwners[moduleID] = loader
+		}
+	}
+
+	for _, loader := range loaders {
+		if owner, ok := moduleOwners[loader.ModuleID]; ok {
+			owner.Children = append(owner.Children, loader.ID)
+		}
+	}
+	for _, loader := range loaders {
+		sort.Strings(loader.Children)
+	}
+
+	sort.Slice(loaders, func(i, j int) bool { return loaders[i].ID < loaders[j].ID })
+	if loaders == nil {
+		loaders = []*moduleGraphLoader{}
+	}
+	return loaders
+}
+
+// writeModuleGraphDOT writes loaders as a graph in the DOT language. Module
+// loaders are drawn as boxes, with bold edges to the loaders nested in their
+// modules and dashed edges to their dependants.
+func writeModuleGraphDOT(w io.Writer, loaders []*moduleGraphLoader) {
+	fmt.Fprintln(w, "digraph modules {")
+	for _, loader := range loaders {
+		fmt.Fprintf(w, "  %s [shape=box];\n", strconv.Quote(loader.ID))
+	}
+	for _, loader := range loaders {
+		for _, child := range loader.Children {
+			fmt.Fprintf(w, "  %s -> %s [style=bold];\n", strconv.Quote(loader.ID), strconv.Quote(child))
+		}
+		for _, dependant := range loader.Dependants {
+			fmt.Fprintf(w, "  %s -> %s [style=dashed];\n", strconv.Quote(loader.ID), strconv.Quote(dependant))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleGraphHandler(t *testing.T) {
+	loaderID := component.ID{LocalID: "module.file.scrape"}
+	nestedID := component.ID{ModuleID: "module.file.scrape", LocalID: "module.string.relabel"}
+	consumerID := component.ID{ModuleID: "module.file.scrape", LocalID: "prometheus.scrape.default"}
+
+	provider := testProvider{components: map[component.ID]*component.Info{
+		loaderID: {
+			ID:            loaderID,
+			ComponentName: "module.file",
+			ModuleIDs:     []string{"module.file.scrape"},
+			ReferencedBy:  []string{"prometheus.remote_write.default"},
+		},
+		nestedID: {
+			ID:            nestedID,
+			ComponentName: "module.string",
+			ModuleIDs:     []string{"module.file.scrape/module.string.relabel"},
+			ReferencedBy:  []string{"prometheus.scrape.default", "export.output"},
+		},
+		consumerID: {
+			ID:            consumerID,
+			ComponentName: "prometheus.scrape",
+			References:    []string{"module.string.relabel"},
+		},
+	}}
+
+	r := mux.NewRouter()
+	NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	get := func(t *testing.T, url string) (int, string) {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		bb, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(bb)
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		status, body := get(t, srv.URL+"/api/v0/web/modules/graph")
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `[
+			{
+				"id": "module.file.scrape",
+				"moduleID": "",
+				"localID": "module.file.scrape",
+				"name": "module.file",
+				"modules": ["module.file.scrape"],
+				"children": ["module.file.scrape/module.string.relabel"],
+				"dependants": ["prometheus.remote_write.default"]
+			},
+			{
+				"id": "module.file.scrape/module.string.relabel",
+				"moduleID": "module.file.scrape",
+				"localID": "module.string.relabel",
+				"name": "module.string",
+				"modules": ["module.file.scrape/module.string.relabel"],
+				"children": [],
+				"dependants": ["module.file.scrape/export.output", "module.file.scrape/prometheus.scrape.default"]
+			}
+		]`, body)
+	})
+
+	t.Run("DOT", func(t *testing.T) {
+		status, body := get(t, srv.URL+"/api/v0/web/modules/graph?format=dot")
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, `digraph modules {
+  "module.file.scrape" [shape=box];
+  "module.file.scrape/module.string.relabel" [shape=box];
+  "module.file.scrape" -> "module.file.scrape/module.string.relabel" [style=bold];
+  "module.file.scrape" -> "prometheus.remote_write.default" [style=dashed];
+  "module.file.scrape/module.string.relabel" -> "module.file.scrape/export.output" [style=dashed];
+  "module.file.scrape/module.string.relabel" -> "module.file.scrape/prometheus.scrape.default" [style=dashed];
+}
+`, body)
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		status, _ := get(t, srv.URL+"/api/v0/web/modules/graph?format=svg")
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+)
+
+// listModulesHandler returns the modules loaded by all running module
+// loaders, including module loaders nested in other modules. Each module is
+// described by the description of its module_meta block and the arguments it
+// accepts, so that the UI and other tooling can show what the module does.
+func (f *FlowAPI) listModulesHandler() http.HandlerFunc {
+	type moduleJSON struct {
+		ID       string      `json:"id"`
+		ModuleID string      `json:"moduleID"`
+		LocalID  string      `json:"localID"`
+		Name     string      `json:"name"`
+		Module   module.Info `json:"module"`
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		modules := []moduleJSON{}
+		for _, info := range component.GetAllComponents(f.flow, component.InfoOptions{}) {
+			loader, ok := info.Component.(module.InfoComponent)
+			if !ok {
+				continue
+			}
+			modules = append(modules, moduleJSON{
+				ID:       info.ID.String(),
+				ModuleID: info.ID.ModuleID,
+				LocalID:  info.ID.LocalID,
+				Name:     info.ComponentName,
+				Module:   loader.ModuleInfo(),
+			})
+		}
+
+		bb, err := json.Marshal(modules)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/module"
+	"github.com/stretchr/testify/require"
+)
+
+type testModuleLoader struct {
+	info module.Info
+}
+
+func (testModuleLoader) Run(context.Context) error        { return nil }
+func (testModuleLoader) Update(component.Arguments) error { return nil }
+func (l testModuleLoader) ModuleInfo() module.Info        { return l.info }
+
+func TestListModulesHandler(t *testing.T) {
+	loaderID := component.ID{LocalID: "module.file.scrape"}
+	nestedID := component.ID{ModuleID: "module.file.scrape", LocalID: "module.string.relabel"}
+	otherID := component.ID{ModuleID: "module.file.scrape", LocalID: "prometheus.scrape.default"}
+
+	provider := testProvider{components: map[component.ID]*component.Info{
+		loaderID: {
+			ID:            loaderID,
+			ComponentName: "module.file",
+			Label:         "scrape",
+			ModuleIDs:     []string{"module.file.scrape"},
+			Component: testModuleLoader{info: module.Info{
+				Name:        "scrape",
+				Description: "Scrapes the given targets.",
+				Arguments: []module.ArgumentMeta{
+					{Name: "targets", Comment: "Targets to scrape.", Type: "list"},
+				},
+				Exports: []string{"output"},
+			}},
+		},
+		nestedID: {
+			ID:            nestedID,
+			ComponentName: "module.string",
+			Label:         "relabel",
+			Component:     testModuleLoader{},
+		},
+		otherID: {
+			ID:            otherID,
+			ComponentName: "prometheus.scrape",
+			Label:         "default",
+		},
+	}}
+
+	r := mux.NewRouter()
+	NewFlowAPI(provider, nil).RegisterRoutes("/api/v0/web", r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v0/web/modules")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	bb, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `[
+		{
+			"id": "module.file.scrape",
+			"moduleID": "",
+			"localID": "module.file.scrape",
+			"name": "module.file",
+			"module": {
+				"name": "scrape",
+				"description": "Scrapes the given targets.",
+				"arguments": [{"name": "targets", "comment": "Targets to scrape.", "type": "list", "optional": false}],
+				"exports": ["output"]
+			}
+		},
+		{
+			"id": "module.file.scrape/module.string.relabel",
+			"moduleID": "module.file.scrape",
+			"localID": "module.string.relabel",
+			"name": "module.string",
+			"module": {}
+		}
+	]`, string(bb))
+}
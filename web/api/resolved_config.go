@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/river/token/builder"
+)
+
+// resolvedConfigHandler returns the effective configuration of the running
+// components as River text. Arguments are rendered with all of their values,
+// including defaults, and secrets are masked. Components running inside of
+// modules are rendered after the root module, each module in its own section.
+func (f *FlowAPI) resolvedConfigHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		infos := component.GetAllComponents(f.flow, component.InfoOptions{
+			GetArguments: true,
+		})
+
+		bb, err := resolvedConfig(infos)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(bb)
+	}
+}
+
+// resolvedConfig renders the set of components as River text, grouping
+// components by the module they are running in.
+func resolvedConfig(infos []*component.Info) ([]byte, error) {
+	var (
+		moduleIDs []string
+		byModule  = make(map[string][]*component.Info)
+	)
+	for _, info := range infos {
+		moduleID := info.ID.ModuleID
+		if _, ok := byModule[moduleID]; !ok {
+			moduleIDs = append(moduleIDs, moduleID)
+		}
+		byModule[moduleID] = append(byModule[moduleID], info)
+	}
+
+	// The root module has an empty ID, so it always sorts first.
+	sort.Strings(moduleIDs)
+
+	var buf bytes.Buffer
+	for i, moduleID := range moduleIDs {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if moduleID != "" {
+			fmt.Fprintf(&buf, "// Module %q\n\n", moduleID)
+		}
+
+		components := byModule[moduleID]
+		sort.Slice(components, func(i, j int) bool {
+			return components[i].ID.LocalID < components[j].ID.LocalID
+		})
+
+		file := builder.NewFile()
+		for _, info := range components {
+			block, err := resolvedBlock(info)
+			if err != nil {
+				return nil, fmt.Errorf("rendering %s: %w", info.ID, err)
+			}
+			file.Body().AppendBlock(block)
+		}
+		buf.Write(file.Bytes())
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// resolvedBlock builds a River block for the component described by info.
+func resolvedBlock(info *component.Info) (block *builder.Block, err error) {
+	// The builder panics on values it can't encode; report those as errors
+	// so a single bad component doesn't take down the endpoint.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	block = builder.NewBlock(strings.Split(info.ComponentName, "."), info.Label)
+	if info.Arguments != nil {
+		encodeAllFields(block.Body(), reflect.ValueOf(info.Arguments))
+	}
+	return block, nil
+}
+
+// encodeAllFields encodes the River-tagged fields of rv into body. Unlike
+// [builder.Body.AppendFrom], optional fields set to their default values are
+// kept so the result describes the full effective configuration.
+func encodeAllFields(body *builder.Body, rv reflect.Value) {
+	rv = derefValue(rv)
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, field := range riverFields(rv.Type()) {
+		fieldVal, err := rv.FieldByIndexErr(field.index)
+		if err != nil {
+			// Fields of nil squashed structs are skipped.
+			continue
+		}
+		encodeField(body, nil, field, fieldVal)
+	}
+}
+
+func encodeField(body *builder.Body, prefix []string, field riverField, fieldVal reflect.Value) {
+	name := append(append([]string{}, prefix...), field.name...)
+
+	switch field.kind {
+	case "attr":
+		body.SetAttributeValue(strings.Join(name, "."), fieldVal.Interface())
+
+	case "block":
+		fieldVal = derefValue(fieldVal)
+		if !fieldVal.IsValid() {
+			return
+		}
+
+		switch fieldVal.Kind() {
+		case reflect.Map:
+			inner := builder.NewBlock(name, "")
+			keys := fieldVal.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			for _, key := range keys {
+				inner.Body().SetAttributeValue(key.String(), fieldVal.MapIndex(key).Interface())
+			}
+			body.AppendBlock(inner)
+
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < fieldVal.Len(); i++ {
+				encodeField(body, prefix, field, fieldVal.Index(i))
+			}
+
+		case reflect.Struct:
+			inner := builder.NewBlock(name, blockLabel(fieldVal))
+			encodeAllFields(inner.Body(), fieldVal)
+			body.AppendBlock(inner)
+		}
+
+	case "enum":
+		fieldVal = derefValue(fieldVal)
+		if !fieldVal.IsValid() {
+			return
+		}
+
+		for i := 0; i < fieldVal.Len(); i++ {
+			elem := derefValue(fieldVal.Index(i))
+			if !elem.IsValid() {
+				continue
+			}
+
+			// Only the first non-zero field of an enum element is set.
+			for _, inner := range riverFields(elem.Type()) {
+				innerVal, err := elem.FieldByIndexErr(inner.index)
+				if err != nil || innerVal.IsZero() {
+					continue
+				}
+				encodeField(body, name, inner, innerVal)
+				break
+			}
+		}
+	}
+}
+
+// riverField is a River-tagged struct field.
+type riverField struct {
+	name  []string
+	index []int
+	kind  string // One of attr, block, enum, or label.
+}
+
+// riverFields returns the River-tagged fields of ty, flattening squashed
+// structs into their parent.
+func riverFields(ty reflect.Type) []riverField {
+	var fields []riverField
+
+	for i := 0; i < ty.NumField(); i++ {
+		field := ty.Field(i)
+		tag, ok := field.Tag.Lookup("river")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name, kind, _ := strings.Cut(tag, ",")
+		kind, _, _ = strings.Cut(kind, ",")
+
+		if kind == "squash" {
+			innerType := field.Type
+			for innerType.Kind() == reflect.Pointer {
+				innerType = innerType.Elem()
+			}
+			for _, inner := range riverFields(innerType) {
+				inner.index = append([]int{i}, inner.index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+
+		fields = append(fields, riverField{
+			name:  strings.Split(name, "."),
+			index: []int{i},
+			kind:  kind,
+		})
+	}
+
+	return fields
+}
+
+// blockLabel returns the value of the label field of rv, if any.
+func blockLabel(rv reflect.Value) string {
+	for _, field := range riverFields(rv.Type()) {
+		if field.kind != "label" {
+			continue
+		}
+		if labelVal, err := rv.FieldByIndexErr(field.index); err == nil {
+			return labelVal.String()
+		}
+	}
+	return ""
+}
+
+func derefValue(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+type testArguments struct {
+	URL      string            `river:"url,attr"`
+	Password rivertypes.Secret `river:"password,attr,optional"`
+	Timeout  time.Duration     `river:"timeout,attr,optional"`
+	Enabled  bool              `river:"enabled,attr,optional"`
+
+	Inner    testInner         `river:"inner,block,optional"`
+	Labels   map[string]string `river:"labels,block,optional"`
+	Squashed testSquashed      `river:",squash"`
+}
+
+type testInner struct {
+	Name string `river:"name,attr,optional"`
+}
+
+type testSquashed struct {
+	Retries int `river:"retries,attr,optional"`
+}
+
+func TestResolvedConfig(t *testing.T) {
+	infos := []*component.Info{
+		{
+			ID:            component.ID{ModuleID: "module.file.a", LocalID: "test.component.inner"},
+			ComponentName: "test.component",
+			Label:         "inner",
+			Arguments:     testArguments{URL: "http://inner"},
+		},
+		{
+			ID:            component.ID{LocalID: "test.component.root"},
+			ComponentName: "test.component",
+			Label:         "root",
+			Arguments: testArguments{
+				URL:      "http://root",
+				Password: "hunter2",
+				Timeout:  time.Minute,
+				Labels:   map[string]string{"zone": "c", "app": "a", "env": "b"},
+			},
+		},
+	}
+
+	bb, err := resolvedConfig(infos)
+	require.NoError(t, err)
+
+	expect := `test.component "root" {
+	url      = "http://root"
+	password = (secret)
+	timeout  = "1m0s"
+	enabled  = false
+
+	inner {
+		name = ""
+	}
+
+	labels {
+		app  = "a"
+		env  = "b"
+		zone = "c"
+	}
+	retries = 0
+}
+
+// Module "module.file.a"
+
+test.component "inner" {
+	url      = "http://inner"
+	password = (secret)
+	timeout  = "0s"
+	enabled  = false
+
+	inner {
+		name = ""
+	}
+
+	labels { }
+	retries = 0
+}
+`
+	require.Equal(t, expect, string(bb))
+}
@@ -3,12 +3,13 @@ import { Link } from 'react-router-dom';
 import { faCubes, faLink } from '@fortawesome/free-solid-svg-icons';
 import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
 
+import { transitiveDependants } from '../../utils/impact';
 import { partitionBody } from '../../utils/partition';
 
 import ComponentBody from './ComponentBody';
 import ComponentList from './ComponentList';
 import { HealthLabel } from './HealthLabel';
-import { ComponentDetail, ComponentInfo, PartitionedBody } from './types';
+import { ComponentDetail, ComponentInfo, ErrorDetail, PartitionedBody } from './types';
 
 import styles from './ComponentView.module.css';
 
@@ -22,6 +23,7 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
 
   const referencedBy = props.component.referencedBy.filter((id) => props.info[id] !== undefined).map((id) => props.info[id]);
   const referencesTo = props.component.referencesTo.filter((id) => props.info[id] !== undefined).map((id) => props.info[id]);
+  const impacted = transitiveDependants(props.component.localID, props.info);
 
   const argsPartition = partitionBody(props.component.arguments, 'Arguments');
   const exportsPartition = props.component.exports && partitionBody(props.component.exports, 'Exports');
@@ -58,6 +60,20 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           {argsPartition && partitionTOC(argsPartition)}
           {exportsPartition && partitionTOC(exportsPartition)}
           {debugPartition && partitionTOC(debugPartition)}
+          {props.component.evaluationErrors && props.component.evaluationErrors.length > 0 && (
+            <li>
+              <Link to="#evaluation-errors" target="_top">
+                Evaluation errors
+              </Link>
+            </li>
+          )}
+          {props.component.healthHistory && props.component.healthHistory.length > 0 && (
+            <li>
+              <Link to="#health-history" target="_top">
+                Health history
+              </Link>
+            </li>
+          )}
           {props.component.referencesTo.length > 0 && (
             <li>
               <Link to="#dependencies" target="_top">
@@ -72,6 +88,13 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
               </Link>
             </li>
           )}
+          {impacted.length > 0 && (
+            <li>
+              <Link to="#impact" target="_top">
+                Impact
+              </Link>
+            </li>
+          )}
           {props.component.moduleInfo && (
             <li>
               <Link to="#module" target="_top">
@@ -112,10 +135,47 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           </blockquote>
         )}
 
+        {props.component.evaluationErrors && props.component.evaluationErrors.length > 0 && (
+          <section id="evaluation-errors">
+            <h2>Evaluation errors</h2>
+            <div className={styles.sectionContent}>
+              <ul className={styles.evaluationErrors}>
+                {props.component.evaluationErrors.map((err, idx) => {
+                  return (
+                    <li key={idx.toString()}>
+                      {err.file && <span className={styles.errorPosition}>{errorPosition(err)}</span>}
+                      <span>{err.message}</span>
+                    </li>
+                  );
+                })}
+              </ul>
+            </div>
+          </section>
+        )}
+
         <ComponentBody partition={argsPartition} />
         {exportsPartition && <ComponentBody partition={exportsPartition} />}
         {debugPartition && <ComponentBody partition={debugPartition} />}
 
+        {props.component.healthHistory && props.component.healthHistory.length > 0 && (
+          <section id="health-history">
+            <h2>Health history</h2>
+            <div className={styles.sectionContent}>
+              <ul className={styles.healthHistory}>
+                {[...props.component.healthHistory].reverse().map((health, idx) => {
+                  return (
+                    <li key={idx.toString()}>
+                      <HealthLabel health={health.state} />
+                      {health.updatedTime && <span className={styles.updateTime}>{health.updatedTime}</span>}
+                      {health.message && <span>{health.message}</span>}
+                    </li>
+                  );
+                })}
+              </ul>
+            </div>
+          </section>
+        )}
+
         {props.component.referencesTo.length > 0 && (
           <section id="dependencies">
             <h2>Dependencies</h2>
@@ -134,6 +194,18 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           </section>
         )}
 
+        {impacted.length > 0 && (
+          <section id="impact">
+            <h2>Impact</h2>
+            <div className={styles.sectionContent}>
+              <p>
+                Components which are re-evaluated, directly or indirectly, when the exports of this component change.
+              </p>
+              <ComponentList components={impacted} moduleID={props.component.moduleID} />
+            </div>
+          </section>
+        )}
+
         {props.component.moduleInfo && (
           <section id="module">
             <h2>Module components</h2>
@@ -153,3 +225,17 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
 function pathJoin(paths: (string | undefined)[]): string {
   return paths.filter((p) => p && p !== '').join('/');
 }
+
+/**
+ * errorPosition returns the file:line:column position of an error.
+ */
+function errorPosition(err: ErrorDetail): string {
+  let pos = err.file || '';
+  if (err.line) {
+    pos += `:${err.line}`;
+    if (err.column) {
+      pos += `:${err.column}`;
+    }
+  }
+  return pos;
+}
@@ -68,6 +68,29 @@ export interface ComponentHealth {
   updatedTime?: string;
 }
 
+/**
+ * ErrorDetail is a structured error reported while evaluating a component.
+ * Errors which originate from River diagnostics include the position in the
+ * source they refer to.
+ */
+export interface ErrorDetail {
+  /** ID of the node which reported the error, if known. */
+  nodeID?: string;
+  /** Either "error" or "warning". */
+  severity: string;
+  message: string;
+
+  /** Name of the file or module the error refers to. */
+  file?: string;
+  line?: number;
+  column?: number;
+  endLine?: number;
+  endColumn?: number;
+
+  /** Optional value related to the error. */
+  value?: string;
+}
+
 /**
  * Known health states for a given component.
  */
@@ -82,6 +105,17 @@ export enum ComponentHealthState {
  * ComponentDetail adds detailed information to ComponentInfo.
  */
 export interface ComponentDetail extends ComponentInfo {
+  /**
+   * Recent transitions of the component's health, ordered from oldest to
+   * newest.
+   */
+  healthHistory?: ComponentHealth[];
+
+  /**
+   * Errors of the last evaluation of the component, if it failed.
+   */
+  evaluationErrors?: ErrorDetail[];
+
   /**
    * Arguments is the list of user-provided settings which configure an argument.
    * This is expected to be the *evaluated* arguments, not the raw expressions
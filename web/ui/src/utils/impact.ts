@@ -0,0 +1,32 @@
+import { ComponentInfo } from '../features/component/types';
+
+/**
+ * transitiveDependants returns all components which directly or indirectly
+ * reference the component with the given ID. These are the components which
+ * are re-evaluated when the component with the given ID changes its exports.
+ *
+ * Components are returned in breadth-first order, so direct dependants come
+ * first. The component itself is never included in the result.
+ */
+export function transitiveDependants(id: string, info: Record<string, ComponentInfo>): ComponentInfo[] {
+  const visited = new Set<string>([id]);
+  const res: ComponentInfo[] = [];
+
+  const queue = [...(info[id]?.referencedBy || [])];
+  while (queue.length > 0) {
+    const next = queue.shift() as string;
+    if (visited.has(next)) {
+      continue;
+    }
+    visited.add(next);
+
+    const component = info[next];
+    if (component === undefined) {
+      continue;
+    }
+    res.push(component);
+    queue.push(...component.referencedBy);
+  }
+
+  return res;
+}